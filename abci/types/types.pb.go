@@ -1037,9 +1037,10 @@ func (m *RequestOfferSnapshot) GetAppHash() []byte {
 
 // loads a snapshot chunk
 type RequestLoadSnapshotChunk struct {
-	Height uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Format uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
-	Chunk  uint32 `protobuf:"varint,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Height     uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Format     uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
+	Chunk      uint32 `protobuf:"varint,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	BaseHeight uint64 `protobuf:"varint,4,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *RequestLoadSnapshotChunk) Reset()         { *m = RequestLoadSnapshotChunk{} }
@@ -1096,6 +1097,13 @@ func (m *RequestLoadSnapshotChunk) GetChunk() uint32 {
 	return 0
 }
 
+func (m *RequestLoadSnapshotChunk) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 // Applies a snapshot chunk
 type RequestApplySnapshotChunk struct {
 	Index  uint32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
@@ -2839,11 +2847,12 @@ func (m *Evidence) GetTotalVotingPower() int64 {
 }
 
 type Snapshot struct {
-	Height   uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Format   uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
-	Chunks   uint32 `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
-	Hash     []byte `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
-	Metadata []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Height     uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Format     uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
+	Chunks     uint32 `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	Hash       []byte `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Metadata   []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	BaseHeight uint64 `protobuf:"varint,6,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *Snapshot) Reset()         { *m = Snapshot{} }
@@ -2914,6 +2923,13 @@ func (m *Snapshot) GetMetadata() []byte {
 	return nil
 }
 
+func (m *Snapshot) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("tendermint.abci.CheckTxType", CheckTxType_name, CheckTxType_value)
 	proto.RegisterEnum("tendermint.abci.EvidenceType", EvidenceType_name, EvidenceType_value)
@@ -2964,172 +2980,173 @@ func init() {
 func init() { proto.RegisterFile("tendermint/abci/types.proto", fileDescriptor_252557cfdd89a31a) }
 
 var fileDescriptor_252557cfdd89a31a = []byte{
-	// 2627 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x5a, 0xcd, 0x73, 0xdb, 0xc6,
-	0x15, 0xe7, 0x37, 0x89, 0x47, 0x91, 0xa2, 0xd6, 0x8a, 0x43, 0x33, 0xb6, 0xe4, 0xc0, 0xe3, 0x34,
-	0x76, 0x12, 0xa9, 0x91, 0xc7, 0xae, 0x33, 0xe9, 0x47, 0x44, 0x9a, 0x2e, 0x15, 0xab, 0x92, 0xba,
-	0xa2, 0x9d, 0x49, 0xdb, 0x18, 0x01, 0x89, 0x15, 0x89, 0x98, 0x04, 0x10, 0x60, 0x29, 0x4b, 0x39,
-	0x76, 0xda, 0x8b, 0xa7, 0x07, 0x1f, 0x7b, 0xc9, 0x4c, 0xff, 0x83, 0x5e, 0x7b, 0xea, 0xa9, 0x87,
-	0x1c, 0xda, 0x99, 0x1c, 0x7b, 0xe8, 0xa4, 0x1d, 0xfb, 0xd6, 0x7f, 0xa0, 0xa7, 0xce, 0x74, 0xf6,
-	0x03, 0x20, 0x40, 0x12, 0x22, 0xd5, 0xf4, 0xd6, 0xdb, 0xee, 0xc3, 0x7b, 0x8f, 0xbb, 0x6f, 0xf7,
-	0xfd, 0xf6, 0xb7, 0x6f, 0x09, 0xaf, 0x51, 0x62, 0x19, 0xc4, 0x1d, 0x9a, 0x16, 0xdd, 0xd4, 0x3b,
-	0x5d, 0x73, 0x93, 0x9e, 0x3a, 0xc4, 0xdb, 0x70, 0x5c, 0x9b, 0xda, 0x68, 0x79, 0xfc, 0x71, 0x83,
-	0x7d, 0xac, 0x5d, 0x09, 0x69, 0x77, 0xdd, 0x53, 0x87, 0xda, 0x9b, 0x8e, 0x6b, 0xdb, 0x47, 0x42,
-	0xbf, 0x76, 0x39, 0xf4, 0x99, 0xfb, 0x09, 0x7b, 0x8b, 0x7c, 0x95, 0xc6, 0x4f, 0xc8, 0xa9, 0xff,
-	0xf5, 0xca, 0x94, 0xad, 0xa3, 0xbb, 0xfa, 0xd0, 0xff, 0xbc, 0xde, 0xb3, 0xed, 0xde, 0x80, 0x6c,
-	0xf2, 0x5e, 0x67, 0x74, 0xb4, 0x49, 0xcd, 0x21, 0xf1, 0xa8, 0x3e, 0x74, 0xa4, 0xc2, 0x6a, 0xcf,
-	0xee, 0xd9, 0xbc, 0xb9, 0xc9, 0x5a, 0x42, 0xaa, 0xfe, 0x25, 0x0f, 0x79, 0x4c, 0x3e, 0x1f, 0x11,
-	0x8f, 0xa2, 0x2d, 0xc8, 0x90, 0x6e, 0xdf, 0xae, 0x26, 0xaf, 0x26, 0xdf, 0x2c, 0x6e, 0x5d, 0xde,
-	0x98, 0x98, 0xdc, 0x86, 0xd4, 0x6b, 0x76, 0xfb, 0x76, 0x2b, 0x81, 0xb9, 0x2e, 0xba, 0x0d, 0xd9,
-	0xa3, 0xc1, 0xc8, 0xeb, 0x57, 0x53, 0xdc, 0xe8, 0x4a, 0x9c, 0xd1, 0x7d, 0xa6, 0xd4, 0x4a, 0x60,
-	0xa1, 0xcd, 0x7e, 0xca, 0xb4, 0x8e, 0xec, 0x6a, 0xfa, 0xec, 0x9f, 0xda, 0xb1, 0x8e, 0xf8, 0x4f,
-	0x31, 0x5d, 0x54, 0x07, 0x30, 0x2d, 0x93, 0x6a, 0xdd, 0xbe, 0x6e, 0x5a, 0xd5, 0x0c, 0xb7, 0x7c,
-	0x3d, 0xde, 0xd2, 0xa4, 0x0d, 0xa6, 0xd8, 0x4a, 0x60, 0xc5, 0xf4, 0x3b, 0x6c, 0xb8, 0x9f, 0x8f,
-	0x88, 0x7b, 0x5a, 0xcd, 0x9e, 0x3d, 0xdc, 0x9f, 0x32, 0x25, 0x36, 0x5c, 0xae, 0x8d, 0x9a, 0x50,
-	0xec, 0x90, 0x9e, 0x69, 0x69, 0x9d, 0x81, 0xdd, 0x7d, 0x52, 0xcd, 0x71, 0x63, 0x35, 0xce, 0xb8,
-	0xce, 0x54, 0xeb, 0x4c, 0xb3, 0x95, 0xc0, 0xd0, 0x09, 0x7a, 0xe8, 0xfb, 0x50, 0xe8, 0xf6, 0x49,
-	0xf7, 0x89, 0x46, 0x4f, 0xaa, 0x79, 0xee, 0x63, 0x3d, 0xce, 0x47, 0x83, 0xe9, 0xb5, 0x4f, 0x5a,
-	0x09, 0x9c, 0xef, 0x8a, 0x26, 0x9b, 0xbf, 0x41, 0x06, 0xe6, 0x31, 0x71, 0x99, 0x7d, 0xe1, 0xec,
-	0xf9, 0xdf, 0x13, 0x9a, 0xdc, 0x83, 0x62, 0xf8, 0x1d, 0xf4, 0x23, 0x50, 0x88, 0x65, 0xc8, 0x69,
-	0x28, 0xdc, 0xc5, 0xd5, 0xd8, 0x75, 0xb6, 0x0c, 0x7f, 0x12, 0x05, 0x22, 0xdb, 0xe8, 0x2e, 0xe4,
-	0xba, 0xf6, 0x70, 0x68, 0xd2, 0x2a, 0x70, 0xeb, 0xb5, 0xd8, 0x09, 0x70, 0xad, 0x56, 0x02, 0x4b,
-	0x7d, 0xb4, 0x07, 0xe5, 0x81, 0xe9, 0x51, 0xcd, 0xb3, 0x74, 0xc7, 0xeb, 0xdb, 0xd4, 0xab, 0x16,
-	0xb9, 0x87, 0xeb, 0x71, 0x1e, 0x76, 0x4d, 0x8f, 0x1e, 0xfa, 0xca, 0xad, 0x04, 0x2e, 0x0d, 0xc2,
-	0x02, 0xe6, 0xcf, 0x3e, 0x3a, 0x22, 0x6e, 0xe0, 0xb0, 0xba, 0x74, 0xb6, 0xbf, 0x7d, 0xa6, 0xed,
-	0xdb, 0x33, 0x7f, 0x76, 0x58, 0x80, 0x7e, 0x0e, 0x17, 0x06, 0xb6, 0x6e, 0x04, 0xee, 0xb4, 0x6e,
-	0x7f, 0x64, 0x3d, 0xa9, 0x96, 0xb8, 0xd3, 0x1b, 0xb1, 0x83, 0xb4, 0x75, 0xc3, 0x77, 0xd1, 0x60,
-	0x06, 0xad, 0x04, 0x5e, 0x19, 0x4c, 0x0a, 0xd1, 0x63, 0x58, 0xd5, 0x1d, 0x67, 0x70, 0x3a, 0xe9,
-	0xbd, 0xcc, 0xbd, 0xdf, 0x8c, 0xf3, 0xbe, 0xcd, 0x6c, 0x26, 0xdd, 0x23, 0x7d, 0x4a, 0x5a, 0xcf,
-	0x43, 0xf6, 0x58, 0x1f, 0x8c, 0x88, 0xfa, 0x1d, 0x28, 0x86, 0xd2, 0x14, 0x55, 0x21, 0x3f, 0x24,
-	0x9e, 0xa7, 0xf7, 0x08, 0xcf, 0x6a, 0x05, 0xfb, 0x5d, 0xb5, 0x0c, 0x4b, 0xe1, 0xd4, 0x54, 0x9f,
-	0x27, 0x03, 0x4b, 0x96, 0x75, 0xcc, 0xf2, 0x98, 0xb8, 0x9e, 0x69, 0x5b, 0xbe, 0xa5, 0xec, 0xa2,
-	0x6b, 0x50, 0xe2, 0xfb, 0x47, 0xf3, 0xbf, 0xb3, 0xd4, 0xcf, 0xe0, 0x25, 0x2e, 0x7c, 0x24, 0x95,
-	0xd6, 0xa1, 0xe8, 0x6c, 0x39, 0x81, 0x4a, 0x9a, 0xab, 0x80, 0xb3, 0xe5, 0xf8, 0x0a, 0xaf, 0xc3,
-	0x12, 0x9b, 0x69, 0xa0, 0x91, 0xe1, 0x3f, 0x52, 0x64, 0x32, 0xa9, 0xa2, 0xfe, 0x39, 0x05, 0x95,
-	0xc9, 0x74, 0x46, 0x77, 0x21, 0xc3, 0x90, 0x4d, 0x82, 0x54, 0x6d, 0x43, 0xc0, 0xde, 0x86, 0x0f,
-	0x7b, 0x1b, 0x6d, 0x1f, 0xf6, 0xea, 0x85, 0xaf, 0xbe, 0x59, 0x4f, 0x3c, 0xff, 0xfb, 0x7a, 0x12,
-	0x73, 0x0b, 0x74, 0x89, 0x65, 0x9f, 0x6e, 0x5a, 0x9a, 0x69, 0xf0, 0x21, 0x2b, 0x2c, 0xb5, 0x74,
-	0xd3, 0xda, 0x31, 0xd0, 0x2e, 0x54, 0xba, 0xb6, 0xe5, 0x11, 0xcb, 0x1b, 0x79, 0x9a, 0x80, 0x55,
-	0x09, 0x4d, 0x91, 0x04, 0x13, 0x60, 0xdd, 0xf0, 0x35, 0x0f, 0xb8, 0x22, 0x5e, 0xee, 0x46, 0x05,
-	0xe8, 0x3e, 0xc0, 0xb1, 0x3e, 0x30, 0x0d, 0x9d, 0xda, 0xae, 0x57, 0xcd, 0x5c, 0x4d, 0xcf, 0xcc,
-	0xb2, 0x47, 0xbe, 0xca, 0x43, 0xc7, 0xd0, 0x29, 0xa9, 0x67, 0xd8, 0x70, 0x71, 0xc8, 0x12, 0xbd,
-	0x01, 0xcb, 0xba, 0xe3, 0x68, 0x1e, 0xd5, 0x29, 0xd1, 0x3a, 0xa7, 0x94, 0x78, 0x1c, 0xb6, 0x96,
-	0x70, 0x49, 0x77, 0x9c, 0x43, 0x26, 0xad, 0x33, 0x21, 0xba, 0x0e, 0x65, 0x86, 0x70, 0xa6, 0x3e,
-	0xd0, 0xfa, 0xc4, 0xec, 0xf5, 0x29, 0x07, 0xa8, 0x34, 0x2e, 0x49, 0x69, 0x8b, 0x0b, 0x55, 0x23,
-	0x58, 0x71, 0x8e, 0x6e, 0x08, 0x41, 0xc6, 0xd0, 0xa9, 0xce, 0x23, 0xb9, 0x84, 0x79, 0x9b, 0xc9,
-	0x1c, 0x9d, 0xf6, 0x65, 0x7c, 0x78, 0x1b, 0x5d, 0x84, 0x9c, 0x74, 0x9b, 0xe6, 0x6e, 0x65, 0x0f,
-	0xad, 0x42, 0xd6, 0x71, 0xed, 0x63, 0xc2, 0x97, 0xae, 0x80, 0x45, 0x47, 0xfd, 0x55, 0x0a, 0x56,
-	0xa6, 0x70, 0x90, 0xf9, 0xed, 0xeb, 0x5e, 0xdf, 0xff, 0x2d, 0xd6, 0x46, 0x77, 0x98, 0x5f, 0xdd,
-	0x20, 0xae, 0x3c, 0x3b, 0xaa, 0xd3, 0xa1, 0x6e, 0xf1, 0xef, 0x32, 0x34, 0x52, 0x1b, 0xed, 0x43,
-	0x65, 0xa0, 0x7b, 0x54, 0x13, 0xb8, 0xa2, 0x85, 0xce, 0x91, 0x69, 0x34, 0xdd, 0xd5, 0x7d, 0x24,
-	0x62, 0x9b, 0x5a, 0x3a, 0x2a, 0x0f, 0x22, 0x52, 0x84, 0x61, 0xb5, 0x73, 0xfa, 0x85, 0x6e, 0x51,
-	0xd3, 0x22, 0xda, 0xd4, 0xca, 0x5d, 0x9a, 0x72, 0xda, 0x3c, 0x36, 0x0d, 0x62, 0x75, 0xfd, 0x25,
-	0xbb, 0x10, 0x18, 0x07, 0x4b, 0xea, 0xa9, 0x18, 0xca, 0x51, 0x24, 0x47, 0x65, 0x48, 0xd1, 0x13,
-	0x19, 0x80, 0x14, 0x3d, 0x41, 0xdf, 0x85, 0x0c, 0x9b, 0x24, 0x9f, 0x7c, 0x79, 0xc6, 0x11, 0x28,
-	0xed, 0xda, 0xa7, 0x0e, 0xc1, 0x5c, 0x53, 0x55, 0x83, 0x74, 0x08, 0xd0, 0x7d, 0xd2, 0xab, 0x7a,
-	0x03, 0x96, 0x27, 0xe0, 0x3b, 0xb4, 0x7e, 0xc9, 0xf0, 0xfa, 0xa9, 0xcb, 0x50, 0x8a, 0x60, 0xb5,
-	0x7a, 0x11, 0x56, 0x67, 0x41, 0xaf, 0xda, 0x0f, 0xe4, 0x11, 0x08, 0x45, 0xb7, 0xa1, 0x10, 0x60,
-	0xaf, 0x48, 0xc7, 0xe9, 0x58, 0xf9, 0xca, 0x38, 0x50, 0x65, 0x79, 0xc8, 0xb6, 0x35, 0xdf, 0x0f,
-	0x29, 0x3e, 0xf0, 0xbc, 0xee, 0x38, 0x2d, 0xdd, 0xeb, 0xab, 0x9f, 0x42, 0x35, 0x0e, 0x57, 0x27,
-	0xa6, 0x91, 0x09, 0xb6, 0xe1, 0x45, 0xc8, 0x1d, 0xd9, 0xee, 0x50, 0xa7, 0xdc, 0x59, 0x09, 0xcb,
-	0x1e, 0xdb, 0x9e, 0x02, 0x63, 0xd3, 0x5c, 0x2c, 0x3a, 0xaa, 0x06, 0x97, 0x62, 0xb1, 0x95, 0x99,
-	0x98, 0x96, 0x41, 0x44, 0x3c, 0x4b, 0x58, 0x74, 0xc6, 0x8e, 0xc4, 0x60, 0x45, 0x87, 0xfd, 0xac,
-	0xc7, 0xe7, 0xca, 0xfd, 0x2b, 0x58, 0xf6, 0xd4, 0xdf, 0x15, 0xa0, 0x80, 0x89, 0xe7, 0x30, 0x4c,
-	0x40, 0x75, 0x50, 0xc8, 0x49, 0x97, 0x38, 0xd4, 0x87, 0xd1, 0xd9, 0xac, 0x41, 0x68, 0x37, 0x7d,
-	0x4d, 0x76, 0x64, 0x07, 0x66, 0xe8, 0x96, 0x64, 0x65, 0xf1, 0x04, 0x4b, 0x9a, 0x87, 0x69, 0xd9,
-	0x1d, 0x9f, 0x96, 0xa5, 0x63, 0x4f, 0x69, 0x61, 0x35, 0xc1, 0xcb, 0x6e, 0x49, 0x5e, 0x96, 0x99,
-	0xf3, 0x63, 0x11, 0x62, 0xd6, 0x88, 0x10, 0xb3, 0xec, 0x9c, 0x69, 0xc6, 0x30, 0xb3, 0x3b, 0x3e,
-	0x33, 0xcb, 0xcd, 0x19, 0xf1, 0x04, 0x35, 0xbb, 0x1f, 0xa5, 0x66, 0x82, 0x56, 0x5d, 0x8b, 0xb5,
-	0x8e, 0xe5, 0x66, 0x3f, 0x08, 0x71, 0xb3, 0x42, 0x2c, 0x31, 0x12, 0x4e, 0x66, 0x90, 0xb3, 0x46,
-	0x84, 0x9c, 0x29, 0x73, 0x62, 0x10, 0xc3, 0xce, 0x3e, 0x08, 0xb3, 0x33, 0x88, 0x25, 0x78, 0x72,
-	0xbd, 0x67, 0xd1, 0xb3, 0xf7, 0x02, 0x7a, 0x56, 0x8c, 0xe5, 0x97, 0x72, 0x0e, 0x93, 0xfc, 0x6c,
-	0x7f, 0x8a, 0x9f, 0x09, 0x3e, 0xf5, 0x46, 0xac, 0x8b, 0x39, 0x04, 0x6d, 0x7f, 0x8a, 0xa0, 0x95,
-	0xe6, 0x38, 0x9c, 0xc3, 0xd0, 0x7e, 0x31, 0x9b, 0xa1, 0xc5, 0x73, 0x28, 0x39, 0xcc, 0xc5, 0x28,
-	0x9a, 0x16, 0x43, 0xd1, 0x96, 0xb9, 0xfb, 0xb7, 0x62, 0xdd, 0x9f, 0x9f, 0xa3, 0xdd, 0x60, 0x27,
-	0xe4, 0x44, 0xce, 0x33, 0x94, 0x21, 0xae, 0x6b, 0xbb, 0x92, 0x6d, 0x89, 0x8e, 0xfa, 0x26, 0x3b,
-	0xb3, 0xc7, 0xf9, 0x7d, 0x06, 0x9f, 0xe3, 0x68, 0x1e, 0xca, 0x69, 0xf5, 0x0f, 0xc9, 0xb1, 0x2d,
-	0x3f, 0xe6, 0xc2, 0xe7, 0xbd, 0x22, 0xcf, 0xfb, 0x10, 0xcb, 0x4b, 0x45, 0x59, 0xde, 0x3a, 0x14,
-	0x19, 0x4a, 0x4f, 0x10, 0x38, 0xdd, 0x09, 0x08, 0xdc, 0x4d, 0x58, 0xe1, 0xc7, 0xb0, 0xe0, 0x82,
-	0x12, 0x9a, 0x33, 0xfc, 0x84, 0x59, 0x66, 0x1f, 0xc4, 0xe6, 0x14, 0x18, 0xfd, 0x0e, 0x5c, 0x08,
-	0xe9, 0x06, 0xe8, 0x2f, 0xd8, 0x4c, 0x25, 0xd0, 0xde, 0x96, 0xc7, 0xc0, 0x9f, 0x92, 0xe3, 0x08,
-	0x8d, 0x99, 0xdf, 0x2c, 0x92, 0x96, 0xfc, 0x1f, 0x91, 0xb4, 0xd4, 0x7f, 0x4d, 0xd2, 0xc2, 0xa7,
-	0x59, 0x3a, 0x7a, 0x9a, 0xfd, 0x2b, 0x39, 0x5e, 0x93, 0x80, 0x72, 0x75, 0x6d, 0x83, 0xc8, 0xf3,
-	0x85, 0xb7, 0x51, 0x05, 0xd2, 0x03, 0xbb, 0x27, 0x4f, 0x11, 0xd6, 0x64, 0x5a, 0x01, 0x08, 0x2b,
-	0x12, 0x63, 0x83, 0xa3, 0x29, 0xcb, 0x23, 0x2c, 0x8f, 0xa6, 0x0a, 0xa4, 0x9f, 0x10, 0x01, 0x99,
-	0x4b, 0x98, 0x35, 0x99, 0x1e, 0xdf, 0x64, 0x1c, 0x08, 0x97, 0xb0, 0xe8, 0xa0, 0xbb, 0xa0, 0xf0,
-	0x32, 0x84, 0x66, 0x3b, 0x9e, 0x44, 0xb7, 0xd7, 0xc2, 0x73, 0x15, 0xd5, 0x86, 0x8d, 0x03, 0xa6,
-	0xb3, 0xef, 0x78, 0xb8, 0xe0, 0xc8, 0x56, 0xe8, 0xd4, 0x55, 0x22, 0xe4, 0xef, 0x32, 0x28, 0x6c,
-	0xf4, 0x9e, 0xa3, 0x77, 0x09, 0x87, 0x2a, 0x05, 0x8f, 0x05, 0xea, 0x63, 0x40, 0xd3, 0x80, 0x8b,
-	0x5a, 0x90, 0x23, 0xc7, 0xc4, 0xa2, 0x6c, 0xd9, 0x58, 0xb8, 0x2f, 0xce, 0x60, 0x56, 0xc4, 0xa2,
-	0xf5, 0x2a, 0x0b, 0xf2, 0x3f, 0xbf, 0x59, 0xaf, 0x08, 0xed, 0xb7, 0xed, 0xa1, 0x49, 0xc9, 0xd0,
-	0xa1, 0xa7, 0x58, 0xda, 0xab, 0x7f, 0x4b, 0x31, 0x9a, 0x13, 0x01, 0xe3, 0x99, 0xb1, 0xf5, 0xb7,
-	0x7c, 0x2a, 0x44, 0x71, 0x17, 0x8b, 0xf7, 0x1a, 0x40, 0x4f, 0xf7, 0xb4, 0xa7, 0xba, 0x45, 0x89,
-	0x21, 0x83, 0x1e, 0x92, 0xa0, 0x1a, 0x14, 0x58, 0x6f, 0xe4, 0x11, 0x43, 0xb2, 0xed, 0xa0, 0x1f,
-	0x9a, 0x67, 0xfe, 0xdb, 0xcd, 0x33, 0x1a, 0xe5, 0xc2, 0x44, 0x94, 0x43, 0x14, 0x44, 0x09, 0x53,
-	0x10, 0x36, 0x36, 0xc7, 0x35, 0x6d, 0xd7, 0xa4, 0xa7, 0x7c, 0x69, 0xd2, 0x38, 0xe8, 0xb3, 0xcb,
-	0xdb, 0x90, 0x0c, 0x1d, 0xdb, 0x1e, 0x68, 0x02, 0x6e, 0x8a, 0xdc, 0x74, 0x49, 0x0a, 0x9b, 0x1c,
-	0x75, 0x7e, 0x9d, 0x1a, 0xe7, 0xdf, 0x98, 0x6a, 0xfe, 0xdf, 0x05, 0x58, 0xfd, 0x0d, 0xbf, 0x80,
-	0x46, 0x8f, 0x5b, 0x74, 0x08, 0x2b, 0x41, 0xfa, 0x6b, 0x23, 0x0e, 0x0b, 0xfe, 0x86, 0x5e, 0x14,
-	0x3f, 0x2a, 0xc7, 0x51, 0xb1, 0x87, 0x3e, 0x86, 0x57, 0x27, 0xb0, 0x2d, 0x70, 0x9d, 0x5a, 0x14,
-	0xe2, 0x5e, 0x89, 0x42, 0x9c, 0xef, 0x7a, 0x1c, 0xac, 0xf4, 0xb7, 0xcc, 0xba, 0x1d, 0x76, 0xa7,
-	0x09, 0xb3, 0x87, 0x99, 0xcb, 0x7f, 0x0d, 0x4a, 0x2e, 0xa1, 0xec, 0x9e, 0x1d, 0xb9, 0x35, 0x2e,
-	0x09, 0xa1, 0xbc, 0x8b, 0x1e, 0xc0, 0x2b, 0x33, 0x59, 0x04, 0xfa, 0x1e, 0x28, 0x63, 0x02, 0x92,
-	0x8c, 0xb9, 0x80, 0x05, 0x97, 0x8a, 0xb1, 0xae, 0xfa, 0xc7, 0xe4, 0xd8, 0x65, 0xf4, 0x9a, 0xd2,
-	0x84, 0x9c, 0x4b, 0xbc, 0xd1, 0x40, 0x5c, 0x1c, 0xca, 0x5b, 0xef, 0x2c, 0xc6, 0x3f, 0x98, 0x74,
-	0x34, 0xa0, 0x58, 0x1a, 0xab, 0x8f, 0x21, 0x27, 0x24, 0xa8, 0x08, 0xf9, 0x87, 0x7b, 0x0f, 0xf6,
-	0xf6, 0x3f, 0xda, 0xab, 0x24, 0x10, 0x40, 0x6e, 0xbb, 0xd1, 0x68, 0x1e, 0xb4, 0x2b, 0x49, 0xa4,
-	0x40, 0x76, 0xbb, 0xbe, 0x8f, 0xdb, 0x95, 0x14, 0x13, 0xe3, 0xe6, 0x87, 0xcd, 0x46, 0xbb, 0x92,
-	0x46, 0x2b, 0x50, 0x12, 0x6d, 0xed, 0xfe, 0x3e, 0xfe, 0xc9, 0x76, 0xbb, 0x92, 0x09, 0x89, 0x0e,
-	0x9b, 0x7b, 0xf7, 0x9a, 0xb8, 0x92, 0x55, 0xdf, 0x65, 0x37, 0x93, 0x18, 0xc6, 0x32, 0xbe, 0x83,
-	0x24, 0x43, 0x77, 0x10, 0xf5, 0xb7, 0x29, 0xa8, 0xc5, 0xd3, 0x10, 0xf4, 0xe1, 0xc4, 0xc4, 0xb7,
-	0xce, 0xc1, 0x61, 0x26, 0x66, 0x8f, 0xae, 0x43, 0xd9, 0x25, 0x47, 0x84, 0x76, 0xfb, 0x82, 0x16,
-	0x89, 0x23, 0xb3, 0x84, 0x4b, 0x52, 0xca, 0x8d, 0x3c, 0xa1, 0xf6, 0x19, 0xe9, 0x52, 0x4d, 0x60,
-	0x91, 0xd8, 0x74, 0x0a, 0x53, 0x63, 0xd2, 0x43, 0x21, 0x54, 0x3f, 0x3d, 0x57, 0x2c, 0x15, 0xc8,
-	0xe2, 0x66, 0x1b, 0x7f, 0x5c, 0x49, 0x23, 0x04, 0x65, 0xde, 0xd4, 0x0e, 0xf7, 0xb6, 0x0f, 0x0e,
-	0x5b, 0xfb, 0x2c, 0x96, 0x17, 0x60, 0xd9, 0x8f, 0xa5, 0x2f, 0xcc, 0xaa, 0x9f, 0x40, 0x39, 0x7a,
-	0xf7, 0x67, 0x21, 0x74, 0xed, 0x91, 0x65, 0xf0, 0x60, 0x64, 0xb1, 0xe8, 0xa0, 0xdb, 0x90, 0x3d,
-	0xb6, 0x45, 0x9a, 0xcd, 0xde, 0x6b, 0x8f, 0x6c, 0x4a, 0x42, 0xb5, 0x03, 0xa1, 0xad, 0x7e, 0x01,
-	0x59, 0x9e, 0x35, 0x2c, 0x03, 0xf8, 0x2d, 0x5e, 0x92, 0x2a, 0xd6, 0x46, 0x9f, 0x00, 0xe8, 0x94,
-	0xba, 0x66, 0x67, 0x34, 0x76, 0xbc, 0x3e, 0x3b, 0xeb, 0xb6, 0x7d, 0xbd, 0xfa, 0x65, 0x99, 0x7e,
-	0xab, 0x63, 0xd3, 0x50, 0x0a, 0x86, 0x1c, 0xaa, 0x7b, 0x50, 0x8e, 0xda, 0xfa, 0x34, 0x40, 0x8c,
-	0x21, 0x4a, 0x03, 0x04, 0xab, 0x93, 0x34, 0x20, 0x20, 0x11, 0x69, 0x51, 0xb1, 0xe1, 0x1d, 0xf5,
-	0x59, 0x12, 0x0a, 0xed, 0x13, 0xb9, 0x1e, 0x31, 0xc5, 0x82, 0xb1, 0x69, 0x2a, 0x7c, 0x35, 0x16,
-	0xd5, 0x87, 0x74, 0x50, 0xd3, 0xf8, 0x20, 0xd8, 0x71, 0x99, 0x45, 0x6f, 0x40, 0x7e, 0x71, 0x47,
-	0x66, 0xd9, 0xfb, 0xa0, 0x04, 0x98, 0xc9, 0xd8, 0xa9, 0x6e, 0x18, 0x2e, 0xf1, 0x3c, 0xb9, 0xef,
-	0xfd, 0x2e, 0xaf, 0x3d, 0xd9, 0x4f, 0xe5, 0xe5, 0x3b, 0x8d, 0x45, 0x47, 0x35, 0x60, 0x79, 0x02,
-	0x70, 0xd1, 0xfb, 0x90, 0x77, 0x46, 0x1d, 0xcd, 0x0f, 0xcf, 0xc4, 0x5b, 0x83, 0xcf, 0x7b, 0x46,
-	0x9d, 0x81, 0xd9, 0x7d, 0x40, 0x4e, 0xfd, 0xc1, 0x38, 0xa3, 0xce, 0x03, 0x11, 0x45, 0xf1, 0x2b,
-	0xa9, 0xf0, 0xaf, 0x1c, 0x43, 0xc1, 0xdf, 0x14, 0xe8, 0x87, 0xa0, 0x04, 0x58, 0x1e, 0x94, 0x24,
-	0x63, 0x0f, 0x01, 0xe9, 0x7e, 0x6c, 0xc2, 0x48, 0xb4, 0x67, 0xf6, 0x2c, 0x62, 0x68, 0x63, 0x7e,
-	0xcc, 0x7f, 0xad, 0x80, 0x97, 0xc5, 0x87, 0x5d, 0x9f, 0x1c, 0xab, 0xff, 0x4e, 0x42, 0xc1, 0x2f,
-	0x3d, 0xa1, 0x77, 0x43, 0xfb, 0xae, 0x3c, 0xe3, 0xa2, 0xee, 0x2b, 0x8e, 0xcb, 0x47, 0xd1, 0xb1,
-	0xa6, 0xce, 0x3f, 0xd6, 0xb8, 0x3a, 0xa0, 0x5f, 0x91, 0xcd, 0x9c, 0xbb, 0x22, 0xfb, 0x36, 0x20,
-	0x6a, 0x53, 0x7d, 0xa0, 0x1d, 0xdb, 0xd4, 0xb4, 0x7a, 0x9a, 0x08, 0xb6, 0xe0, 0x02, 0x15, 0xfe,
-	0xe5, 0x11, 0xff, 0x70, 0xc0, 0xe3, 0xfe, 0xcb, 0x24, 0x14, 0x02, 0x50, 0x3f, 0x6f, 0x35, 0xe8,
-	0x22, 0xe4, 0x24, 0x6e, 0x89, 0x72, 0x90, 0xec, 0x05, 0x85, 0xc9, 0x4c, 0xa8, 0x30, 0x59, 0x83,
-	0xc2, 0x90, 0x50, 0x9d, 0x9f, 0x6c, 0xe2, 0x8a, 0x12, 0xf4, 0x6f, 0xbe, 0x07, 0xc5, 0x50, 0x61,
-	0x8e, 0x65, 0xde, 0x5e, 0xf3, 0xa3, 0x4a, 0xa2, 0x96, 0x7f, 0xf6, 0xe5, 0xd5, 0xf4, 0x1e, 0x79,
-	0xca, 0xf6, 0x2c, 0x6e, 0x36, 0x5a, 0xcd, 0xc6, 0x83, 0x4a, 0xb2, 0x56, 0x7c, 0xf6, 0xe5, 0xd5,
-	0x3c, 0x26, 0xbc, 0x48, 0x70, 0xb3, 0x05, 0x4b, 0xe1, 0x55, 0x89, 0x42, 0x1f, 0x82, 0xf2, 0xbd,
-	0x87, 0x07, 0xbb, 0x3b, 0x8d, 0xed, 0x76, 0x53, 0x7b, 0xb4, 0xdf, 0x6e, 0x56, 0x92, 0xe8, 0x55,
-	0xb8, 0xb0, 0xbb, 0xf3, 0xe3, 0x56, 0x5b, 0x6b, 0xec, 0xee, 0x34, 0xf7, 0xda, 0xda, 0x76, 0xbb,
-	0xbd, 0xdd, 0x78, 0x50, 0x49, 0x6d, 0xfd, 0x5e, 0x81, 0xe5, 0xed, 0x7a, 0x63, 0x87, 0xc1, 0xb6,
-	0xd9, 0xd5, 0xf9, 0xfd, 0xb1, 0x01, 0x19, 0x7e, 0x43, 0x3c, 0xf3, 0xd9, 0xae, 0x76, 0x76, 0xf9,
-	0x08, 0xdd, 0x87, 0x2c, 0xbf, 0x3c, 0xa2, 0xb3, 0xdf, 0xf1, 0x6a, 0x73, 0xea, 0x49, 0x6c, 0x30,
-	0x3c, 0x3d, 0xce, 0x7c, 0xd8, 0xab, 0x9d, 0x5d, 0x5e, 0x42, 0x18, 0x94, 0x31, 0xf9, 0x9c, 0xff,
-	0xd0, 0x55, 0x5b, 0x00, 0x6c, 0xd0, 0x2e, 0xe4, 0xfd, 0xfb, 0xc2, 0xbc, 0xa7, 0xb7, 0xda, 0xdc,
-	0xfa, 0x0f, 0x0b, 0x97, 0xb8, 0xd7, 0x9d, 0xfd, 0x8e, 0x58, 0x9b, 0x53, 0xcc, 0x42, 0x3b, 0x90,
-	0x93, 0x84, 0x6a, 0xce, 0x73, 0x5a, 0x6d, 0x5e, 0x3d, 0x87, 0x05, 0x6d, 0x7c, 0x63, 0x9e, 0xff,
-	0x3a, 0x5a, 0x5b, 0xa0, 0x4e, 0x87, 0x1e, 0x02, 0x84, 0x6e, 0x71, 0x0b, 0x3c, 0x7b, 0xd6, 0x16,
-	0xa9, 0xbf, 0xa1, 0x7d, 0x28, 0x04, 0xa4, 0x7a, 0xee, 0x23, 0x64, 0x6d, 0x7e, 0x21, 0x0c, 0x3d,
-	0x86, 0x52, 0x94, 0x4c, 0x2e, 0xf6, 0xb4, 0x58, 0x5b, 0xb0, 0xc2, 0xc5, 0xfc, 0x47, 0x99, 0xe5,
-	0x62, 0x4f, 0x8d, 0xb5, 0x05, 0x0b, 0x5e, 0xe8, 0x33, 0x58, 0x99, 0x66, 0x7e, 0x8b, 0xbf, 0x3c,
-	0xd6, 0xce, 0x51, 0x02, 0x43, 0x43, 0x40, 0x33, 0x18, 0xe3, 0x39, 0x1e, 0x22, 0x6b, 0xe7, 0xa9,
-	0x88, 0xd5, 0x9b, 0x5f, 0xbd, 0x58, 0x4b, 0x7e, 0xfd, 0x62, 0x2d, 0xf9, 0x8f, 0x17, 0x6b, 0xc9,
-	0xe7, 0x2f, 0xd7, 0x12, 0x5f, 0xbf, 0x5c, 0x4b, 0xfc, 0xf5, 0xe5, 0x5a, 0xe2, 0x67, 0x6f, 0xf5,
-	0x4c, 0xda, 0x1f, 0x75, 0x36, 0xba, 0xf6, 0x70, 0x33, 0xfc, 0x0f, 0x87, 0x59, 0xff, 0xba, 0xe8,
-	0xe4, 0xf8, 0xa1, 0x72, 0xeb, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x92, 0xa5, 0x39, 0xcc, 0x95,
-	0x21, 0x00, 0x00,
+	// 2642 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x5a, 0xcd, 0x73, 0x23, 0x47,
+	0x15, 0xd7, 0xb7, 0x34, 0x4f, 0x9f, 0xee, 0x75, 0x1c, 0xad, 0xb2, 0xb1, 0x37, 0x93, 0x4a, 0x48,
+	0x36, 0x89, 0x4d, 0x9c, 0x4a, 0x48, 0x2a, 0x7c, 0xc4, 0x52, 0xb4, 0xc8, 0x59, 0x63, 0x9b, 0xb6,
+	0x76, 0x53, 0x01, 0xb2, 0xc3, 0x48, 0xd3, 0x96, 0x26, 0x2b, 0xcd, 0x4c, 0x66, 0x46, 0x8e, 0x9d,
+	0x1b, 0x55, 0x70, 0xd9, 0xe2, 0xb0, 0x47, 0x2e, 0xa9, 0xa2, 0x8a, 0x3f, 0x80, 0x2b, 0x27, 0x4e,
+	0x1c, 0x72, 0x80, 0xaa, 0x1c, 0x39, 0x50, 0x81, 0xda, 0xbd, 0xf1, 0x0f, 0x70, 0xa2, 0x8a, 0xea,
+	0xaf, 0xd1, 0x8c, 0xa4, 0xb1, 0x64, 0xc2, 0x8d, 0x5b, 0xf7, 0xd3, 0x7b, 0x6f, 0xba, 0x5f, 0xf7,
+	0xfb, 0xf5, 0xaf, 0x5f, 0x0b, 0x9e, 0xf1, 0x89, 0x65, 0x10, 0x77, 0x6c, 0x5a, 0xfe, 0x8e, 0xde,
+	0xeb, 0x9b, 0x3b, 0xfe, 0x85, 0x43, 0xbc, 0x6d, 0xc7, 0xb5, 0x7d, 0x1b, 0x55, 0xa7, 0x3f, 0x6e,
+	0xd3, 0x1f, 0x1b, 0xcf, 0x86, 0xb4, 0xfb, 0xee, 0x85, 0xe3, 0xdb, 0x3b, 0x8e, 0x6b, 0xdb, 0xa7,
+	0x5c, 0xbf, 0x71, 0x23, 0xf4, 0x33, 0xf3, 0x13, 0xf6, 0xd6, 0xb8, 0x31, 0x6f, 0xfc, 0x80, 0x5c,
+	0xc8, 0x5f, 0x9f, 0x9d, 0xb3, 0x75, 0x74, 0x57, 0x1f, 0xcb, 0x9f, 0xb7, 0x06, 0xb6, 0x3d, 0x18,
+	0x91, 0x1d, 0xd6, 0xeb, 0x4d, 0x4e, 0x77, 0x7c, 0x73, 0x4c, 0x3c, 0x5f, 0x1f, 0x3b, 0x42, 0x61,
+	0x7d, 0x60, 0x0f, 0x6c, 0xd6, 0xdc, 0xa1, 0x2d, 0x2e, 0x55, 0xff, 0x92, 0x87, 0x3c, 0x26, 0x9f,
+	0x4e, 0x88, 0xe7, 0xa3, 0x5d, 0xc8, 0x90, 0xfe, 0xd0, 0xae, 0x27, 0x6f, 0x26, 0x5f, 0x2a, 0xee,
+	0xde, 0xd8, 0x9e, 0x99, 0xdc, 0xb6, 0xd0, 0x6b, 0xf7, 0x87, 0x76, 0x27, 0x81, 0x99, 0x2e, 0x7a,
+	0x13, 0xb2, 0xa7, 0xa3, 0x89, 0x37, 0xac, 0xa7, 0x98, 0xd1, 0xb3, 0x71, 0x46, 0xb7, 0xa9, 0x52,
+	0x27, 0x81, 0xb9, 0x36, 0xfd, 0x94, 0x69, 0x9d, 0xda, 0xf5, 0xf4, 0xe5, 0x9f, 0xda, 0xb7, 0x4e,
+	0xd9, 0xa7, 0xa8, 0x2e, 0x6a, 0x02, 0x98, 0x96, 0xe9, 0x6b, 0xfd, 0xa1, 0x6e, 0x5a, 0xf5, 0x0c,
+	0xb3, 0x7c, 0x2e, 0xde, 0xd2, 0xf4, 0x5b, 0x54, 0xb1, 0x93, 0xc0, 0x8a, 0x29, 0x3b, 0x74, 0xb8,
+	0x9f, 0x4e, 0x88, 0x7b, 0x51, 0xcf, 0x5e, 0x3e, 0xdc, 0x1f, 0x53, 0x25, 0x3a, 0x5c, 0xa6, 0x8d,
+	0xda, 0x50, 0xec, 0x91, 0x81, 0x69, 0x69, 0xbd, 0x91, 0xdd, 0x7f, 0x50, 0xcf, 0x31, 0x63, 0x35,
+	0xce, 0xb8, 0x49, 0x55, 0x9b, 0x54, 0xb3, 0x93, 0xc0, 0xd0, 0x0b, 0x7a, 0xe8, 0xbb, 0x50, 0xe8,
+	0x0f, 0x49, 0xff, 0x81, 0xe6, 0x9f, 0xd7, 0xf3, 0xcc, 0xc7, 0x56, 0x9c, 0x8f, 0x16, 0xd5, 0xeb,
+	0x9e, 0x77, 0x12, 0x38, 0xdf, 0xe7, 0x4d, 0x3a, 0x7f, 0x83, 0x8c, 0xcc, 0x33, 0xe2, 0x52, 0xfb,
+	0xc2, 0xe5, 0xf3, 0x7f, 0x9f, 0x6b, 0x32, 0x0f, 0x8a, 0x21, 0x3b, 0xe8, 0x07, 0xa0, 0x10, 0xcb,
+	0x10, 0xd3, 0x50, 0x98, 0x8b, 0x9b, 0xb1, 0xeb, 0x6c, 0x19, 0x72, 0x12, 0x05, 0x22, 0xda, 0xe8,
+	0x6d, 0xc8, 0xf5, 0xed, 0xf1, 0xd8, 0xf4, 0xeb, 0xc0, 0xac, 0x37, 0x63, 0x27, 0xc0, 0xb4, 0x3a,
+	0x09, 0x2c, 0xf4, 0xd1, 0x21, 0x54, 0x46, 0xa6, 0xe7, 0x6b, 0x9e, 0xa5, 0x3b, 0xde, 0xd0, 0xf6,
+	0xbd, 0x7a, 0x91, 0x79, 0x78, 0x21, 0xce, 0xc3, 0x81, 0xe9, 0xf9, 0x27, 0x52, 0xb9, 0x93, 0xc0,
+	0xe5, 0x51, 0x58, 0x40, 0xfd, 0xd9, 0xa7, 0xa7, 0xc4, 0x0d, 0x1c, 0xd6, 0x4b, 0x97, 0xfb, 0x3b,
+	0xa2, 0xda, 0xd2, 0x9e, 0xfa, 0xb3, 0xc3, 0x02, 0xf4, 0x53, 0xb8, 0x36, 0xb2, 0x75, 0x23, 0x70,
+	0xa7, 0xf5, 0x87, 0x13, 0xeb, 0x41, 0xbd, 0xcc, 0x9c, 0xbe, 0x1c, 0x3b, 0x48, 0x5b, 0x37, 0xa4,
+	0x8b, 0x16, 0x35, 0xe8, 0x24, 0xf0, 0xda, 0x68, 0x56, 0x88, 0xee, 0xc3, 0xba, 0xee, 0x38, 0xa3,
+	0x8b, 0x59, 0xef, 0x15, 0xe6, 0xfd, 0x56, 0x9c, 0xf7, 0x3d, 0x6a, 0x33, 0xeb, 0x1e, 0xe9, 0x73,
+	0xd2, 0x66, 0x1e, 0xb2, 0x67, 0xfa, 0x68, 0x42, 0xd4, 0x6f, 0x41, 0x31, 0x94, 0xa6, 0xa8, 0x0e,
+	0xf9, 0x31, 0xf1, 0x3c, 0x7d, 0x40, 0x58, 0x56, 0x2b, 0x58, 0x76, 0xd5, 0x0a, 0x94, 0xc2, 0xa9,
+	0xa9, 0x3e, 0x4a, 0x42, 0x31, 0x94, 0x75, 0xd4, 0xf2, 0x8c, 0xb8, 0x9e, 0x69, 0x5b, 0xd2, 0x52,
+	0x74, 0xd1, 0xf3, 0x50, 0x66, 0xfb, 0x47, 0x93, 0xbf, 0xd3, 0xd4, 0xcf, 0xe0, 0x12, 0x13, 0xde,
+	0x13, 0x4a, 0x5b, 0x50, 0x74, 0x76, 0x9d, 0x40, 0x25, 0xcd, 0x54, 0xc0, 0xd9, 0x75, 0xa4, 0xc2,
+	0x73, 0x50, 0xa2, 0x33, 0x0d, 0x34, 0x32, 0xec, 0x23, 0x45, 0x2a, 0x13, 0x2a, 0xea, 0x9f, 0x53,
+	0x50, 0x9b, 0x4d, 0x67, 0xf4, 0x36, 0x64, 0x28, 0xb2, 0x09, 0x90, 0x6a, 0x6c, 0x73, 0xd8, 0xdb,
+	0x96, 0xb0, 0xb7, 0xdd, 0x95, 0xb0, 0xd7, 0x2c, 0x7c, 0xf9, 0xf5, 0x56, 0xe2, 0xd1, 0xdf, 0xb7,
+	0x92, 0x98, 0x59, 0xa0, 0xeb, 0x34, 0xfb, 0x74, 0xd3, 0xd2, 0x4c, 0x83, 0x0d, 0x59, 0xa1, 0xa9,
+	0xa5, 0x9b, 0xd6, 0xbe, 0x81, 0x0e, 0xa0, 0xd6, 0xb7, 0x2d, 0x8f, 0x58, 0xde, 0xc4, 0xd3, 0x38,
+	0xac, 0xd6, 0xd3, 0xf3, 0x09, 0xc6, 0xc1, 0xba, 0x25, 0x35, 0x8f, 0x99, 0x22, 0xae, 0xf6, 0xa3,
+	0x02, 0x74, 0x1b, 0xe0, 0x4c, 0x1f, 0x99, 0x86, 0xee, 0xdb, 0xae, 0x57, 0xcf, 0xdc, 0x4c, 0x2f,
+	0xcc, 0xb2, 0x7b, 0x52, 0xe5, 0xae, 0x63, 0xe8, 0x3e, 0x69, 0x66, 0xe8, 0x70, 0x71, 0xc8, 0x12,
+	0xbd, 0x08, 0x55, 0xdd, 0x71, 0x34, 0xcf, 0xd7, 0x7d, 0xa2, 0xf5, 0x2e, 0x7c, 0xe2, 0x31, 0xd8,
+	0x2a, 0xe1, 0xb2, 0xee, 0x38, 0x27, 0x54, 0xda, 0xa4, 0x42, 0xf4, 0x02, 0x54, 0x28, 0xc2, 0x99,
+	0xfa, 0x48, 0x1b, 0x12, 0x73, 0x30, 0xf4, 0x19, 0x40, 0xa5, 0x71, 0x59, 0x48, 0x3b, 0x4c, 0xa8,
+	0x1a, 0x50, 0x0a, 0xa3, 0x1b, 0x42, 0x90, 0x31, 0x74, 0x5f, 0x67, 0x91, 0x2c, 0x61, 0xd6, 0xa6,
+	0x32, 0x47, 0xf7, 0x87, 0x22, 0x3e, 0xac, 0x8d, 0x36, 0x20, 0x27, 0xdc, 0xa6, 0x99, 0x5b, 0xd1,
+	0x43, 0xeb, 0x90, 0x75, 0x5c, 0xfb, 0x8c, 0xb0, 0xa5, 0x2b, 0x60, 0xde, 0x51, 0x7f, 0x99, 0x82,
+	0xb5, 0x39, 0x1c, 0xa4, 0x7e, 0x87, 0xba, 0x37, 0x94, 0xdf, 0xa2, 0x6d, 0xf4, 0x16, 0xf5, 0xab,
+	0x1b, 0xc4, 0x15, 0x67, 0x47, 0x7d, 0x3e, 0xd4, 0x1d, 0xf6, 0xbb, 0x08, 0x8d, 0xd0, 0x46, 0x47,
+	0x50, 0x1b, 0xe9, 0x9e, 0xaf, 0x71, 0x5c, 0xd1, 0x42, 0xe7, 0xc8, 0x3c, 0x9a, 0x1e, 0xe8, 0x12,
+	0x89, 0xe8, 0xa6, 0x16, 0x8e, 0x2a, 0xa3, 0x88, 0x14, 0x61, 0x58, 0xef, 0x5d, 0x7c, 0xae, 0x5b,
+	0xbe, 0x69, 0x11, 0x6d, 0x6e, 0xe5, 0xae, 0xcf, 0x39, 0x6d, 0x9f, 0x99, 0x06, 0xb1, 0xfa, 0x72,
+	0xc9, 0xae, 0x05, 0xc6, 0xc1, 0x92, 0x7a, 0x2a, 0x86, 0x4a, 0x14, 0xc9, 0x51, 0x05, 0x52, 0xfe,
+	0xb9, 0x08, 0x40, 0xca, 0x3f, 0x47, 0xdf, 0x86, 0x0c, 0x9d, 0x24, 0x9b, 0x7c, 0x65, 0xc1, 0x11,
+	0x28, 0xec, 0xba, 0x17, 0x0e, 0xc1, 0x4c, 0x53, 0x55, 0xa1, 0x36, 0x8b, 0xee, 0xb3, 0x5e, 0xd5,
+	0x97, 0xa1, 0x3a, 0x03, 0xdf, 0xa1, 0xf5, 0x4b, 0x86, 0xd7, 0x4f, 0xad, 0x42, 0x39, 0x82, 0xd5,
+	0xea, 0x06, 0xac, 0x2f, 0x82, 0x5e, 0x75, 0x08, 0xeb, 0x8b, 0x20, 0x14, 0xbd, 0x09, 0x85, 0x00,
+	0x7b, 0x79, 0x3a, 0xce, 0xc7, 0x4a, 0x2a, 0xe3, 0x40, 0x95, 0xe6, 0x21, 0xdd, 0xd6, 0x6c, 0x3f,
+	0xa4, 0xd8, 0xc0, 0xf3, 0xba, 0xe3, 0x74, 0x74, 0x6f, 0xa8, 0xfe, 0x22, 0x09, 0xf5, 0x38, 0x60,
+	0x9d, 0x99, 0x47, 0x26, 0xd8, 0x87, 0x1b, 0x90, 0x3b, 0xb5, 0xdd, 0xb1, 0xee, 0x33, 0x6f, 0x65,
+	0x2c, 0x7a, 0x74, 0x7f, 0x72, 0x90, 0x4d, 0x33, 0x31, 0xef, 0x50, 0x60, 0xea, 0xe9, 0x1e, 0x91,
+	0x99, 0x92, 0xe1, 0xc0, 0x44, 0x45, 0x22, 0x4d, 0x34, 0xb8, 0x1e, 0x8b, 0xbe, 0xd4, 0xa7, 0x69,
+	0x19, 0x84, 0x47, 0xbc, 0x8c, 0x79, 0x67, 0xfa, 0x25, 0x3e, 0x1d, 0xf1, 0xa5, 0x0d, 0xc8, 0x79,
+	0x2c, 0x1a, 0x6c, 0x00, 0x0a, 0x16, 0x3d, 0xf5, 0xb7, 0x05, 0x28, 0x60, 0xe2, 0x39, 0x14, 0x35,
+	0x50, 0x13, 0x14, 0x72, 0xde, 0x27, 0x8e, 0x2f, 0x81, 0x76, 0x31, 0xaf, 0xe0, 0xda, 0x6d, 0xa9,
+	0x49, 0x0f, 0xf5, 0xc0, 0x0c, 0xbd, 0x21, 0x78, 0x5b, 0x3c, 0x05, 0x13, 0xe6, 0x61, 0xe2, 0xf6,
+	0x96, 0x24, 0x6e, 0xe9, 0xd8, 0x73, 0x9c, 0x5b, 0xcd, 0x30, 0xb7, 0x37, 0x04, 0x73, 0xcb, 0x2c,
+	0xf9, 0x58, 0x84, 0xba, 0xb5, 0x22, 0xd4, 0x2d, 0xbb, 0x64, 0x9a, 0x31, 0xdc, 0xed, 0x2d, 0xc9,
+	0xdd, 0x72, 0x4b, 0x46, 0x3c, 0x43, 0xde, 0x6e, 0x47, 0xc9, 0x1b, 0x27, 0x5e, 0xcf, 0xc7, 0x5a,
+	0xc7, 0xb2, 0xb7, 0xef, 0x85, 0xd8, 0x5b, 0x21, 0x96, 0x3a, 0x71, 0x27, 0x0b, 0xe8, 0x5b, 0x2b,
+	0x42, 0xdf, 0x94, 0x25, 0x31, 0x88, 0xe1, 0x6f, 0xef, 0x85, 0xf9, 0x1b, 0xc4, 0x52, 0x40, 0xb1,
+	0xde, 0x8b, 0x08, 0xdc, 0x3b, 0x01, 0x81, 0x2b, 0xc6, 0x32, 0x50, 0x31, 0x87, 0x59, 0x06, 0x77,
+	0x34, 0xc7, 0xe0, 0x38, 0xe3, 0x7a, 0x31, 0xd6, 0xc5, 0x12, 0x0a, 0x77, 0x34, 0x47, 0xe1, 0xca,
+	0x4b, 0x1c, 0x2e, 0xe1, 0x70, 0x3f, 0x5b, 0xcc, 0xe1, 0xe2, 0x59, 0x96, 0x18, 0xe6, 0x6a, 0x24,
+	0x4e, 0x8b, 0x21, 0x71, 0x55, 0xe6, 0xfe, 0x95, 0x58, 0xf7, 0x57, 0x67, 0x71, 0x2f, 0xc3, 0x9a,
+	0x34, 0x0e, 0x72, 0x9e, 0xa2, 0x0c, 0x71, 0x5d, 0xdb, 0x15, 0x7c, 0x8c, 0x77, 0xd4, 0x97, 0xa0,
+	0x14, 0xa8, 0x5e, 0xce, 0xf8, 0x18, 0xde, 0x87, 0x72, 0x5a, 0xfd, 0x43, 0x12, 0x4a, 0xe1, 0x74,
+	0x8d, 0x30, 0x02, 0x45, 0x30, 0x82, 0x10, 0x0f, 0x4c, 0x45, 0x79, 0xe0, 0x16, 0x14, 0x29, 0x8e,
+	0xcf, 0x50, 0x3c, 0xdd, 0x09, 0x28, 0xde, 0x2d, 0x58, 0x63, 0x07, 0x35, 0x67, 0x8b, 0x21, 0xc0,
+	0x4d, 0xe3, 0x2a, 0xfd, 0x81, 0x6f, 0x4e, 0x26, 0x46, 0xaf, 0xc1, 0xb5, 0x90, 0x6e, 0x70, 0x3e,
+	0x70, 0xbe, 0x53, 0x0b, 0xb4, 0xf7, 0xc4, 0x41, 0xf1, 0xa7, 0x24, 0xac, 0xcd, 0xc1, 0xc5, 0x42,
+	0x1a, 0x97, 0xfc, 0x1f, 0xd1, 0xb8, 0xd4, 0x7f, 0x4d, 0xe3, 0xc2, 0xe7, 0x5d, 0x3a, 0x7a, 0xde,
+	0xfd, 0x2b, 0x09, 0xe5, 0x08, 0x6a, 0xd1, 0x25, 0xe8, 0xdb, 0x06, 0x11, 0xe7, 0x0b, 0x6b, 0xa3,
+	0x1a, 0xa4, 0x47, 0xf6, 0x40, 0x9c, 0x22, 0xb4, 0x49, 0xb5, 0x02, 0x10, 0x56, 0x04, 0xc6, 0x06,
+	0x47, 0x53, 0x96, 0x45, 0x98, 0x77, 0xa8, 0xed, 0x03, 0xc2, 0x21, 0xb3, 0x84, 0x69, 0x13, 0xad,
+	0x8b, 0x4d, 0xc6, 0x80, 0xb0, 0x84, 0x79, 0x07, 0xbd, 0x0d, 0x0a, 0x2b, 0x54, 0x68, 0xb6, 0xe3,
+	0x09, 0x74, 0x7b, 0x26, 0x3c, 0x57, 0x5e, 0x8f, 0xd8, 0x3e, 0xa6, 0x3a, 0x47, 0x8e, 0x87, 0x0b,
+	0x8e, 0x68, 0x85, 0x8e, 0x65, 0x25, 0x42, 0x0f, 0x6f, 0x80, 0x42, 0x47, 0xef, 0x39, 0x7a, 0x9f,
+	0x30, 0xa8, 0x52, 0xf0, 0x54, 0xa0, 0xde, 0x07, 0x34, 0x0f, 0xb8, 0xa8, 0x03, 0x39, 0x72, 0x46,
+	0x2c, 0x9f, 0x2e, 0x1b, 0x0d, 0xf7, 0xc6, 0x02, 0xee, 0x45, 0x2c, 0xbf, 0x59, 0xa7, 0x41, 0xfe,
+	0xe7, 0xd7, 0x5b, 0x35, 0xae, 0xfd, 0xaa, 0x3d, 0x36, 0x7d, 0x32, 0x76, 0xfc, 0x0b, 0x2c, 0xec,
+	0xd5, 0xbf, 0xa5, 0xa0, 0x2a, 0x3f, 0x20, 0x19, 0xd8, 0xa2, 0xd8, 0xca, 0x2d, 0x9f, 0x0a, 0x91,
+	0xe0, 0xd5, 0xe2, 0xbd, 0x09, 0x30, 0xd0, 0x3d, 0xed, 0x33, 0xdd, 0xf2, 0x89, 0x21, 0x82, 0x1e,
+	0x92, 0xa0, 0x06, 0x14, 0x68, 0x6f, 0xe2, 0x11, 0x43, 0xf0, 0xf1, 0xa0, 0x1f, 0x9a, 0x67, 0xfe,
+	0x9b, 0xcd, 0x33, 0x1a, 0xe5, 0xc2, 0x4c, 0x94, 0x43, 0x14, 0x44, 0x09, 0x53, 0x10, 0x3a, 0x36,
+	0xc7, 0x35, 0x6d, 0xd7, 0xf4, 0x2f, 0xd8, 0xd2, 0xa4, 0x71, 0xd0, 0xa7, 0xd7, 0xbb, 0x31, 0x19,
+	0x3b, 0xb6, 0x3d, 0xd2, 0x38, 0xdc, 0x14, 0x99, 0x69, 0x49, 0x08, 0xdb, 0x0c, 0x75, 0x7e, 0x95,
+	0x82, 0xb5, 0xb9, 0xa3, 0xea, 0xff, 0x2f, 0xc0, 0xea, 0xaf, 0xd9, 0x15, 0x35, 0x7a, 0xdc, 0xa2,
+	0x13, 0x58, 0x0b, 0xd2, 0x5f, 0x9b, 0x30, 0x58, 0x90, 0x1b, 0x7a, 0x55, 0xfc, 0xa8, 0x9d, 0x45,
+	0xc5, 0x1e, 0xfa, 0x08, 0x9e, 0x9e, 0xc1, 0xb6, 0xc0, 0x75, 0x6a, 0x55, 0x88, 0x7b, 0x2a, 0x0a,
+	0x71, 0xd2, 0xf5, 0x34, 0x58, 0xe9, 0x6f, 0x98, 0x75, 0xfb, 0x50, 0x91, 0xd1, 0xe0, 0xec, 0x61,
+	0xe1, 0xf2, 0x3f, 0x0f, 0x65, 0x97, 0xf8, 0xf4, 0x26, 0x1e, 0xb9, 0x57, 0x96, 0xb8, 0x50, 0xd0,
+	0xf0, 0x63, 0x78, 0x6a, 0x21, 0x8b, 0x40, 0xdf, 0x01, 0x65, 0x4a, 0x40, 0x92, 0x31, 0x57, 0x34,
+	0xa9, 0x8e, 0xa7, 0xba, 0xea, 0x1f, 0x93, 0xf0, 0xd4, 0x42, 0x1e, 0x81, 0xda, 0x90, 0x73, 0x89,
+	0x37, 0x19, 0xf1, 0x9b, 0x45, 0x65, 0xf7, 0xb5, 0xd5, 0xf8, 0x07, 0x95, 0x4e, 0x46, 0x3e, 0x16,
+	0xc6, 0xea, 0x7d, 0xc8, 0x71, 0x09, 0x2a, 0x42, 0xfe, 0xee, 0xe1, 0x9d, 0xc3, 0xa3, 0x0f, 0x0f,
+	0x6b, 0x09, 0x04, 0x90, 0xdb, 0x6b, 0xb5, 0xda, 0xc7, 0xdd, 0x5a, 0x12, 0x29, 0x90, 0xdd, 0x6b,
+	0x1e, 0xe1, 0x6e, 0x2d, 0x45, 0xc5, 0xb8, 0xfd, 0x41, 0xbb, 0xd5, 0xad, 0xa5, 0xd1, 0x1a, 0x94,
+	0x79, 0x5b, 0xbb, 0x7d, 0x84, 0x7f, 0xb4, 0xd7, 0xad, 0x65, 0x42, 0xa2, 0x93, 0xf6, 0xe1, 0xfb,
+	0x6d, 0x5c, 0xcb, 0xaa, 0xaf, 0xc3, 0x75, 0x39, 0x8e, 0xf9, 0xdb, 0x51, 0x70, 0x07, 0x49, 0x86,
+	0xee, 0x20, 0xea, 0x6f, 0x52, 0xd0, 0x88, 0xa7, 0x21, 0xe8, 0x83, 0x99, 0x89, 0xef, 0x5e, 0x81,
+	0xc3, 0xcc, 0xcc, 0x9e, 0x56, 0x21, 0x5c, 0x72, 0x4a, 0xfc, 0xfe, 0x90, 0xd3, 0x22, 0x7e, 0x64,
+	0x96, 0x71, 0x59, 0x48, 0x99, 0x91, 0xc7, 0xd5, 0x3e, 0x21, 0x7d, 0x5f, 0xe3, 0x58, 0xc4, 0x37,
+	0x9d, 0x82, 0xcb, 0x5c, 0x7a, 0xc2, 0x85, 0xea, 0xcf, 0xaf, 0x14, 0x4b, 0x05, 0xb2, 0xb8, 0xdd,
+	0xc5, 0x1f, 0xd5, 0xd2, 0x08, 0x41, 0x85, 0x35, 0xb5, 0x93, 0xc3, 0xbd, 0xe3, 0x93, 0xce, 0x11,
+	0x8d, 0xe5, 0x35, 0xa8, 0xca, 0x58, 0x4a, 0x61, 0x56, 0xfd, 0x18, 0x2a, 0xd1, 0xea, 0x00, 0x0d,
+	0xa1, 0x6b, 0x4f, 0x2c, 0x83, 0x05, 0x23, 0x8b, 0x79, 0x87, 0x96, 0x8c, 0xcf, 0x6c, 0x9e, 0x66,
+	0x8b, 0xf7, 0xda, 0x3d, 0xdb, 0x27, 0xa1, 0xea, 0x02, 0xd7, 0x56, 0x3f, 0x87, 0x2c, 0xcb, 0x1a,
+	0x9a, 0x01, 0xec, 0x9e, 0x2f, 0x48, 0x15, 0x6d, 0xa3, 0x8f, 0x01, 0x74, 0xdf, 0x77, 0xcd, 0xde,
+	0x64, 0xea, 0x78, 0x6b, 0x71, 0xd6, 0xed, 0x49, 0xbd, 0xe6, 0x0d, 0x91, 0x7e, 0xeb, 0x53, 0xd3,
+	0x50, 0x0a, 0x86, 0x1c, 0xaa, 0x87, 0x50, 0x89, 0xda, 0x4a, 0x1a, 0xc0, 0xc7, 0x10, 0xa5, 0x01,
+	0x9c, 0xd5, 0xf1, 0xce, 0x94, 0x44, 0xa4, 0x79, 0x4d, 0x87, 0x75, 0xd4, 0x87, 0x49, 0x28, 0x74,
+	0xcf, 0xc5, 0x7a, 0xc4, 0x94, 0x13, 0xa6, 0xa6, 0xa9, 0xf0, 0xd5, 0x98, 0xd7, 0x27, 0xd2, 0x41,
+	0xd5, 0xe3, 0xbd, 0x60, 0xc7, 0x65, 0x56, 0xbd, 0x01, 0xc9, 0xf2, 0x8f, 0xc8, 0xb2, 0x77, 0x41,
+	0x09, 0x30, 0x93, 0xb2, 0x53, 0xdd, 0x30, 0x5c, 0xe2, 0x79, 0x62, 0xdf, 0xcb, 0x2e, 0x1d, 0x8e,
+	0x63, 0x7f, 0x26, 0x2e, 0xdf, 0x69, 0xcc, 0x3b, 0xaa, 0x01, 0xd5, 0x19, 0xc0, 0x45, 0xef, 0x42,
+	0xde, 0x99, 0xf4, 0x34, 0x19, 0x9e, 0x99, 0xd7, 0x08, 0xc9, 0x7b, 0x26, 0xbd, 0x91, 0xd9, 0xbf,
+	0x43, 0x2e, 0xe4, 0x60, 0x9c, 0x49, 0xef, 0x0e, 0x8f, 0x22, 0xff, 0x4a, 0x2a, 0xfc, 0x95, 0x33,
+	0x28, 0xc8, 0x4d, 0x81, 0xbe, 0x0f, 0x4a, 0x80, 0xe5, 0x41, 0xd1, 0x32, 0xf6, 0x10, 0x10, 0xee,
+	0xa7, 0x26, 0x94, 0x44, 0x7b, 0xe6, 0xc0, 0x22, 0x86, 0x36, 0xe5, 0xc7, 0xec, 0x6b, 0x05, 0x5c,
+	0xe5, 0x3f, 0x1c, 0x48, 0x72, 0xac, 0xfe, 0x3b, 0x09, 0x05, 0x59, 0x9c, 0x42, 0xaf, 0x87, 0xf6,
+	0x5d, 0x65, 0xc1, 0x45, 0x5d, 0x2a, 0x4e, 0x0b, 0x4c, 0xd1, 0xb1, 0xa6, 0xae, 0x3e, 0xd6, 0xb8,
+	0x4a, 0xa1, 0xac, 0xd9, 0x66, 0xae, 0x5c, 0xb3, 0x7d, 0x15, 0x90, 0x6f, 0xfb, 0xfa, 0x48, 0x3b,
+	0xb3, 0x7d, 0xd3, 0x1a, 0x68, 0x3c, 0xd8, 0x9c, 0x0b, 0xd4, 0xd8, 0x2f, 0xf7, 0xd8, 0x0f, 0xc7,
+	0x2c, 0xee, 0xbf, 0x4b, 0x42, 0x21, 0x00, 0xf5, 0xab, 0x96, 0x8b, 0x36, 0x20, 0x27, 0x70, 0x8b,
+	0xd7, 0x8b, 0x44, 0x2f, 0x28, 0x5d, 0x66, 0x42, 0xa5, 0xcb, 0x06, 0x14, 0xc6, 0xc4, 0xd7, 0xd9,
+	0xc9, 0xc6, 0xaf, 0x28, 0x41, 0x7f, 0xb6, 0xc0, 0x94, 0x9b, 0x2d, 0x30, 0xdd, 0x7a, 0x07, 0x8a,
+	0xa1, 0xda, 0x1e, 0x4d, 0xcd, 0xc3, 0xf6, 0x87, 0xb5, 0x44, 0x23, 0xff, 0xf0, 0x8b, 0x9b, 0xe9,
+	0x43, 0xf2, 0x19, 0xdd, 0xd4, 0xb8, 0xdd, 0xea, 0xb4, 0x5b, 0x77, 0x6a, 0xc9, 0x46, 0xf1, 0xe1,
+	0x17, 0x37, 0xf3, 0x98, 0xb0, 0x2a, 0xc2, 0xad, 0x0e, 0x94, 0xc2, 0xcb, 0x16, 0xc5, 0x46, 0x04,
+	0x95, 0xf7, 0xef, 0x1e, 0x1f, 0xec, 0xb7, 0xf6, 0xba, 0x6d, 0xed, 0xde, 0x51, 0xb7, 0x5d, 0x4b,
+	0xa2, 0xa7, 0xe1, 0xda, 0xc1, 0xfe, 0x0f, 0x3b, 0x5d, 0xad, 0x75, 0xb0, 0xdf, 0x3e, 0xec, 0x6a,
+	0x7b, 0xdd, 0xee, 0x5e, 0xeb, 0x4e, 0x2d, 0xb5, 0xfb, 0x7b, 0x05, 0xaa, 0x7b, 0xcd, 0xd6, 0x3e,
+	0xc5, 0x75, 0xb3, 0xaf, 0xb3, 0x0b, 0x66, 0x0b, 0x32, 0xec, 0x0a, 0x79, 0xe9, 0xcb, 0x5f, 0xe3,
+	0xf2, 0xfa, 0x12, 0xba, 0x0d, 0x59, 0x76, 0xbb, 0x44, 0x97, 0x3f, 0x05, 0x36, 0x96, 0x14, 0x9c,
+	0xe8, 0x60, 0x58, 0xfe, 0x5c, 0xfa, 0x36, 0xd8, 0xb8, 0xbc, 0xfe, 0x84, 0x30, 0x28, 0x53, 0x76,
+	0xba, 0xfc, 0xad, 0xac, 0xb1, 0x02, 0x1a, 0xa1, 0x03, 0xc8, 0xcb, 0x0b, 0xc5, 0xb2, 0xd7, 0xbb,
+	0xc6, 0xd2, 0x02, 0x11, 0x0d, 0x17, 0xbf, 0xf8, 0x5d, 0xfe, 0x14, 0xd9, 0x58, 0x52, 0xed, 0x42,
+	0xfb, 0x90, 0x13, 0x8c, 0x6b, 0xc9, 0x8b, 0x5c, 0x63, 0x59, 0xc1, 0x87, 0x06, 0x6d, 0x7a, 0xa5,
+	0x5e, 0xfe, 0xc0, 0xda, 0x58, 0xa1, 0x90, 0x87, 0xee, 0x02, 0x84, 0xae, 0x79, 0x2b, 0xbc, 0x9c,
+	0x36, 0x56, 0x29, 0xd0, 0xa1, 0x23, 0x28, 0x04, 0xac, 0x7b, 0xe9, 0x3b, 0x66, 0x63, 0x79, 0xa5,
+	0x0c, 0xdd, 0x87, 0x72, 0x94, 0x6d, 0xae, 0xf6, 0x3a, 0xd9, 0x58, 0xb1, 0x04, 0x46, 0xfd, 0x47,
+	0xa9, 0xe7, 0x6a, 0xaf, 0x95, 0x8d, 0x15, 0x2b, 0x62, 0xe8, 0x13, 0x58, 0x9b, 0xa7, 0x86, 0xab,
+	0x3f, 0x5e, 0x36, 0xae, 0x50, 0x23, 0x43, 0x63, 0x40, 0x0b, 0x28, 0xe5, 0x15, 0xde, 0x32, 0x1b,
+	0x57, 0x29, 0x99, 0x35, 0xdb, 0x5f, 0x3e, 0xde, 0x4c, 0x7e, 0xf5, 0x78, 0x33, 0xf9, 0x8f, 0xc7,
+	0x9b, 0xc9, 0x47, 0x4f, 0x36, 0x13, 0x5f, 0x3d, 0xd9, 0x4c, 0xfc, 0xf5, 0xc9, 0x66, 0xe2, 0x27,
+	0xaf, 0x0c, 0x4c, 0x7f, 0x38, 0xe9, 0x6d, 0xf7, 0xed, 0xf1, 0x4e, 0xf8, 0x4f, 0x12, 0x8b, 0xfe,
+	0xb8, 0xd1, 0xcb, 0xb1, 0x53, 0xe7, 0x8d, 0xff, 0x0c, 0x00, 0xa6, 0x08, 0x07, 0x75, 0xd8, 0x21,
+	0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -4499,6 +4516,11 @@ func (m *RequestLoadSnapshotChunk) MarshalToSizedBuffer(dAtA []byte) (int, error
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Chunk != 0 {
 		i = encodeVarintTypes(dAtA, i, uint64(m.Chunk))
 		i--
@@ -6023,6 +6045,11 @@ func (m *Snapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Metadata) > 0 {
 		i -= len(m.Metadata)
 		copy(dAtA[i:], m.Metadata)
@@ -6460,6 +6487,9 @@ func (m *RequestLoadSnapshotChunk) Size() (n int) {
 	if m.Chunk != 0 {
 		n += 1 + sovTypes(uint64(m.Chunk))
 	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -7181,6 +7211,9 @@ func (m *Snapshot) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -9153,6 +9186,25 @@ func (m *RequestLoadSnapshotChunk) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -13268,6 +13320,25 @@ func (m *Snapshot) Unmarshal(dAtA []byte) error {
 				m.Metadata = []byte{}
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
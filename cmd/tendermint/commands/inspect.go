@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,6 +11,7 @@ import (
 
 	cfg "github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/inspect"
+	"github.com/tendermint/tendermint/proxy"
 	"github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/state/indexer/sink"
 	"github.com/tendermint/tendermint/store"
@@ -42,6 +44,12 @@ func init() {
 			config.DBBackend, "database backend: goleveldb | cleveldb | boltdb | rocksdb | badgerdb")
 	InspectCmd.Flags().
 		String("db-dir", config.DBPath, "database directory")
+	InspectCmd.Flags().
+		String("upstream", "",
+			"address of a node RPC server to forward read-only routes not served by inspect to, e.g. http://127.0.0.1:26657")
+	InspectCmd.Flags().
+		String("snapshot-app", "",
+			"address of the ABCI application to list snapshots from with the list_snapshots route, e.g. tcp://127.0.0.1:26658")
 }
 
 func runInspect(cmd *cobra.Command, args []string) error {
@@ -78,6 +86,27 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	stateStore := state.NewStore(stateDB)
 
 	ins := inspect.New(config.RPC, blockStore, stateStore, sinks, logger)
+	if upstream, _ := cmd.Flags().GetString("upstream"); upstream != "" {
+		if err := ins.SetUpstream(upstream); err != nil {
+			return err
+		}
+	}
+	if addr, _ := cmd.Flags().GetString("snapshot-app"); addr != "" {
+		client, err := proxy.NewRemoteClientCreator(addr, config.ABCI, true).NewABCIClient()
+		if err != nil {
+			return err
+		}
+		client.SetLogger(logger.With("module", "abci-client"))
+		if err := client.Start(); err != nil {
+			return fmt.Errorf("error starting snapshot app connection: %w", err)
+		}
+		defer func() {
+			if err := client.Stop(); err != nil {
+				logger.Error("error stopping snapshot app connection", "error", err)
+			}
+		}()
+		ins.SetSnapshotConn(proxy.NewAppConnSnapshot(client))
+	}
 
 	logger.Info("starting inspect server")
 	if err := ins.Run(ctx); err != nil {
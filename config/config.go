@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	tmjson "github.com/tendermint/tendermint/libs/json"
 	"github.com/tendermint/tendermint/libs/log"
 	tmos "github.com/tendermint/tendermint/libs/os"
@@ -34,6 +36,16 @@ const (
 
 	MempoolV0 = "v0"
 	MempoolV1 = "v1"
+
+	ChunkChecksumSHA256  = "sha256"
+	ChunkChecksumBlake2b = "blake2b"
+
+	DiscoveryStrategyBroadcast = "broadcast"
+	DiscoveryStrategySample    = "sample"
+
+	BackfillFailureProceed = "proceed"
+	BackfillFailureRetry   = "retry"
+	BackfillFailureAbort   = "abort"
 )
 
 // NOTE: Most of the structs & relevant comments + the
@@ -57,6 +69,8 @@ var (
 	defaultNodeKeyName  = "node_key.json"
 	defaultAddrBookName = "addrbook.json"
 
+	defaultStateSyncServeNodeKeyName = "statesync_serve_node_key.json"
+
 	defaultConfigFilePath   = filepath.Join(defaultConfigDir, defaultConfigFileName)
 	defaultGenesisJSONPath  = filepath.Join(defaultConfigDir, defaultGenesisJSONName)
 	defaultPrivValKeyPath   = filepath.Join(defaultConfigDir, defaultPrivValKeyName)
@@ -64,6 +78,8 @@ var (
 
 	defaultNodeKeyPath  = filepath.Join(defaultConfigDir, defaultNodeKeyName)
 	defaultAddrBookPath = filepath.Join(defaultConfigDir, defaultAddrBookName)
+
+	defaultStateSyncServeNodeKeyPath = filepath.Join(defaultConfigDir, defaultStateSyncServeNodeKeyName)
 )
 
 // Config defines the top level configuration for a Tendermint node
@@ -130,6 +146,7 @@ func (cfg *Config) SetRoot(root string) *Config {
 	cfg.Mempool.RootDir = root
 	cfg.Consensus.RootDir = root
 	cfg.PrivValidator.RootDir = root
+	cfg.StateSync.RootDir = root
 	return cfg
 }
 
@@ -505,9 +522,27 @@ type RPCConfig struct {
 	// Maximum size of request body, in bytes
 	MaxBodyBytes int64 `mapstructure:"max-body-bytes"`
 
+	// Maximum size of HTTP response body, in bytes. Larger responses are
+	// replaced by an error. Websocket messages aren't limited. A value of 0
+	// disables the limit.
+	MaxResponseBodyBytes int64 `mapstructure:"max-response-body-bytes"`
+
 	// Maximum size of request header, in bytes
 	MaxHeaderBytes int `mapstructure:"max-header-bytes"`
 
+	// Maximum number of blocks that can be requested in a single /blocks_stream
+	// call. A value of 0 disables the limit. Only used by the inspect server.
+	MaxBlocksStreamRange int64 `mapstructure:"max-blocks-stream-range"`
+
+	// Maximum number of conditions a /tx_search or /block_search query may
+	// contain. A value of 0 disables the limit. Only used by the inspect server.
+	MaxQueryConditions int `mapstructure:"max-query-conditions"`
+
+	// Maximum number of /tx_search and /block_search queries run against the
+	// event sinks at the same time. Further queries wait for one to complete.
+	// A value of 0 disables the limit. Only used by the inspect server.
+	MaxConcurrentSinkQueries int `mapstructure:"max-concurrent-sink-queries"`
+
 	// The path to a file containing certificate that is used to create the HTTPS server.
 	// Might be either absolute path or path related to Tendermint's config directory.
 	//
@@ -550,6 +585,10 @@ func DefaultRPCConfig() *RPCConfig {
 		MaxBodyBytes:   int64(1000000), // 1MB
 		MaxHeaderBytes: 1 << 20,        // same as the net/http default
 
+		MaxBlocksStreamRange:     100000,
+		MaxQueryConditions:       16,
+		MaxConcurrentSinkQueries: 10,
+
 		TLSCertFile: "",
 		TLSKeyFile:  "",
 	}
@@ -585,9 +624,21 @@ func (cfg *RPCConfig) ValidateBasic() error {
 	if cfg.MaxBodyBytes < 0 {
 		return errors.New("max-body-bytes can't be negative")
 	}
+	if cfg.MaxResponseBodyBytes < 0 {
+		return errors.New("max-response-body-bytes can't be negative")
+	}
 	if cfg.MaxHeaderBytes < 0 {
 		return errors.New("max-header-bytes can't be negative")
 	}
+	if cfg.MaxBlocksStreamRange < 0 {
+		return errors.New("max-blocks-stream-range can't be negative")
+	}
+	if cfg.MaxQueryConditions < 0 {
+		return errors.New("max-query-conditions can't be negative")
+	}
+	if cfg.MaxConcurrentSinkQueries < 0 {
+		return errors.New("max-concurrent-sink-queries can't be negative")
+	}
 	return nil
 }
 
@@ -900,6 +951,8 @@ func (cfg *MempoolConfig) ValidateBasic() error {
 
 // StateSyncConfig defines the configuration for the Tendermint state sync service
 type StateSyncConfig struct {
+	RootDir string `mapstructure:"home"`
+
 	// State sync rapidly bootstraps a new node by discovering, fetching, and restoring a
 	// state machine snapshot from peers instead of fetching and replaying historical
 	// blocks. Requires some peers in the network to take and serve state machine
@@ -917,6 +970,18 @@ type StateSyncConfig struct {
 	// with net.Dial, for example: "host.example.com:2125".
 	RPCServers []string `mapstructure:"rpc-servers"`
 
+	// If using P2P, the maximum number of peers, including the primary, that the
+	// light client uses as providers. Further peers are kept as spares and only
+	// rotated in when active providers fail (default: 6).
+	MaxStateProviders int `mapstructure:"max-state-providers"`
+
+	// If using P2P, the number of providers that must serve a light block
+	// identical to the one verified by the light client at the height holding
+	// the app hash of a snapshot. All the providers are queried concurrently.
+	// A value of 0 only relies on the light client's own cross-referencing
+	// (default: 0).
+	StateProviderQuorum int `mapstructure:"state-provider-quorum"`
+
 	// The hash and height of a trusted block. Must be within the trust-period.
 	TrustHeight int64  `mapstructure:"trust-height"`
 	TrustHash   string `mapstructure:"trust-hash"`
@@ -929,17 +994,263 @@ type StateSyncConfig struct {
 	// Time to spend discovering snapshots before initiating a restore.
 	DiscoveryTime time.Duration `mapstructure:"discovery-time"`
 
+	// How snapshots are discovered, either "broadcast" to request snapshots
+	// from all connected peers, or "sample" to request them from a random
+	// sample of discovery-sample-size peers only. When sampling, the sample is
+	// doubled with peers that weren't asked yet every time discovery finds no
+	// suitable snapshot (default: "broadcast").
+	DiscoveryStrategy string `mapstructure:"discovery-strategy"`
+
+	// The number of peers initially sampled by the "sample" discovery
+	// strategy (default: 10).
+	DiscoverySampleSize int `mapstructure:"discovery-sample-size"`
+
+	// The maximum number of times to run the whole snapshot discovery and
+	// restoration cycle before state sync fails. Snapshots are requested from
+	// peers again before every new attempt (default: 1).
+	MaxSyncAttempts int `mapstructure:"max-sync-attempts"`
+
+	// The maximum amount of time to wait for enough peers to connect before
+	// starting state sync. When exceeded, state sync fails. A value of 0
+	// disables the limit (default: 0).
+	PeerWaitTimeout time.Duration `mapstructure:"peer-wait-timeout"`
+
+	// How often to check whether enough peers have connected while waiting for
+	// them before starting state sync. Each interval is extended by a random
+	// duration of up to peer-check-jitter, so that nodes started together
+	// don't check and request snapshots in lockstep (defaults: 200ms and 100ms).
+	PeerCheckInterval time.Duration `mapstructure:"peer-check-interval"`
+	PeerCheckJitter   time.Duration `mapstructure:"peer-check-jitter"`
+
 	// Temporary directory for state sync snapshot chunks, defaults to os.TempDir().
 	// The synchronizer will create a new, randomly named directory within this directory
 	// and remove it when the sync is complete.
 	TempDir string `mapstructure:"temp-dir"`
 
+	// Prefix of the per-node subdirectory of temp-dir in which the synchronizer
+	// stores its chunk files. The subdirectory is named after the prefix, the
+	// chain ID and the node ID, so that multiple nodes can share temp-dir
+	// (default: "tm-statesync").
+	TempDirPrefix string `mapstructure:"temp-dir-prefix"`
+
+	// Whether to keep the files of the chunks applied to the app in the temp
+	// dir until the whole snapshot is restored, instead of removing them when
+	// the sync stops. A sync restarted after a crash then reads the applied
+	// chunks from disk rather than downloading them again (default: false).
+	RetainAppliedChunks bool `mapstructure:"retain-applied-chunks"`
+
 	// The timeout duration before re-requesting a chunk, possibly from a different
 	// peer (default: 15 seconds).
 	ChunkRequestTimeout time.Duration `mapstructure:"chunk-request-timeout"`
 
 	// The number of concurrent chunk and block fetchers to run (default: 4).
 	Fetchers int32 `mapstructure:"fetchers"`
+
+	// The algorithm used to checksum the snapshot chunks served to peers, either
+	// "sha256" or "blake2b". Received chunks are verified using the algorithm
+	// indicated by the serving peer. An empty value disables checksums
+	// (default: "sha256").
+	ChunkChecksumAlgorithm string `mapstructure:"chunk-checksum-algorithm"`
+
+	// The number of chunks loaded from the app ahead of each chunk served to a
+	// peer, and cached for the peer's next requests of the same snapshot. It
+	// saves ABCI round trips when peers request chunks sequentially, at the
+	// cost of holding up to this many chunks in memory per peer, and at most
+	// 64MB across all peers. A value of 0 disables read-ahead (default: 0).
+	ChunkReadAhead int `mapstructure:"chunk-read-ahead"`
+
+	// The snapshot formats advertised and served to peers. Snapshots of other
+	// formats aren't advertised, and requests for their chunks are answered as
+	// missing, so that peers running an older app don't download snapshots in
+	// formats they can't apply, e.g. during a format migration. An empty list
+	// serves all formats (default: []).
+	ServeFormats []uint32 `mapstructure:"serve-formats"`
+
+	// Whether to verify the light blocks at the height of a snapshot, and thus
+	// its app hash, before fetching any of its chunks. When disabled, chunks
+	// are fetched while the light blocks are verified (default: false).
+	VerifySnapshotBeforeDownload bool `mapstructure:"verify-snapshot-before-download"`
+
+	// The minimum number of distinct peers that must serve a randomly chosen
+	// chunk of a snapshot before committing to restore it. The chunk is
+	// requested from two more peers than required, and from other peers only
+	// if too few of those serve it. Snapshots served by fewer peers are
+	// rejected in favor of the next candidate. A value of 0 disables the check
+	// (default: 0).
+	MinChunkServingPeers int `mapstructure:"min-chunk-serving-peers"`
+
+	// How long no peer may advertise the snapshot being restored before it is
+	// abandoned in favor of the next candidate, while its chunks are fetched.
+	// Peers are checked periodically, so that a long sync doesn't hang on a
+	// snapshot its serving peers have all left. A value of 0 disables the
+	// check (default: 1 minute).
+	SnapshotAvailabilityGracePeriod time.Duration `mapstructure:"snapshot-availability-grace-period"`
+
+	// The height of the last breaking upgrade of the chain. Snapshots taken
+	// below it are rejected as soon as they're advertised, as if no peer
+	// served them, since restoring them would leave the node on the
+	// pre-upgrade state. A value of 0 accepts snapshots of any height
+	// (default: 0).
+	MinUpgradeHeight int64 `mapstructure:"min-upgrade-height"`
+
+	// Whether to log every chunk request sent and every chunk response
+	// received while restoring a snapshot, along with the peer, the chunk size
+	// and the time the peer took to respond. Meant for diagnosing slow or stuck
+	// snapshot downloads (default: false).
+	LogChunkTransfers bool `mapstructure:"log-chunk-transfers"`
+
+	// The maximum rate, in bytes per second, at which snapshot chunks are served
+	// to other peers while the node is itself state syncing. Chunk requests
+	// received while the rate is exceeded are ignored, leaving the requesting
+	// peers to fetch the chunks elsewhere. The limit is lifted once the sync
+	// completes. A value of 0 disables the limit (default: 0).
+	SyncingServeRate int64 `mapstructure:"syncing-serve-rate"`
+
+	// The maximum amount of time to wait for a peer to return a light block
+	// requested by the P2P state provider. A value of 0 leaves the requests
+	// bounded by the light client only (default: 0).
+	LightBlockRequestTimeout time.Duration `mapstructure:"light-block-request-timeout"`
+
+	// The maximum amount of time to wait for a peer to return a light block
+	// requested during backfill, after which the block is requested again
+	// from another peer (default: 10s).
+	BackfillRequestTimeout time.Duration `mapstructure:"backfill-request-timeout"`
+
+	// The maximum number of light blocks fetched during backfill ahead of the
+	// one being verified. Fetchers wait for blocks to be verified once it's
+	// reached, bounding the memory held by fetched blocks when verification
+	// lags behind, e.g. on chains with large validator sets. A value of 0
+	// disables the limit (default: 0).
+	BackfillMaxPendingBlocks int `mapstructure:"backfill-max-pending-blocks"`
+
+	// The maximum number of light block requests per second served to each
+	// peer. Excess requests are dropped, leaving the requesting peer to fetch
+	// the light blocks elsewhere. A value of 0 disables the limit (default: 0).
+	LightBlockServeRateLimit int64 `mapstructure:"light-block-serve-rate-limit"`
+
+	// The maximum amount of time to wait for the app to list its snapshots when
+	// serving a peer. When exceeded, the snapshots listed by the previous call
+	// are served instead. A value of 0 disables the limit (default: 10s).
+	ListSnapshotsTimeout time.Duration `mapstructure:"list-snapshots-timeout"`
+
+	// Whether to compress the metadata of the snapshots advertised to peers
+	// that support it, keeping snapshot messages of apps with large metadata
+	// under the message size limit (default: false).
+	CompressSnapshotMetadata bool `mapstructure:"compress-snapshot-metadata"`
+
+	// Whether to skip backfilling historical blocks after a successful state
+	// sync, bootstrapping the node with the restored state alone. This is the
+	// fastest way to join consensus, but the node won't be able to serve, or
+	// verify evidence for, any height below the snapshot (default: false).
+	SkipBackfill bool `mapstructure:"skip-backfill"`
+
+	// What to do when backfill fails after a successful state sync: "proceed"
+	// starts the node with the blocks backfilled so far, "retry" runs backfill
+	// again up to backfill-retries times, doubling the delay between attempts,
+	// before proceeding, and "abort" fails the sync, shutting down the node.
+	// Backfill stopped by max-backfill-time is never retried nor aborted
+	// (default: "proceed").
+	BackfillFailurePolicy string `mapstructure:"backfill-failure-policy"`
+
+	// The number of times backfill is run again after failing, when
+	// backfill-failure-policy is "retry" (default: 3).
+	BackfillRetries int `mapstructure:"backfill-retries"`
+
+	// The maximum amount of time to spend backfilling historical blocks after a
+	// successful state sync. When exceeded, backfill stops at the height it has
+	// reached. A value of 0 disables the limit (default: 0).
+	MaxBackfillTime time.Duration `mapstructure:"max-backfill-time"`
+
+	// The number of heights between the checkpoints persisted during backfill.
+	// Each checkpoint is a signed header along with its validator set, which
+	// allows a partially backfilled range to be verified independently. A value
+	// of 0 disables checkpointing (default: 0).
+	BackfillCheckpointInterval int64 `mapstructure:"backfill-checkpoint-interval"`
+
+	// Whether backfill verifies that the commit of every fetched light block is
+	// signed by +2/3 of the block's validators, rejecting peers that serve
+	// forged signatures. Headers are authenticated by the hash chain down from
+	// the trusted block, but the commits stored along with them are not
+	// (default: true).
+	BackfillVerifyCommits bool `mapstructure:"backfill-verify-commits"`
+
+	// The number of distinct peers that must return a light block whose hash
+	// doesn't match the trusted block ID at the same height during backfill
+	// before they are reported. Until then, the block is fetched again from
+	// another peer. A value of 0 or 1 reports every such peer right away
+	// (default: 1).
+	BackfillMismatchPeers int `mapstructure:"backfill-mismatch-peers"`
+
+	// Whether backfill fetches every verified light block again from up to two
+	// other peers at once and checks that two of the responses are
+	// byte-identical. This at least doubles the bandwidth used by backfill, but
+	// catches a primary source serving subtly altered blocks. Backfill fails if
+	// fewer than two peers are connected.
+	BackfillCrossCheck bool `mapstructure:"backfill-cross-check"`
+
+	// The time to wait before fetching a light block again when no other peer
+	// is free to cross-check it, or when the peers that cross-checked it don't
+	// agree (default: 1s).
+	BackfillCrossCheckWait time.Duration `mapstructure:"backfill-cross-check-wait"`
+
+	// The percentage of time the backfill verifier may spend waiting for light
+	// blocks to be fetched before a message suggesting to increase the number
+	// of fetchers is logged. It is checked every minute. A value of 0 disables
+	// the message (default: 80).
+	BackfillIdleWarnPercent int `mapstructure:"backfill-idle-warn-percent"`
+
+	// The number of panics a peer's messages may trigger while being handled
+	// before the peer is reported and disconnected. Until then, such messages
+	// are dropped. A value of 0 or 1 reports the peer on its first panic
+	// (default: 3).
+	MaxPeerPanics int `mapstructure:"max-peer-panics"`
+
+	// Whether to check on startup that the heights of the block store and of
+	// the state store agree, failing to start the state sync reactor if they
+	// don't. A disagreement is left behind by an interrupted state sync, after
+	// which the node must be re-synced (default: false).
+	VerifyStoreConsistency bool `mapstructure:"verify-store-consistency"`
+
+	// Address to listen on for peers' state sync requests over a dedicated p2p
+	// transport, so that high-bandwidth snapshot and chunk serving doesn't
+	// compete with the consensus traffic of the main p2p connections. The
+	// listener has its own node key, whose ID peers use to add it as a
+	// persistent or bootstrap peer, and only carries the state sync channels.
+	// Snapshots and chunks are then no longer served over the main p2p
+	// connections. Requires the new p2p stack. An empty address serves them
+	// over the main p2p connections (default: "").
+	ServeListenAddress string `mapstructure:"serve-laddr"`
+
+	// Path to the JSON file containing the private key of the serve-laddr
+	// listener, generated if missing (default: "config/statesync_serve_node_key.json").
+	ServeNodeKey string `mapstructure:"serve-node-key-file"`
+
+	// The priorities of the state sync p2p channels, relative to each other and
+	// to the channels of the other reactors. Channels with a higher priority
+	// get a larger share of the bandwidth. Nodes that mostly serve backfilling
+	// peers may want to raise the priority of the light block channel
+	// (defaults: 6, 3, 5 and 2).
+	SnapshotChannelPriority   int `mapstructure:"snapshot-channel-priority"`
+	ChunkChannelPriority      int `mapstructure:"chunk-channel-priority"`
+	LightBlockChannelPriority int `mapstructure:"light-block-channel-priority"`
+	ParamsChannelPriority     int `mapstructure:"params-channel-priority"`
+
+	// The MaxSendBytes of the state sync p2p channels. It doesn't limit the
+	// size of messages: with the "wdrr" p2p queue-type, it multiplies the
+	// channel priority to give the number of bytes the channel may send per
+	// scheduling round, so a message larger than that waits for several
+	// rounds. Raising it for the chunk and light block channels lets their
+	// large messages out sooner. Other queue types ignore it (defaults: 400).
+	SnapshotChannelMaxSendBytes   int `mapstructure:"snapshot-channel-max-send-bytes"`
+	ChunkChannelMaxSendBytes      int `mapstructure:"chunk-channel-max-send-bytes"`
+	LightBlockChannelMaxSendBytes int `mapstructure:"light-block-channel-max-send-bytes"`
+	ParamsChannelMaxSendBytes     int `mapstructure:"params-channel-max-send-bytes"`
+}
+
+// ServeNodeKeyFile returns the full path to the node key file of the
+// serve-laddr listener.
+func (cfg *StateSyncConfig) ServeNodeKeyFile() string {
+	return rootify(cfg.ServeNodeKey, cfg.RootDir)
 }
 
 func (cfg *StateSyncConfig) TrustHashBytes() []byte {
@@ -956,8 +1267,39 @@ func DefaultStateSyncConfig() *StateSyncConfig {
 	return &StateSyncConfig{
 		TrustPeriod:         168 * time.Hour,
 		DiscoveryTime:       15 * time.Second,
+		DiscoveryStrategy:   DiscoveryStrategyBroadcast,
+		DiscoverySampleSize: 10,
+		MaxSyncAttempts:     1,
+		PeerCheckInterval:   200 * time.Millisecond,
+		PeerCheckJitter:     100 * time.Millisecond,
+		MaxStateProviders:   6,
+		TempDirPrefix:       "tm-statesync",
 		ChunkRequestTimeout: 15 * time.Second,
 		Fetchers:            4,
+
+		SnapshotAvailabilityGracePeriod: 1 * time.Minute,
+
+		ListSnapshotsTimeout:    10 * time.Second,
+		BackfillRequestTimeout:  10 * time.Second,
+		ChunkChecksumAlgorithm:  ChunkChecksumSHA256,
+		BackfillVerifyCommits:   true,
+		BackfillCrossCheckWait:  1 * time.Second,
+		BackfillMismatchPeers:   1,
+		BackfillFailurePolicy:   BackfillFailureProceed,
+		BackfillRetries:         3,
+		BackfillIdleWarnPercent: 80,
+		MaxPeerPanics:           3,
+		ServeNodeKey:            defaultStateSyncServeNodeKeyPath,
+
+		SnapshotChannelPriority:   6,
+		ChunkChannelPriority:      3,
+		LightBlockChannelPriority: 5,
+		ParamsChannelPriority:     2,
+
+		SnapshotChannelMaxSendBytes:   400,
+		ChunkChannelMaxSendBytes:      400,
+		LightBlockChannelMaxSendBytes: 400,
+		ParamsChannelMaxSendBytes:     400,
 	}
 }
 
@@ -968,6 +1310,59 @@ func TestStateSyncConfig() *StateSyncConfig {
 
 // ValidateBasic performs basic validation.
 func (cfg *StateSyncConfig) ValidateBasic() error {
+	// peers are served whether or not state sync is enabled
+	if cfg.MaxPeerPanics < 0 {
+		return errors.New("max-peer-panics can't be negative")
+	}
+
+	if cfg.ChunkReadAhead < 0 {
+		return errors.New("chunk-read-ahead can't be negative")
+	}
+
+	if cfg.ServeListenAddress != "" && cfg.ServeNodeKey == "" {
+		return errors.New("serve-node-key-file is required with serve-laddr")
+	}
+
+	if cfg.SnapshotChannelPriority <= 0 {
+		return errors.New("snapshot-channel-priority must be positive")
+	}
+
+	if cfg.ChunkChannelPriority <= 0 {
+		return errors.New("chunk-channel-priority must be positive")
+	}
+
+	if cfg.LightBlockChannelPriority <= 0 {
+		return errors.New("light-block-channel-priority must be positive")
+	}
+
+	if cfg.ParamsChannelPriority <= 0 {
+		return errors.New("params-channel-priority must be positive")
+	}
+
+	if cfg.SnapshotChannelMaxSendBytes <= 0 {
+		return errors.New("snapshot-channel-max-send-bytes must be positive")
+	}
+
+	if cfg.ChunkChannelMaxSendBytes <= 0 {
+		return errors.New("chunk-channel-max-send-bytes must be positive")
+	}
+
+	if cfg.LightBlockChannelMaxSendBytes <= 0 {
+		return errors.New("light-block-channel-max-send-bytes must be positive")
+	}
+
+	if cfg.ParamsChannelMaxSendBytes <= 0 {
+		return errors.New("params-channel-max-send-bytes must be positive")
+	}
+
+	if cfg.ListSnapshotsTimeout < 0 {
+		return errors.New("list-snapshots-timeout can't be negative")
+	}
+
+	if cfg.LightBlockServeRateLimit < 0 {
+		return errors.New("light-block-serve-rate-limit can't be negative")
+	}
+
 	if !cfg.Enable {
 		return nil
 	}
@@ -986,10 +1381,48 @@ func (cfg *StateSyncConfig) ValidateBasic() error {
 		}
 	}
 
+	if cfg.UseP2P && cfg.MaxStateProviders < 2 {
+		return errors.New("max-state-providers must be at least 2")
+	}
+
+	if cfg.StateProviderQuorum < 0 {
+		return errors.New("state-provider-quorum can't be negative")
+	}
+
+	if cfg.UseP2P && cfg.StateProviderQuorum > cfg.MaxStateProviders {
+		return errors.New("state-provider-quorum can't exceed max-state-providers")
+	}
+
 	if cfg.DiscoveryTime != 0 && cfg.DiscoveryTime < 5*time.Second {
 		return errors.New("discovery time must be 0s or greater than five seconds")
 	}
 
+	switch cfg.DiscoveryStrategy {
+	case DiscoveryStrategyBroadcast:
+	case DiscoveryStrategySample:
+		if cfg.DiscoverySampleSize <= 0 {
+			return errors.New("discovery-sample-size must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown discovery-strategy %q", cfg.DiscoveryStrategy)
+	}
+
+	if cfg.MaxSyncAttempts < 1 {
+		return errors.New("max-sync-attempts must be at least 1")
+	}
+
+	if cfg.PeerWaitTimeout < 0 {
+		return errors.New("peer-wait-timeout can't be negative")
+	}
+
+	if cfg.PeerCheckInterval <= 0 {
+		return errors.New("peer-check-interval must be positive")
+	}
+
+	if cfg.PeerCheckJitter < 0 {
+		return errors.New("peer-check-jitter can't be negative")
+	}
+
 	if cfg.TrustPeriod <= 0 {
 		return errors.New("trusted-period is required")
 	}
@@ -1002,10 +1435,21 @@ func (cfg *StateSyncConfig) ValidateBasic() error {
 		return errors.New("trusted-hash is required")
 	}
 
-	_, err := hex.DecodeString(cfg.TrustHash)
+	trustHash, err := hex.DecodeString(cfg.TrustHash)
 	if err != nil {
 		return fmt.Errorf("invalid trusted-hash: %w", err)
 	}
+	if len(trustHash) != tmhash.Size {
+		return fmt.Errorf("invalid trusted-hash: expected %d bytes, got %d", tmhash.Size, len(trustHash))
+	}
+
+	if cfg.TempDirPrefix == "" {
+		return errors.New("temp-dir-prefix is required")
+	}
+
+	if strings.ContainsRune(cfg.TempDirPrefix, filepath.Separator) {
+		return errors.New("temp-dir-prefix can't contain a path separator")
+	}
 
 	if cfg.ChunkRequestTimeout < 5*time.Second {
 		return errors.New("chunk-request-timeout must be at least 5 seconds")
@@ -1015,6 +1459,70 @@ func (cfg *StateSyncConfig) ValidateBasic() error {
 		return errors.New("fetchers is required")
 	}
 
+	switch cfg.ChunkChecksumAlgorithm {
+	case "", ChunkChecksumSHA256, ChunkChecksumBlake2b:
+	default:
+		return fmt.Errorf("unknown chunk-checksum-algorithm %q", cfg.ChunkChecksumAlgorithm)
+	}
+
+	if cfg.BackfillMismatchPeers < 0 {
+		return errors.New("backfill-mismatch-peers can't be negative")
+	}
+
+	if cfg.BackfillIdleWarnPercent < 0 || cfg.BackfillIdleWarnPercent > 100 {
+		return errors.New("backfill-idle-warn-percent must be between 0 and 100")
+	}
+
+	if cfg.MinChunkServingPeers < 0 {
+		return errors.New("min-chunk-serving-peers can't be negative")
+	}
+
+	if cfg.SnapshotAvailabilityGracePeriod < 0 {
+		return errors.New("snapshot-availability-grace-period can't be negative")
+	}
+
+	if cfg.MinUpgradeHeight < 0 {
+		return errors.New("min-upgrade-height can't be negative")
+	}
+
+	if cfg.SyncingServeRate < 0 {
+		return errors.New("syncing-serve-rate can't be negative")
+	}
+
+	if cfg.LightBlockRequestTimeout < 0 {
+		return errors.New("light-block-request-timeout can't be negative")
+	}
+
+	if cfg.BackfillRequestTimeout <= 0 {
+		return errors.New("backfill-request-timeout must be positive")
+	}
+
+	if cfg.BackfillMaxPendingBlocks < 0 {
+		return errors.New("backfill-max-pending-blocks can't be negative")
+	}
+
+	if cfg.MaxBackfillTime < 0 {
+		return errors.New("max-backfill-time can't be negative")
+	}
+
+	switch cfg.BackfillFailurePolicy {
+	case "", BackfillFailureProceed, BackfillFailureRetry, BackfillFailureAbort:
+	default:
+		return fmt.Errorf("unknown backfill-failure-policy %q", cfg.BackfillFailurePolicy)
+	}
+
+	if cfg.BackfillRetries < 0 {
+		return errors.New("backfill-retries can't be negative")
+	}
+
+	if cfg.BackfillCheckpointInterval < 0 {
+		return errors.New("backfill-checkpoint-interval can't be negative")
+	}
+
+	if cfg.BackfillCrossCheckWait < 0 {
+		return errors.New("backfill-cross-check-wait can't be negative")
+	}
+
 	return nil
 }
 
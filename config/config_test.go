@@ -2,6 +2,7 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -73,6 +74,9 @@ func TestRPCConfigValidateBasic(t *testing.T) {
 		"TimeoutBroadcastTxCommit",
 		"MaxBodyBytes",
 		"MaxHeaderBytes",
+		"MaxBlocksStreamRange",
+		"MaxQueryConditions",
+		"MaxConcurrentSinkQueries",
 	}
 
 	for _, fieldName := range fieldsToTest {
@@ -125,6 +129,87 @@ func TestStateSyncConfigValidateBasic(t *testing.T) {
 	require.NoError(t, cfg.ValidateBasic())
 }
 
+func TestStateSyncConfigValidateBasicTrustHash(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.Enable = true
+	cfg.UseP2P = true
+	cfg.TrustPeriod = time.Hour
+	cfg.TrustHeight = 1
+	cfg.TrustHash = strings.Repeat("ab", 32)
+	require.NoError(t, cfg.ValidateBasic())
+
+	// not hex
+	cfg.TrustHash = strings.Repeat("zz", 32)
+	require.Error(t, cfg.ValidateBasic())
+
+	// wrong length
+	cfg.TrustHash = strings.Repeat("ab", 20)
+	require.Error(t, cfg.ValidateBasic())
+}
+
+func TestStateSyncConfigValidateBasicDiscoveryStrategy(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.Enable = true
+	cfg.UseP2P = true
+	cfg.TrustPeriod = time.Hour
+	cfg.TrustHeight = 1
+	cfg.TrustHash = strings.Repeat("ab", 32)
+	cfg.DiscoveryStrategy = DiscoveryStrategySample
+	require.NoError(t, cfg.ValidateBasic())
+
+	cfg.DiscoverySampleSize = 0
+	require.Error(t, cfg.ValidateBasic())
+
+	cfg.DiscoveryStrategy = "gossip"
+	require.Error(t, cfg.ValidateBasic())
+}
+
+func TestStateSyncConfigValidateBasicPeerCheck(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.Enable = true
+	cfg.UseP2P = true
+	cfg.TrustPeriod = time.Hour
+	cfg.TrustHeight = 1
+	cfg.TrustHash = strings.Repeat("ab", 32)
+	cfg.PeerCheckJitter = 0
+	require.NoError(t, cfg.ValidateBasic())
+
+	cfg.PeerCheckJitter = -time.Millisecond
+	require.Error(t, cfg.ValidateBasic())
+
+	cfg.PeerCheckJitter = 0
+	cfg.PeerCheckInterval = 0
+	require.Error(t, cfg.ValidateBasic())
+}
+
+func TestStateSyncConfigValidateBasicChannelPriorities(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.LightBlockChannelPriority = 10
+	require.NoError(t, cfg.ValidateBasic())
+
+	// priorities are validated even when state sync is disabled
+	cfg.LightBlockChannelPriority = 0
+	require.Error(t, cfg.ValidateBasic())
+}
+
+func TestStateSyncConfigValidateBasicChannelMaxSendBytes(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.ChunkChannelMaxSendBytes = 4000
+	require.NoError(t, cfg.ValidateBasic())
+
+	cfg.ChunkChannelMaxSendBytes = 0
+	require.Error(t, cfg.ValidateBasic())
+}
+
+func TestStateSyncConfigValidateBasicServeListenAddress(t *testing.T) {
+	cfg := TestStateSyncConfig()
+	cfg.ServeListenAddress = "tcp://0.0.0.0:26660"
+	require.NoError(t, cfg.ValidateBasic())
+
+	cfg.ServeNodeKey = ""
+	require.Error(t, cfg.ValidateBasic())
+}
+
 func TestBlockSyncConfigValidateBasic(t *testing.T) {
 	cfg := TestBlockSyncConfig()
 	assert.NoError(t, cfg.ValidateBasic())
@@ -239,9 +239,27 @@ timeout-broadcast-tx-commit = "{{ .RPC.TimeoutBroadcastTxCommit }}"
 # Maximum size of request body, in bytes
 max-body-bytes = {{ .RPC.MaxBodyBytes }}
 
+# Maximum size of HTTP response body, in bytes. Larger responses are
+# replaced by an error. Websocket messages aren't limited. A value of 0
+# disables the limit.
+max-response-body-bytes = {{ .RPC.MaxResponseBodyBytes }}
+
 # Maximum size of request header, in bytes
 max-header-bytes = {{ .RPC.MaxHeaderBytes }}
 
+# Maximum number of blocks that can be requested in a single /blocks_stream
+# call. A value of 0 disables the limit. Only used by the inspect server.
+max-blocks-stream-range = {{ .RPC.MaxBlocksStreamRange }}
+
+# Maximum number of conditions a /tx_search or /block_search query may
+# contain. A value of 0 disables the limit. Only used by the inspect server.
+max-query-conditions = {{ .RPC.MaxQueryConditions }}
+
+# Maximum number of /tx_search and /block_search queries run against the
+# event sinks at the same time. Further queries wait for one to complete.
+# A value of 0 disables the limit. Only used by the inspect server.
+max-concurrent-sink-queries = {{ .RPC.MaxConcurrentSinkQueries }}
+
 # The path to a file containing certificate that is used to create the HTTPS server.
 # Might be either absolute path or path related to Tendermint's config directory.
 # If the certificate is signed by a certificate authority,
@@ -433,7 +451,20 @@ use-p2p = {{ .StateSync.UseP2P }}
 # for example: "host.example.com:2125"
 rpc-servers = "{{ StringsJoin .StateSync.RPCServers "," }}"
 
-# The hash and height of a trusted block. Must be within the trust-period.
+# If using P2P, the maximum number of peers, including the primary, that the
+# light client uses as providers. Further peers are kept as spares and only
+# rotated in when active providers fail (default: 6).
+max-state-providers = {{ .StateSync.MaxStateProviders }}
+
+# If using P2P, the number of providers that must serve a light block
+# identical to the one verified by the light client at the height holding
+# the app hash of a snapshot. All the providers are queried concurrently.
+# A value of 0 only relies on the light client's own cross-referencing
+# (default: 0).
+state-provider-quorum = {{ .StateSync.StateProviderQuorum }}
+
+# The hash and height of a trusted block. Must be within the trust-period. The hash must be
+# hex-encoded and 32 bytes long.
 trust-height = {{ .StateSync.TrustHeight }}
 trust-hash = "{{ .StateSync.TrustHash }}"
 
@@ -445,11 +476,50 @@ trust-period = "{{ .StateSync.TrustPeriod }}"
 # Time to spend discovering snapshots before initiating a restore.
 discovery-time = "{{ .StateSync.DiscoveryTime }}"
 
+# How snapshots are discovered, either "broadcast" to request snapshots
+# from all connected peers, or "sample" to request them from a random
+# sample of discovery-sample-size peers only. When sampling, the sample is
+# doubled with peers that weren't asked yet every time discovery finds no
+# suitable snapshot (default: "broadcast").
+discovery-strategy = "{{ .StateSync.DiscoveryStrategy }}"
+
+# The number of peers initially sampled by the "sample" discovery
+# strategy (default: 10).
+discovery-sample-size = {{ .StateSync.DiscoverySampleSize }}
+
+# The maximum number of times to run the whole snapshot discovery and
+# restoration cycle before state sync fails. Snapshots are requested from
+# peers again before every new attempt (default: 1).
+max-sync-attempts = {{ .StateSync.MaxSyncAttempts }}
+
+# The maximum amount of time to wait for enough peers to connect before
+# starting state sync. When exceeded, state sync fails. A value of 0
+# disables the limit (default: 0).
+peer-wait-timeout = "{{ .StateSync.PeerWaitTimeout }}"
+
+# How often to check whether enough peers have connected while waiting for
+# them before starting state sync. Each interval is extended by a random
+# duration of up to peer-check-jitter, so that nodes started together
+# don't check and request snapshots in lockstep (defaults: 200ms and 100ms).
+peer-check-interval = "{{ .StateSync.PeerCheckInterval }}"
+peer-check-jitter = "{{ .StateSync.PeerCheckJitter }}"
+
 # Temporary directory for state sync snapshot chunks, defaults to os.TempDir().
 # The synchronizer will create a new, randomly named directory within this directory
 # and remove it when the sync is complete.
 temp-dir = "{{ .StateSync.TempDir }}"
 
+# Prefix of the per-node subdirectory of temp-dir in which the synchronizer
+# stores its chunk files. The subdirectory is named after the prefix, the
+# chain ID and the node ID, so that multiple nodes can share temp-dir.
+temp-dir-prefix = "{{ .StateSync.TempDirPrefix }}"
+
+# Whether to keep the files of the chunks applied to the app in the temp
+# dir until the whole snapshot is restored, instead of removing them when
+# the sync stops. A sync restarted after a crash then reads the applied
+# chunks from disk rather than downloading them again.
+retain-applied-chunks = {{ .StateSync.RetainAppliedChunks }}
+
 # The timeout duration before re-requesting a chunk, possibly from a different
 # peer (default: 15 seconds).
 chunk-request-timeout = "{{ .StateSync.ChunkRequestTimeout }}"
@@ -457,6 +527,201 @@ chunk-request-timeout = "{{ .StateSync.ChunkRequestTimeout }}"
 # The number of concurrent chunk and block fetchers to run (default: 4).
 fetchers = "{{ .StateSync.Fetchers }}"
 
+# The algorithm used to checksum the snapshot chunks served to peers, either
+# "sha256" or "blake2b". Received chunks are verified using the algorithm
+# indicated by the serving peer. An empty value disables checksums
+# (default: "sha256").
+chunk-checksum-algorithm = "{{ .StateSync.ChunkChecksumAlgorithm }}"
+
+# The number of chunks loaded from the app ahead of each chunk served to a
+# peer, and cached for the peer's next requests of the same snapshot. It
+# saves ABCI round trips when peers request chunks sequentially, at the
+# cost of holding up to this many chunks in memory per peer, and at most
+# 64MB across all peers. A value of 0 disables read-ahead.
+chunk-read-ahead = {{ .StateSync.ChunkReadAhead }}
+
+# The snapshot formats advertised and served to peers. Snapshots of other
+# formats aren't advertised, and requests for their chunks are answered as
+# missing, so that peers running an older app don't download snapshots in
+# formats they can't apply, e.g. during a format migration. An empty list
+# serves all formats.
+serve-formats = [{{ range $i, $f := .StateSync.ServeFormats }}{{ if $i }}, {{ end }}{{ $f }}{{ end }}]
+
+# Whether to verify the light blocks at the height of a snapshot, and thus
+# its app hash, before fetching any of its chunks. When disabled, chunks
+# are fetched while the light blocks are verified (default: false).
+verify-snapshot-before-download = {{ .StateSync.VerifySnapshotBeforeDownload }}
+
+# The minimum number of distinct peers that must serve a randomly chosen
+# chunk of a snapshot before committing to restore it. The chunk is
+# requested from two more peers than required, and from other peers only
+# if too few of those serve it. Snapshots served by fewer peers are
+# rejected in favor of the next candidate. A value of 0 disables the check
+# (default: 0).
+min-chunk-serving-peers = {{ .StateSync.MinChunkServingPeers }}
+
+# How long no peer may advertise the snapshot being restored before it is
+# abandoned in favor of the next candidate, while its chunks are fetched.
+# Peers are checked periodically, so that a long sync doesn't hang on a
+# snapshot its serving peers have all left. A value of 0 disables the check.
+snapshot-availability-grace-period = "{{ .StateSync.SnapshotAvailabilityGracePeriod }}"
+
+# The height of the last breaking upgrade of the chain. Snapshots taken
+# below it are rejected as soon as they're advertised, as if no peer
+# served them, since restoring them would leave the node on the
+# pre-upgrade state. A value of 0 accepts snapshots of any height.
+min-upgrade-height = {{ .StateSync.MinUpgradeHeight }}
+
+# Whether to log every chunk request sent and every chunk response
+# received while restoring a snapshot, along with the peer, the chunk size
+# and the time the peer took to respond. Meant for diagnosing slow or stuck
+# snapshot downloads (default: false).
+log-chunk-transfers = {{ .StateSync.LogChunkTransfers }}
+
+# The maximum rate, in bytes per second, at which snapshot chunks are served
+# to other peers while the node is itself state syncing. Chunk requests
+# received while the rate is exceeded are ignored, leaving the requesting
+# peers to fetch the chunks elsewhere. The limit is lifted once the sync
+# completes. A value of 0 disables the limit (default: 0).
+syncing-serve-rate = {{ .StateSync.SyncingServeRate }}
+
+# The maximum amount of time to wait for a peer to return a light block
+# requested by the P2P state provider. A value of 0 leaves the requests
+# bounded by the light client only (default: 0).
+light-block-request-timeout = "{{ .StateSync.LightBlockRequestTimeout }}"
+
+# The maximum amount of time to wait for a peer to return a light block
+# requested during backfill, after which the block is requested again
+# from another peer (default: 10s).
+backfill-request-timeout = "{{ .StateSync.BackfillRequestTimeout }}"
+
+# The maximum number of light blocks fetched during backfill ahead of the
+# one being verified. Fetchers wait for blocks to be verified once it's
+# reached, bounding the memory held by fetched blocks when verification
+# lags behind, e.g. on chains with large validator sets. A value of 0
+# disables the limit.
+backfill-max-pending-blocks = {{ .StateSync.BackfillMaxPendingBlocks }}
+
+# The maximum number of light block requests per second served to each
+# peer. Excess requests are dropped, leaving the requesting peer to fetch
+# the light blocks elsewhere. A value of 0 disables the limit (default: 0).
+light-block-serve-rate-limit = {{ .StateSync.LightBlockServeRateLimit }}
+
+# The maximum amount of time to wait for the app to list its snapshots when
+# serving a peer. When exceeded, the snapshots listed by the previous call
+# are served instead. A value of 0 disables the limit (default: 10s).
+list-snapshots-timeout = "{{ .StateSync.ListSnapshotsTimeout }}"
+
+# Whether to compress the metadata of the snapshots advertised to peers
+# that support it, keeping snapshot messages of apps with large metadata
+# under the message size limit (default: false).
+compress-snapshot-metadata = {{ .StateSync.CompressSnapshotMetadata }}
+
+# Whether to skip backfilling historical blocks after a successful state
+# sync, bootstrapping the node with the restored state alone. This is the
+# fastest way to join consensus, but the node won't be able to serve, or
+# verify evidence for, any height below the snapshot (default: false).
+skip-backfill = {{ .StateSync.SkipBackfill }}
+
+# What to do when backfill fails after a successful state sync: "proceed"
+# starts the node with the blocks backfilled so far, "retry" runs backfill
+# again up to backfill-retries times, doubling the delay between attempts,
+# before proceeding, and "abort" fails the sync, shutting down the node.
+# Backfill stopped by max-backfill-time is never retried nor aborted
+# (default: "proceed").
+backfill-failure-policy = "{{ .StateSync.BackfillFailurePolicy }}"
+
+# The number of times backfill is run again after failing, when
+# backfill-failure-policy is "retry" (default: 3).
+backfill-retries = {{ .StateSync.BackfillRetries }}
+
+# The maximum amount of time to spend backfilling historical blocks after a
+# successful state sync. When exceeded, backfill stops at the height it has
+# reached. A value of 0 disables the limit (default: 0).
+max-backfill-time = "{{ .StateSync.MaxBackfillTime }}"
+
+# The number of heights between the checkpoints persisted during backfill.
+# Each checkpoint is a signed header along with its validator set, which
+# allows a partially backfilled range to be verified independently. A value
+# of 0 disables checkpointing (default: 0).
+backfill-checkpoint-interval = {{ .StateSync.BackfillCheckpointInterval }}
+
+# Whether backfill verifies that the commit of every fetched light block is
+# signed by +2/3 of the block's validators, rejecting peers that serve forged
+# signatures. Headers are authenticated by the hash chain down from the trusted
+# block, but the commits stored along with them are not (default: true).
+backfill-verify-commits = {{ .StateSync.BackfillVerifyCommits }}
+
+# The number of distinct peers that must return a light block whose hash
+# doesn't match the trusted block ID at the same height during backfill before
+# they are reported. Until then, the block is fetched again from another peer.
+# A value of 0 or 1 reports every such peer right away (default: 1).
+backfill-mismatch-peers = {{ .StateSync.BackfillMismatchPeers }}
+
+# Whether backfill fetches every verified light block again from up to two
+# other peers at once and checks that two of the responses are byte-identical.
+# This at least doubles the bandwidth used by backfill, but catches a primary
+# source serving subtly altered blocks. Backfill fails if fewer than two peers
+# are connected.
+backfill-cross-check = {{ .StateSync.BackfillCrossCheck }}
+
+# The time to wait before fetching a light block again when no other peer is
+# free to cross-check it, or when the peers that cross-checked it don't agree
+# (default: 1s).
+backfill-cross-check-wait = "{{ .StateSync.BackfillCrossCheckWait }}"
+
+# The percentage of time the backfill verifier may spend waiting for light
+# blocks to be fetched before a message suggesting to increase the number of
+# fetchers is logged. It is checked every minute. A value of 0 disables the
+# message (default: 80).
+backfill-idle-warn-percent = {{ .StateSync.BackfillIdleWarnPercent }}
+
+# The number of panics a peer's messages may trigger while being handled
+# before the peer is reported and disconnected. Until then, such messages
+# are dropped. A value of 0 or 1 reports the peer on its first panic
+# (default: 3).
+max-peer-panics = {{ .StateSync.MaxPeerPanics }}
+
+# Whether to check on startup that the heights of the block store and of
+# the state store agree, failing to start the state sync reactor if they
+# don't. A disagreement is left behind by an interrupted state sync, after
+# which the node must be re-synced.
+verify-store-consistency = {{ .StateSync.VerifyStoreConsistency }}
+
+# Address to listen on for peers' state sync requests over a dedicated p2p
+# transport, so that high-bandwidth snapshot and chunk serving doesn't
+# compete with the consensus traffic of the main p2p connections. The
+# listener has its own node key, whose ID peers use to add it as a
+# persistent or bootstrap peer, and only carries the state sync channels.
+# Snapshots and chunks are then no longer served over the main p2p
+# connections. Requires the new p2p stack. An empty address serves them
+# over the main p2p connections.
+serve-laddr = "{{ .StateSync.ServeListenAddress }}"
+
+# Path to the JSON file containing the private key of the serve-laddr
+# listener, generated if missing.
+serve-node-key-file = "{{ js .StateSync.ServeNodeKey }}"
+
+# The priorities of the state sync p2p channels, relative to each other and
+# to the channels of the other reactors. Channels with a higher priority
+# get a larger share of the bandwidth. Nodes that mostly serve backfilling
+# peers may want to raise the priority of the light block channel.
+snapshot-channel-priority = {{ .StateSync.SnapshotChannelPriority }}
+chunk-channel-priority = {{ .StateSync.ChunkChannelPriority }}
+light-block-channel-priority = {{ .StateSync.LightBlockChannelPriority }}
+params-channel-priority = {{ .StateSync.ParamsChannelPriority }}
+
+# The MaxSendBytes of the state sync p2p channels. It doesn't limit the
+# size of messages: with the "wdrr" p2p queue-type, it multiplies the
+# channel priority to give the number of bytes the channel may send per
+# scheduling round, so a message larger than that waits for several
+# rounds. Raising it for the chunk and light block channels lets their
+# large messages out sooner. Other queue types ignore it.
+snapshot-channel-max-send-bytes = {{ .StateSync.SnapshotChannelMaxSendBytes }}
+chunk-channel-max-send-bytes = {{ .StateSync.ChunkChannelMaxSendBytes }}
+light-block-channel-max-send-bytes = {{ .StateSync.LightBlockChannelMaxSendBytes }}
+params-channel-max-send-bytes = {{ .StateSync.ParamsChannelMaxSendBytes }}
+
 #######################################################
 ###       Block Sync Configuration Connections       ###
 #######################################################
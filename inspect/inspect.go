@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 
 	"github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/inspect/rpc"
 	"github.com/tendermint/tendermint/libs/log"
 	tmstrings "github.com/tendermint/tendermint/libs/strings"
+	"github.com/tendermint/tendermint/proxy"
 	rpccore "github.com/tendermint/tendermint/rpc/core"
 	"github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/state/indexer"
@@ -27,9 +29,13 @@ import (
 // any other components. A caller can query the Inspector service to inspect the
 // persisted state and debug the failure.
 type Inspector struct {
-	routes rpccore.RoutesMap
+	blockStore state.BlockStore
+	stateStore state.Store
+	eventSinks []indexer.EventSink
 
-	config *config.RPCConfig
+	config       *config.RPCConfig
+	upstream     *url.URL
+	snapshotConn proxy.AppConnSnapshot
 
 	indexerService *indexer.Service
 	eventBus       *types.EventBus
@@ -43,13 +49,14 @@ type Inspector struct {
 ///
 //nolint:lll
 func New(cfg *config.RPCConfig, bs state.BlockStore, ss state.Store, es []indexer.EventSink, logger log.Logger) *Inspector {
-	routes := rpc.Routes(*cfg, ss, bs, es, logger)
 	eb := types.NewEventBus()
 	eb.SetLogger(logger.With("module", "events"))
 	is := indexer.NewIndexerService(es, eb)
 	is.SetLogger(logger.With("module", "txindex"))
 	return &Inspector{
-		routes:         routes,
+		blockStore:     bs,
+		stateStore:     ss,
+		eventSinks:     es,
 		config:         cfg,
 		logger:         logger,
 		eventBus:       eb,
@@ -81,6 +88,30 @@ func NewFromConfig(cfg *config.Config) (*Inspector, error) {
 	return New(cfg.RPC, bs, ss, sinks, logger), nil
 }
 
+// SetUpstream configures the Inspector to forward the JSON-RPC methods it does not
+// serve locally to the RPC server of the node at the given address. Only the
+// read-only methods listed in rpc.ProxyableMethods are forwarded. SetUpstream must
+// be called before Run.
+func (ins *Inspector) SetUpstream(addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("invalid upstream address %q: %w", addr, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid upstream address %q: scheme must be http or https", addr)
+	}
+	ins.upstream = u
+	return nil
+}
+
+// SetSnapshotConn configures the Inspector to serve the list_snapshots route,
+// listing the snapshots of the app over the given connection. Without it, the
+// route reports that it is not available. SetSnapshotConn must be called before
+// Run.
+func (ins *Inspector) SetSnapshotConn(conn proxy.AppConnSnapshot) {
+	ins.snapshotConn = conn
+}
+
 // Run starts the Inspector servers and blocks until the servers shut down. The passed
 // in context is used to control the lifecycle of the servers.
 func (ins *Inspector) Run(ctx context.Context) error {
@@ -104,13 +135,20 @@ func (ins *Inspector) Run(ctx context.Context) error {
 			ins.logger.Error("indexer service stopped with error", "err", err)
 		}
 	}()
-	return startRPCServers(ctx, ins.config, ins.logger, ins.routes)
+	routes := rpc.Routes(*ins.config, ins.stateStore, ins.blockStore, ins.eventSinks, ins.snapshotConn, ins.logger)
+	return startRPCServers(ctx, ins.config, ins.logger, routes, ins.upstream)
 }
 
-func startRPCServers(ctx context.Context, cfg *config.RPCConfig, logger log.Logger, routes rpccore.RoutesMap) error {
+func startRPCServers(
+	ctx context.Context,
+	cfg *config.RPCConfig,
+	logger log.Logger,
+	routes rpccore.RoutesMap,
+	upstream *url.URL,
+) error {
 	g, tctx := errgroup.WithContext(ctx)
 	listenAddrs := tmstrings.SplitAndTrimEmpty(cfg.ListenAddress, ",", " ")
-	rh := rpc.Handler(cfg, routes, logger)
+	rh := rpc.Handler(cfg, routes, upstream, logger)
 	for _, listenerAddr := range listenAddrs {
 		server := rpc.Server{
 			Logger:  logger,
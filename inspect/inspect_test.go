@@ -2,8 +2,13 @@ package inspect_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"sync"
@@ -16,10 +21,21 @@ import (
 	abcitypes "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/inspect"
+	inspectrpc "github.com/tendermint/tendermint/inspect/rpc"
+	"github.com/tendermint/tendermint/internal/test/factory"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmjson "github.com/tendermint/tendermint/libs/json"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/pubsub/query"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
 	"github.com/tendermint/tendermint/proto/tendermint/state"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
 	httpclient "github.com/tendermint/tendermint/rpc/client/http"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	jsonrpcclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	sm "github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/state/indexer"
 	indexermocks "github.com/tendermint/tendermint/state/indexer/mocks"
 	statemocks "github.com/tendermint/tendermint/state/mocks"
@@ -66,12 +82,14 @@ func TestBlock(t *testing.T) {
 	stateStoreMock := &statemocks.Store{}
 
 	blockStoreMock := &statemocks.BlockStore{}
-	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("Height").Return(testHeight + 1)
 	blockStoreMock.On("Base").Return(int64(0))
 	blockStoreMock.On("LoadBlockMeta", testHeight).Return(&types.BlockMeta{})
+	blockStoreMock.On("LoadBlockMeta", testHeight+1).Return(nil)
 	blockStoreMock.On("LoadBlock", testHeight).Return(testBlock)
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
@@ -104,6 +122,90 @@ func TestBlock(t *testing.T) {
 	stateStoreMock.AssertExpectations(t)
 }
 
+func TestProtobufResults(t *testing.T) {
+	testHeight := int64(1)
+	testBlock := new(types.Block)
+	testBlock.Header.Height = testHeight
+	testBlock.Header.LastCommitHash = []byte("test hash")
+	stateStoreMock := &statemocks.Store{}
+
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight + 1)
+	blockStoreMock.On("Base").Return(int64(0))
+	blockStoreMock.On("LoadBlockMeta", testHeight).Return(&types.BlockMeta{})
+	blockStoreMock.On("LoadBlockMeta", testHeight+1).Return(nil)
+	blockStoreMock.On("LoadBlock", testHeight).Return(testBlock)
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	addr := strings.Replace(rpcConfig.ListenAddress, "tcp://", "http://", 1)
+
+	get := func(path, accept string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, addr+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// the block is served as protobuf when the client accepts it
+	resp := get("/block?height=1", "application/json, "+inspectrpc.ContentTypeProtobuf)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, inspectrpc.ContentTypeProtobuf, resp.Header.Get("Content-Type"))
+	pb := new(tmproto.Block)
+	require.NoError(t, pb.Unmarshal(body))
+	require.Equal(t, testBlock.Height, pb.Header.Height)
+	require.Equal(t, []byte(testBlock.LastCommitHash), pb.Header.LastCommitHash)
+
+	// and as JSON otherwise
+	resp = get("/block?height=1", "application/json")
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	// errors are reported as JSON
+	resp = get("/block?height=3", inspectrpc.ContentTypeProtobuf)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	// as are the heights whose block meta is missing, for which there is no
+	// block to encode
+	for _, path := range []string{"/block?height=2", "/commit?height=2"} {
+		resp = get(path, inspectrpc.ContentTypeProtobuf)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"), path)
+	}
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
 func TestTxSearch(t *testing.T) {
 	testHash := []byte("test")
 	testTx := []byte("tx")
@@ -216,6 +318,7 @@ func TestConsensusParams(t *testing.T) {
 	}, nil)
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -267,6 +370,7 @@ func TestBlockResults(t *testing.T) {
 	blockStoreMock.On("Height").Return(testHeight)
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -313,6 +417,7 @@ func TestCommit(t *testing.T) {
 	}, nil)
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -346,6 +451,320 @@ func TestCommit(t *testing.T) {
 	stateStoreMock.AssertExpectations(t)
 }
 
+func TestLatestCommit(t *testing.T) {
+	testHeight := int64(10)
+	testRound := int32(101)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("LoadBlockMeta", testHeight).Return(&types.BlockMeta{
+		Header: types.Header{Height: testHeight},
+	})
+	blockStoreMock.On("LoadSeenCommit").Return(&types.Commit{
+		Height: testHeight,
+		Round:  testRound,
+	})
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// the commit of the latest block is returned without asking for its height
+	res := new(coretypes.ResultCommit)
+	_, err = cli.Call(context.Background(), "latest_commit", map[string]interface{}{}, res)
+	require.NoError(t, err)
+	require.Equal(t, testHeight, res.SignedHeader.Header.Height)
+	require.Equal(t, testHeight, res.SignedHeader.Commit.Height)
+	require.Equal(t, testRound, res.SignedHeader.Commit.Round)
+	require.False(t, res.CanonicalCommit)
+
+	// as does the commit route given a height of 0 or no height at all
+	for _, params := range []map[string]interface{}{{"height": 0}, {}} {
+		res := new(coretypes.ResultCommit)
+		_, err = cli.Call(context.Background(), "commit", params, res)
+		require.NoError(t, err)
+		require.Equal(t, testHeight, res.SignedHeader.Header.Height)
+		require.Equal(t, testRound, res.SignedHeader.Commit.Round)
+		require.False(t, res.CanonicalCommit)
+	}
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestEvidenceParams(t *testing.T) {
+	testHeight := int64(10)
+	testParams := types.EvidenceParams{
+		MaxAgeNumBlocks: 1000,
+		MaxAgeDuration:  48 * time.Hour,
+		MaxBytes:        1024,
+	}
+	stateStoreMock := &statemocks.Store{}
+	stateStoreMock.On("LoadConsensusParams", testHeight).Return(types.ConsensusParams{Evidence: testParams}, nil)
+	stateStoreMock.On("LoadConsensusParams", int64(20)).Return(types.ConsensusParams{}, errors.New("not found"))
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// a height of 0 defaults to the latest block
+	res := new(inspectrpc.ResultEvidenceParams)
+	_, err = cli.Call(context.Background(), "evidence_params", map[string]interface{}{"height": 0}, res)
+	require.NoError(t, err)
+	require.Equal(t, testHeight, res.Height)
+	require.Equal(t, testParams, res.EvidenceParams)
+
+	_, err = cli.Call(context.Background(), "evidence_params", map[string]interface{}{"height": 20}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestSeenCommit(t *testing.T) {
+	testHeight := int64(10)
+	testRound := int32(3)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("LoadSeenCommit").Return(&types.Commit{
+		Height: testHeight,
+		Round:  testRound,
+	})
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	for _, height := range []int64{0, testHeight} {
+		res := new(inspectrpc.ResultSeenCommit)
+		_, err = cli.Call(context.Background(), "seen_commit", map[string]interface{}{"height": height}, res)
+		require.NoError(t, err)
+		require.Equal(t, testHeight, res.Height)
+		require.Equal(t, testHeight, res.Commit.Height)
+		require.Equal(t, testRound, res.Commit.Round)
+	}
+
+	// only the latest seen commit is stored
+	_, err = cli.Call(context.Background(), "seen_commit", map[string]interface{}{"height": testHeight - 1},
+		new(inspectrpc.ResultSeenCommit))
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestVerifyChain(t *testing.T) {
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(int64(5))
+	blockStoreMock.On("Base").Return(int64(1))
+	var lastBlockID types.BlockID
+	for h := int64(1); h <= 5; h++ {
+		header := types.Header{Height: h, ValidatorsHash: []byte{1}, LastBlockID: lastBlockID}
+		if h == 4 {
+			// the stored header at height 4 doesn't chain to the one at height 3
+			header.LastBlockID = types.BlockID{Hash: []byte{4}}
+		}
+		blockStoreMock.On("LoadBlockMeta", h).Return(&types.BlockMeta{Header: header})
+		lastBlockID = types.BlockID{Hash: header.Hash()}
+	}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultVerifyChain)
+	_, err = cli.Call(context.Background(), "verify_chain", map[string]interface{}{
+		"minHeight": 1, "maxHeight": 3,
+	}, res)
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+	require.Nil(t, res.Discontinuity)
+
+	// the whole chain is verified by default
+	res = new(inspectrpc.ResultVerifyChain)
+	_, err = cli.Call(context.Background(), "verify_chain", map[string]interface{}{}, res)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.MinHeight)
+	require.EqualValues(t, 5, res.MaxHeight)
+	require.False(t, res.Valid)
+	require.NotNil(t, res.Discontinuity)
+	require.EqualValues(t, 4, res.Discontinuity.Height)
+	require.EqualValues(t, []byte{4}, res.Discontinuity.LastBlockHash)
+
+	_, err = cli.Call(context.Background(), "verify_chain", map[string]interface{}{
+		"minHeight": 4, "maxHeight": 3,
+	}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestListSnapshots(t *testing.T) {
+	testSnapshots := []*abcitypes.Snapshot{
+		{Height: 10, Format: 1, Chunks: 2, Hash: []byte{1, 2}, Metadata: []byte{3}},
+		{Height: 20, Format: 1, Chunks: 1, Hash: []byte{4, 5}, BaseHeight: 10},
+	}
+	snapshotConnMock := &proxymocks.AppConnSnapshot{}
+	snapshotConnMock.On("ListSnapshotsSync", mock.Anything, abcitypes.RequestListSnapshots{}).
+		Return(&abcitypes.ResponseListSnapshots{Snapshots: testSnapshots}, nil)
+
+	testCases := []struct {
+		name         string
+		snapshotConn bool
+	}{
+		{"with snapshot connection", true},
+		{"without snapshot connection", false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			stateStoreMock := &statemocks.Store{}
+			blockStoreMock := &statemocks.BlockStore{}
+			eventSinkMock := &indexermocks.EventSink{}
+			eventSinkMock.On("Stop").Return(nil)
+			eventSinkMock.On("Type").Return(indexer.KV)
+
+			rpcConfig := config.TestRPCConfig()
+			l := log.TestingLogger()
+			d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+			if tc.snapshotConn {
+				d.SetSnapshotConn(snapshotConnMock)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+
+			startedWG := &sync.WaitGroup{}
+			startedWG.Add(1)
+			go func() {
+				startedWG.Done()
+				defer wg.Done()
+				require.NoError(t, d.Run(ctx))
+			}()
+			// FIXME: used to induce context switch.
+			// Determine more deterministic method for prompting a context switch
+			startedWG.Wait()
+			requireConnect(t, rpcConfig.ListenAddress, 20)
+			cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+			require.NoError(t, err)
+
+			res := new(inspectrpc.ResultListSnapshots)
+			_, err = cli.Call(context.Background(), "list_snapshots", map[string]interface{}{}, res)
+			if tc.snapshotConn {
+				require.NoError(t, err)
+				require.Len(t, res.Snapshots, 2)
+				require.Equal(t, uint64(10), res.Snapshots[0].Height)
+				require.Equal(t, tmbytes.HexBytes{1, 2}, res.Snapshots[0].Hash)
+				require.Equal(t, tmbytes.HexBytes{3}, res.Snapshots[0].Metadata)
+				require.Equal(t, uint64(10), res.Snapshots[1].BaseHeight)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "not available")
+			}
+
+			cancel()
+			wg.Wait()
+		})
+	}
+	snapshotConnMock.AssertExpectations(t)
+}
+
 func TestBlockByHash(t *testing.T) {
 	testHeight := int64(1)
 	testHash := []byte("test hash")
@@ -365,6 +784,7 @@ func TestBlockByHash(t *testing.T) {
 	blockStoreMock.On("LoadBlockByHash", testHash).Return(testBlock, nil)
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -416,6 +836,7 @@ func TestBlockchain(t *testing.T) {
 	})
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -467,6 +888,7 @@ func TestValidators(t *testing.T) {
 	blockStoreMock.On("Base").Return(int64(0))
 	eventSinkMock := &indexermocks.EventSink{}
 	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
 	rpcConfig := config.TestRPCConfig()
 	l := log.TestingLogger()
 	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
@@ -563,21 +985,800 @@ func TestBlockSearch(t *testing.T) {
 	stateStoreMock.AssertExpectations(t)
 }
 
-func requireConnect(t testing.TB, addr string, retries int) {
-	parts := strings.SplitN(addr, "://", 2)
-	if len(parts) != 2 {
-		t.Fatalf("malformed address to dial: %s", addr)
-	}
-	var err error
-	for i := 0; i < retries; i++ {
-		var conn net.Conn
-		conn, err = net.Dial(parts[0], parts[1])
-		if err == nil {
-			conn.Close()
-			return
-		}
-		// FIXME attempt to yield and let the other goroutine continue execution.
-		time.Sleep(time.Microsecond * 100)
-	}
-	t.Fatalf("unable to connect to server %s after %d tries: %s", addr, retries, err)
+func TestSearchQueryConditionsLimit(t *testing.T) {
+	testQuery := "tx.height > 1 AND tx.height < 5 AND account.owner = 'Ivan'"
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	rpcConfig.MaxQueryConditions = 2
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := httpclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// queries with more conditions than allowed are rejected before reaching
+	// the sink
+	var page = 1
+	_, err = cli.TxSearch(context.Background(), testQuery, false, &page, &page, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeding the maximum of 2")
+	_, err = cli.BlockSearch(context.Background(), testQuery, &page, &page, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeding the maximum of 2")
+	eventSinkMock.AssertNotCalled(t, "SearchTxEvents", mock.Anything, mock.Anything)
+	eventSinkMock.AssertNotCalled(t, "SearchBlockEvents", mock.Anything, mock.Anything)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestSearchUnavailable(t *testing.T) {
+	testQuery := "tx.height = 1"
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.NULL)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := httpclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// searches are rejected with a clear error when only a null sink is
+	// configured
+	var page = 1
+	_, err = cli.TxSearch(context.Background(), testQuery, false, &page, &page, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "search not available: no indexer configured")
+	_, err = cli.BlockSearch(context.Background(), testQuery, &page, &page, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "search not available: no indexer configured")
+	eventSinkMock.AssertNotCalled(t, "SearchTxEvents", mock.Anything, mock.Anything)
+	eventSinkMock.AssertNotCalled(t, "SearchBlockEvents", mock.Anything, mock.Anything)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestSearchConcurrencyLimit(t *testing.T) {
+	testQuery := "tx.height = 1"
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+	eventSinkMock.On("SearchTxEvents", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			started <- struct{}{}
+			<-release
+		}).
+		Return([]*abcitypes.TxResult{}, nil)
+
+	rpcConfig := config.TestRPCConfig()
+	rpcConfig.MaxConcurrentSinkQueries = 1
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := httpclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var page = 1
+			_, err := cli.TxSearch(context.Background(), testQuery, false, &page, &page, "")
+			errCh <- err
+		}()
+	}
+
+	// the second search only reaches the sink once the first one completes
+	<-started
+	select {
+	case <-started:
+		t.Fatal("concurrent search exceeded the limit")
+	case <-time.After(200 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	require.NoError(t, <-errCh)
+	require.NoError(t, <-errCh)
+
+	cancel()
+	wg.Wait()
+}
+
+func requireConnect(t testing.TB, addr string, retries int) {
+	parts := strings.SplitN(addr, "://", 2)
+	if len(parts) != 2 {
+		t.Fatalf("malformed address to dial: %s", addr)
+	}
+	var err error
+	for i := 0; i < retries; i++ {
+		var conn net.Conn
+		conn, err = net.Dial(parts[0], parts[1])
+		if err == nil {
+			conn.Close()
+			return
+		}
+		// FIXME attempt to yield and let the other goroutine continue execution.
+		time.Sleep(time.Microsecond * 100)
+	}
+	t.Fatalf("unable to connect to server %s after %d tries: %s", addr, retries, err)
+}
+
+type pingableEventSink struct {
+	*indexermocks.EventSink
+	err error
+}
+
+func (s pingableEventSink) Ping(context.Context) error { return s.err }
+
+func TestSinksHealth(t *testing.T) {
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	kvSinkMock := &indexermocks.EventSink{}
+	kvSinkMock.On("Stop").Return(nil)
+	kvSinkMock.On("Type").Return(indexer.KV)
+	psqlSinkMock := &indexermocks.EventSink{}
+	psqlSinkMock.On("Stop").Return(nil)
+	psqlSinkMock.On("Type").Return(indexer.PSQL)
+	psqlSink := pingableEventSink{EventSink: psqlSinkMock, err: errors.New("connection refused")}
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{kvSinkMock, psqlSink}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultSinksHealth)
+	_, err = cli.Call(context.Background(), "sinks_health", map[string]interface{}{}, res)
+	require.NoError(t, err)
+	require.Len(t, res.Sinks, 2)
+	require.Equal(t, indexer.KV, res.Sinks[0].Type)
+	require.True(t, res.Sinks[0].Available)
+	require.Equal(t, indexer.PSQL, res.Sinks[1].Type)
+	require.False(t, res.Sinks[1].Available)
+	require.Equal(t, "connection refused", res.Sinks[1].Error)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestUpstreamProxy(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		methods []string
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpctypes.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mtx.Lock()
+		methods = append(methods, req.Method)
+		mtx.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(
+			rpctypes.NewRPCSuccessResponse(req.ID, coretypes.ResultNetInfo{NPeers: 3})))
+	}))
+	defer upstream.Close()
+
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	require.Error(t, d.SetUpstream("tcp://127.0.0.1:26657"))
+	require.NoError(t, d.SetUpstream(upstream.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// read-only routes not served by the Inspector are proxied.
+	netInfo := new(coretypes.ResultNetInfo)
+	_, err = cli.Call(context.Background(), "net_info", map[string]interface{}{}, netInfo)
+	require.NoError(t, err)
+	require.Equal(t, 3, netInfo.NPeers)
+
+	// routes served by the Inspector are never proxied.
+	health := new(inspectrpc.ResultSinksHealth)
+	_, err = cli.Call(context.Background(), "sinks_health", map[string]interface{}{}, health)
+	require.NoError(t, err)
+	require.Len(t, health.Sinks, 1)
+
+	// routes that modify the upstream node are never proxied.
+	_, err = cli.Call(context.Background(), "broadcast_tx_sync",
+		map[string]interface{}{"tx": []byte{0x01}}, new(coretypes.ResultBroadcastTx))
+	require.Error(t, err)
+
+	mtx.Lock()
+	require.Equal(t, []string{"net_info"}, methods)
+	mtx.Unlock()
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestBlocksStream(t *testing.T) {
+	testHeight := int64(5)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("Base").Return(int64(1))
+	for h := int64(2); h <= 4; h++ {
+		block := new(types.Block)
+		block.Header.Height = h
+		blockStoreMock.On("LoadBlock", h).Return(block)
+		blockStoreMock.On("LoadBlockMeta", h).Return(&types.BlockMeta{
+			BlockID: types.BlockID{Hash: []byte(fmt.Sprintf("hash%d", h))},
+		})
+	}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	rpcConfig.MaxBlocksStreamRange = 3
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.NewWS(rpcConfig.ListenAddress, "/websocket")
+	require.NoError(t, err)
+	require.NoError(t, cli.Start())
+
+	// ranges larger than the configured maximum are rejected
+	err = cli.Call(context.Background(), "blocks_stream", map[string]interface{}{"minHeight": 1, "maxHeight": 5})
+	require.NoError(t, err)
+	resp := <-cli.ResponsesCh
+	require.Error(t, resp.Error)
+
+	err = cli.Call(context.Background(), "blocks_stream", map[string]interface{}{"minHeight": 2, "maxHeight": 4})
+	require.NoError(t, err)
+	var heights []int64
+	for i := 0; i < 4; i++ {
+		resp := <-cli.ResponsesCh
+		require.Nil(t, resp.Error)
+		if strings.Contains(string(resp.Result), "block_id") {
+			res := new(coretypes.ResultBlock)
+			require.NoError(t, tmjson.Unmarshal(resp.Result, res))
+			require.Equal(t, []byte(fmt.Sprintf("hash%d", res.Block.Height)), []byte(res.BlockID.Hash))
+			heights = append(heights, res.Block.Height)
+			continue
+		}
+		res := new(inspectrpc.ResultBlocksStream)
+		require.NoError(t, tmjson.Unmarshal(resp.Result, res))
+		require.Equal(t, int64(2), res.MinHeight)
+		require.Equal(t, int64(4), res.MaxHeight)
+	}
+	require.Equal(t, []int64{2, 3, 4}, heights)
+
+	require.NoError(t, cli.Stop())
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestBlockTimes(t *testing.T) {
+	testHeight := int64(10)
+	testTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("Base").Return(int64(1))
+	for h := int64(8); h <= testHeight; h++ {
+		blockStoreMock.On("LoadBlockMeta", h).Return(&types.BlockMeta{
+			Header: types.Header{Height: h, Time: testTime.Add(time.Duration(h) * time.Second)},
+		})
+	}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultBlockTimes)
+	_, err = cli.Call(context.Background(), "block_times", map[string]interface{}{"count": 3}, res)
+	require.NoError(t, err)
+	require.Len(t, res.BlockTimes, 3)
+	for i, bt := range res.BlockTimes {
+		h := int64(8 + i)
+		require.Equal(t, h, bt.Height)
+		require.True(t, testTime.Add(time.Duration(h)*time.Second).Equal(bt.Time))
+	}
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestTxCounts(t *testing.T) {
+	testHeight := int64(10)
+	testTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("Base").Return(int64(1))
+	for h := int64(4); h <= 7; h++ {
+		blockStoreMock.On("LoadBlockMeta", h).Return(&types.BlockMeta{
+			Header: types.Header{Height: h, Time: testTime.Add(time.Duration(h) * time.Second)},
+			NumTxs: int(h * 2),
+		})
+	}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// the second page of the range [2, 7], two heights per page
+	res := new(inspectrpc.ResultTxCounts)
+	_, err = cli.Call(context.Background(), "tx_counts", map[string]interface{}{
+		"minHeight": 2, "maxHeight": 7, "page": 2, "per_page": 2,
+	}, res)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, res.Total)
+	require.Len(t, res.TxCounts, 2)
+	for i, tc := range res.TxCounts {
+		h := int64(4 + i)
+		require.Equal(t, h, tc.Height)
+		require.Equal(t, int(h*2), tc.NumTxs)
+		require.True(t, testTime.Add(time.Duration(h)*time.Second).Equal(tc.Time))
+	}
+
+	// the last page is truncated to the range
+	_, err = cli.Call(context.Background(), "tx_counts", map[string]interface{}{
+		"minHeight": 2, "maxHeight": 7, "page": 2, "per_page": 4,
+	}, res)
+	require.NoError(t, err)
+	require.Len(t, res.TxCounts, 2)
+	require.Equal(t, int64(6), res.TxCounts[0].Height)
+
+	_, err = cli.Call(context.Background(), "tx_counts", map[string]interface{}{
+		"minHeight": 2, "maxHeight": 7, "page": 4, "per_page": 2,
+	}, res)
+	require.Error(t, err)
+
+	_, err = cli.Call(context.Background(), "tx_counts", map[string]interface{}{
+		"minHeight": 8, "maxHeight": 7,
+	}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestAppHash(t *testing.T) {
+	testHeight := int64(10)
+	appHash := tmrand.Bytes(32)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("LoadBlockMeta", testHeight).Return(&types.BlockMeta{
+		Header: types.Header{Height: testHeight, AppHash: appHash},
+	})
+	blockStoreMock.On("LoadBlockMeta", testHeight+1).Return(nil)
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// a height of 0 defaults to the latest height
+	res := new(inspectrpc.ResultAppHash)
+	_, err = cli.Call(context.Background(), "app_hash", map[string]interface{}{"height": 0}, res)
+	require.NoError(t, err)
+	require.Equal(t, testHeight, res.Height)
+	require.Equal(t, tmbytes.HexBytes(appHash), res.AppHash)
+
+	_, err = cli.Call(context.Background(), "app_hash", map[string]interface{}{"height": testHeight + 1}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestTxCount(t *testing.T) {
+	testHeight := int64(10)
+	stateStoreMock := &statemocks.Store{}
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("Base").Return(int64(3))
+	for h := int64(3); h <= testHeight; h++ {
+		blockStoreMock.On("LoadBlockMeta", h).Return(&types.BlockMeta{
+			Header: types.Header{Height: h},
+			NumTxs: int(h),
+		})
+	}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultTxCount)
+	_, err = cli.Call(context.Background(), "tx_count", map[string]interface{}{
+		"minHeight": 4, "maxHeight": 6,
+	}, res)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), res.MinHeight)
+	require.Equal(t, int64(6), res.MaxHeight)
+	require.Equal(t, int64(4+5+6), res.NumTxs)
+
+	// the range defaults to all the blocks in the block store
+	_, err = cli.Call(context.Background(), "tx_count", map[string]interface{}{}, res)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.MinHeight)
+	require.Equal(t, testHeight, res.MaxHeight)
+	require.Equal(t, int64(3+4+5+6+7+8+9+10), res.NumTxs)
+
+	_, err = cli.Call(context.Background(), "tx_count", map[string]interface{}{
+		"minHeight": 8, "maxHeight": 7,
+	}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestLightBlock(t *testing.T) {
+	testHeight := int64(10)
+	vals, _ := factory.RandValidatorSet(1, 10)
+	header := types.Header{
+		ChainID:        factory.DefaultTestChainID,
+		Height:         testHeight,
+		Time:           time.Now().UTC(),
+		ValidatorsHash: vals.Hash(),
+	}
+	commit := &types.Commit{Height: testHeight, BlockID: factory.MakeBlockIDWithHash(header.Hash())}
+	stateStoreMock := &statemocks.Store{}
+	stateStoreMock.On("LoadValidators", testHeight).Return(vals, nil)
+	blockStoreMock := &statemocks.BlockStore{}
+	blockStoreMock.On("Height").Return(testHeight)
+	blockStoreMock.On("LoadBlockMeta", testHeight).Return(&types.BlockMeta{Header: header})
+	blockStoreMock.On("LoadBlockMeta", testHeight+1).Return(nil)
+	blockStoreMock.On("LoadBlockCommit", testHeight).Return(commit)
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	// a height of 0 returns the latest light block
+	res := new(inspectrpc.ResultLightBlock)
+	_, err = cli.Call(context.Background(), "light_block", map[string]interface{}{"height": 0}, res)
+	require.NoError(t, err)
+	require.Equal(t, header.Hash(), res.LightBlock.Hash())
+	require.Equal(t, commit.BlockID, res.LightBlock.Commit.BlockID)
+	require.Equal(t, vals.Hash(), res.LightBlock.ValidatorSet.Hash())
+
+	_, err = cli.Call(context.Background(), "light_block", map[string]interface{}{"height": testHeight + 1}, res)
+	require.Error(t, err)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestStateDiff(t *testing.T) {
+	valsA, _ := factory.RandValidatorSet(3, 10)
+	kept, changed, removed := valsA.Validators[0].Copy(), valsA.Validators[1].Copy(), valsA.Validators[2]
+	changed.VotingPower = 20
+	added, _ := factory.RandValidator(false, 5)
+	valsB := types.NewValidatorSet([]*types.Validator{kept, changed, added})
+
+	paramsA := *types.DefaultConsensusParams()
+	paramsB := paramsA
+	paramsB.Block.MaxBytes = 2 * paramsA.Block.MaxBytes
+
+	stateStoreMock := &statemocks.Store{}
+	stateStoreMock.On("LoadValidators", int64(10)).Return(valsA, nil)
+	stateStoreMock.On("LoadValidators", int64(20)).Return(valsB, nil)
+	stateStoreMock.On("LoadValidators", int64(1)).Return(nil, errors.New("pruned"))
+	stateStoreMock.On("LoadConsensusParams", int64(10)).Return(paramsA, nil)
+	stateStoreMock.On("LoadConsensusParams", int64(20)).Return(paramsB, nil)
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultStateDiff)
+	_, err = cli.Call(context.Background(), "state_diff", map[string]interface{}{"heightA": 10, "heightB": 20}, res)
+	require.NoError(t, err)
+	require.Len(t, res.AddedValidators, 1)
+	require.Equal(t, added.Address, res.AddedValidators[0].Address)
+	require.Len(t, res.RemovedValidators, 1)
+	require.Equal(t, removed.Address, res.RemovedValidators[0].Address)
+	require.Equal(t, []inspectrpc.ValidatorChange{{
+		Address: changed.Address, VotingPowerA: 10, VotingPowerB: 20,
+	}}, res.ChangedValidators)
+	require.NotNil(t, res.ConsensusParams)
+	require.Equal(t, []string{"block"}, res.ConsensusParams.Sections)
+	require.Equal(t, paramsB.Block, res.ConsensusParams.ParamsB.Block)
+
+	// the state at height 1 has been pruned
+	_, err = cli.Call(context.Background(), "state_diff", map[string]interface{}{"heightA": 1, "heightB": 20}, res)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not available")
+
+	cancel()
+	wg.Wait()
+
+	stateStoreMock.AssertExpectations(t)
+}
+
+func TestValidatorSetHeights(t *testing.T) {
+	ranges := []sm.HeightRange{{Min: 3, Max: 5}, {Min: 10, Max: 13}}
+	stateStoreMock := &statemocks.Store{}
+	stateStoreMock.On("LoadValidatorSetHeights").Return(ranges, nil)
+	blockStoreMock := &statemocks.BlockStore{}
+	eventSinkMock := &indexermocks.EventSink{}
+	eventSinkMock.On("Stop").Return(nil)
+	eventSinkMock.On("Type").Return(indexer.KV)
+
+	rpcConfig := config.TestRPCConfig()
+	l := log.TestingLogger()
+	d := inspect.New(rpcConfig, blockStoreMock, stateStoreMock, []indexer.EventSink{eventSinkMock}, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	startedWG := &sync.WaitGroup{}
+	startedWG.Add(1)
+	go func() {
+		startedWG.Done()
+		defer wg.Done()
+		require.NoError(t, d.Run(ctx))
+	}()
+	// FIXME: used to induce context switch.
+	// Determine more deterministic method for prompting a context switch
+	startedWG.Wait()
+	requireConnect(t, rpcConfig.ListenAddress, 20)
+	cli, err := jsonrpcclient.New(rpcConfig.ListenAddress)
+	require.NoError(t, err)
+
+	res := new(inspectrpc.ResultValidatorSetHeights)
+	_, err = cli.Call(context.Background(), "validator_set_heights", map[string]interface{}{}, res)
+	require.NoError(t, err)
+	require.Equal(t, ranges, res.Ranges)
+
+	cancel()
+	wg.Wait()
+
+	blockStoreMock.AssertExpectations(t)
+	stateStoreMock.AssertExpectations(t)
 }
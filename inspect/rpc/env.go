@@ -0,0 +1,628 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/internal/statesync"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+	tmstrings "github.com/tendermint/tendermint/libs/strings"
+	"github.com/tendermint/tendermint/proxy"
+	"github.com/tendermint/tendermint/rpc/core"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// sinkPingTimeout is the maximum amount of time spent waiting for a single
+	// event sink to respond to a ping.
+	sinkPingTimeout = 5 * time.Second
+
+	// defaultBlockTimesCount and maxBlockTimesCount are the default and maximum
+	// number of blocks whose times are returned by the block_times route.
+	defaultBlockTimesCount = 100
+	maxBlockTimesCount     = 10000
+
+	// maxTxCountsRange is the maximum number of heights spanned by the range
+	// of the tx_counts route, whose results are paginated by defaultTxCountsPerPage
+	// heights, or up to maxTxCountsPerPage if requested.
+	maxTxCountsRange       = 100000
+	defaultTxCountsPerPage = 100
+	maxTxCountsPerPage     = 1000
+
+	// maxTxCountRange is the maximum number of heights spanned by the range of
+	// the tx_count route.
+	maxTxCountRange = 100000
+
+	// maxVerifyChainRange is the maximum number of heights spanned by the range
+	// of the verify_chain route.
+	maxVerifyChainRange = 100000
+)
+
+// errSearchUnavailable is returned by the tx_search and block_search routes
+// when none of the event sinks can be searched.
+var errSearchUnavailable = errors.New("search not available: no indexer configured")
+
+// environment extends the core RPC environment with the routes that are only
+// served by the Inspector.
+type environment struct {
+	*core.Environment
+
+	// snapshotConn is the connection to the app listing its snapshots, or nil
+	// if none is configured.
+	snapshotConn proxy.AppConnSnapshot
+
+	// sinkQueries holds a token for each search query running against the
+	// event sinks, bounding their number. It is nil if there is no limit.
+	sinkQueries chan struct{}
+
+	// searchable is true if one of the event sinks, i.e. a kv sink, supports
+	// searching transactions and blocks.
+	searchable bool
+}
+
+// pinger is implemented by event sinks whose backing data store can be probed
+// for availability, such as the PostgreSQL sink.
+type pinger interface {
+	Ping(context.Context) error
+}
+
+// SinksHealth pings each of the configured event sinks and reports whether
+// their backing data stores are reachable. Sinks that cannot be probed, such as
+// the embedded kv sink, are always reported as available.
+func (env *environment) SinksHealth(ctx *rpctypes.Context) (*ResultSinksHealth, error) {
+	sinks := make([]SinkHealth, 0, len(env.EventSinks))
+	for _, sink := range env.EventSinks {
+		health := SinkHealth{Type: sink.Type(), Available: true}
+		if p, ok := sink.(pinger); ok {
+			pctx, cancel := context.WithTimeout(ctx.Context(), sinkPingTimeout)
+			if err := p.Ping(pctx); err != nil {
+				health.Available = false
+				health.Error = err.Error()
+			}
+			cancel()
+		}
+		sinks = append(sinks, health)
+	}
+	return &ResultSinksHealth{Sinks: sinks}, nil
+}
+
+// BlocksStream streams the blocks in the inclusive range [minHeight, maxHeight]
+// over the websocket connection of the caller, in ascending order. Each block
+// is sent as a separate ResultBlock response carrying the ID of the request.
+// Blocks are loaded one at a time and only once the connection has accepted
+// the previous one, so a slow client throttles the export rather than
+// causing blocks to be buffered. A minHeight or maxHeight of 0 defaults to the
+// lowest or highest block available in the block store respectively.
+func (env *environment) BlocksStream(ctx *rpctypes.Context, minHeight, maxHeight int64) (*ResultBlocksStream, error) {
+	minHeight, maxHeight, err := env.heightRange(minHeight, maxHeight, env.Config.MaxBlocksStreamRange)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := ctx.RemoteAddr()
+	env.Logger.Info("Streaming blocks", "remote", addr, "minHeight", minHeight, "maxHeight", maxHeight)
+
+	// Capture the current ID, since it can change in the future.
+	streamID := ctx.JSONReq.ID
+	go func() {
+		// stop streaming once the connection is closed
+		writeCtx := ctx.WSConn.Context()
+		for h := minHeight; h <= maxHeight; h++ {
+			block, blockMeta := env.BlockStore.LoadBlock(h), env.BlockStore.LoadBlockMeta(h)
+			if block == nil || blockMeta == nil {
+				err := fmt.Errorf("block at height %d is no longer available", h)
+				if ok := ctx.WSConn.TryWriteRPCResponse(rpctypes.RPCServerError(streamID, err)); !ok {
+					env.Logger.Info("Can't write response (slow client)", "to", addr, "err", err)
+				}
+				return
+			}
+
+			resp := rpctypes.NewRPCSuccessResponse(streamID, &coretypes.ResultBlock{
+				BlockID: blockMeta.BlockID,
+				Block:   block,
+			})
+			if err := ctx.WSConn.WriteRPCResponse(writeCtx, resp); err != nil {
+				env.Logger.Info("Stopped streaming blocks", "to", addr, "height", h, "err", err)
+				return
+			}
+		}
+	}()
+
+	return &ResultBlocksStream{MinHeight: minHeight, MaxHeight: maxHeight}, nil
+}
+
+// TxSearch rejects queries if no event sink can be searched, or if they have
+// more conditions than allowed by the max-query-conditions option, before
+// searching the transactions as the core RPC environment does, once fewer than
+// max-concurrent-sink-queries searches are running.
+func (env *environment) TxSearch(
+	ctx *rpctypes.Context,
+	query string,
+	prove bool,
+	pagePtr, perPagePtr *int,
+	orderBy string,
+) (*coretypes.ResultTxSearch, error) {
+	if !env.searchable {
+		return nil, errSearchUnavailable
+	}
+	if err := env.checkQueryComplexity(query); err != nil {
+		return nil, err
+	}
+	release, err := env.acquireSinkQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return env.Environment.TxSearch(ctx, query, prove, pagePtr, perPagePtr, orderBy)
+}
+
+// BlockSearch rejects queries if no event sink can be searched, or if they have
+// more conditions than allowed by the max-query-conditions option, before
+// searching the blocks as the core RPC environment does, once fewer than
+// max-concurrent-sink-queries searches are running.
+func (env *environment) BlockSearch(
+	ctx *rpctypes.Context,
+	query string,
+	pagePtr, perPagePtr *int,
+	orderBy string,
+) (*coretypes.ResultBlockSearch, error) {
+	if !env.searchable {
+		return nil, errSearchUnavailable
+	}
+	if err := env.checkQueryComplexity(query); err != nil {
+		return nil, err
+	}
+	release, err := env.acquireSinkQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return env.Environment.BlockSearch(ctx, query, pagePtr, perPagePtr, orderBy)
+}
+
+// acquireSinkQuery waits until fewer than max-concurrent-sink-queries searches
+// are running against the event sinks, returning a function to call once the
+// search completes. It gives up if the request is canceled while waiting.
+func (env *environment) acquireSinkQuery(ctx *rpctypes.Context) (func(), error) {
+	if env.sinkQueries == nil {
+		return func() {}, nil
+	}
+	select {
+	case env.sinkQueries <- struct{}{}:
+		return func() { <-env.sinkQueries }, nil
+	case <-ctx.Context().Done():
+		return nil, ctx.Context().Err()
+	}
+}
+
+// checkQueryComplexity returns an error if the query can't be parsed or has
+// more conditions than allowed. Each bound of a range counts as a condition.
+func (env *environment) checkQueryComplexity(query string) error {
+	limit := env.Config.MaxQueryConditions
+	if limit <= 0 {
+		return nil
+	}
+	q, err := tmquery.New(query)
+	if err != nil {
+		return err
+	}
+	conditions, err := q.Conditions()
+	if err != nil {
+		return err
+	}
+	if len(conditions) > limit {
+		return fmt.Errorf("query has %d conditions, exceeding the maximum of %d", len(conditions), limit)
+	}
+	return nil
+}
+
+// BlockTimes returns the timestamps of the last count committed blocks in the
+// block store, in ascending order of height. A count of 0 defaults to
+// defaultBlockTimesCount, and counts are capped at maxBlockTimesCount.
+func (env *environment) BlockTimes(ctx *rpctypes.Context, count int) (*ResultBlockTimes, error) {
+	if count < 0 {
+		return nil, errors.New("count must be non negative")
+	}
+	if count == 0 {
+		count = defaultBlockTimesCount
+	}
+	if count > maxBlockTimesCount {
+		count = maxBlockTimesCount
+	}
+
+	base, height := env.BlockStore.Base(), env.BlockStore.Height()
+	if height == 0 {
+		return nil, errors.New("no blocks available")
+	}
+	minHeight := height - int64(count) + 1
+	if minHeight < base {
+		minHeight = base
+	}
+
+	times := make([]BlockTime, 0, height-minHeight+1)
+	for h := minHeight; h <= height; h++ {
+		blockMeta := env.BlockStore.LoadBlockMeta(h)
+		if blockMeta == nil {
+			return nil, fmt.Errorf("block meta at height %d is not available", h)
+		}
+		times = append(times, BlockTime{Height: h, Time: blockMeta.Header.Time})
+	}
+	return &ResultBlockTimes{BlockTimes: times}, nil
+}
+
+// TxCounts returns the time and number of transactions of each block in the
+// inclusive range [minHeight, maxHeight], in ascending order of height, as
+// recorded in the block metas. A minHeight or maxHeight of 0 defaults to the
+// lowest or highest block available in the block store respectively. The
+// range may span at most maxTxCountsRange heights and is paginated, page 1
+// starting at minHeight.
+func (env *environment) TxCounts(
+	ctx *rpctypes.Context,
+	minHeight, maxHeight int64,
+	pagePtr, perPagePtr *int,
+) (*ResultTxCounts, error) {
+	minHeight, maxHeight, err := env.heightRange(minHeight, maxHeight, maxTxCountsRange)
+	if err != nil {
+		return nil, err
+	}
+	total := maxHeight - minHeight + 1
+
+	perPage := defaultTxCountsPerPage
+	if perPagePtr != nil && *perPagePtr > 0 {
+		perPage = *perPagePtr
+	}
+	if perPage > maxTxCountsPerPage {
+		perPage = maxTxCountsPerPage
+	}
+	page := 1
+	if pagePtr != nil {
+		pages := (int(total)-1)/perPage + 1
+		if *pagePtr <= 0 || *pagePtr > pages {
+			return nil, fmt.Errorf("page should be within [1, %d] range, given %d", pages, *pagePtr)
+		}
+		page = *pagePtr
+	}
+
+	from := minHeight + int64((page-1)*perPage)
+	to := from + int64(perPage) - 1
+	if to > maxHeight {
+		to = maxHeight
+	}
+	counts := make([]BlockTxCount, 0, to-from+1)
+	for h := from; h <= to; h++ {
+		blockMeta := env.BlockStore.LoadBlockMeta(h)
+		if blockMeta == nil {
+			return nil, fmt.Errorf("block meta at height %d is not available", h)
+		}
+		counts = append(counts, BlockTxCount{Height: h, Time: blockMeta.Header.Time, NumTxs: blockMeta.NumTxs})
+	}
+	return &ResultTxCounts{TxCounts: counts, Total: total}, nil
+}
+
+// TxCount returns the total number of transactions in the blocks of the
+// inclusive range [minHeight, maxHeight], summed from the block metas without
+// loading the blocks. A minHeight or maxHeight of 0 defaults to the lowest or
+// highest block available in the block store respectively. The range may span
+// at most maxTxCountRange heights.
+func (env *environment) TxCount(ctx *rpctypes.Context, minHeight, maxHeight int64) (*ResultTxCount, error) {
+	minHeight, maxHeight, err := env.heightRange(minHeight, maxHeight, maxTxCountRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var numTxs int64
+	for h := minHeight; h <= maxHeight; h++ {
+		blockMeta := env.BlockStore.LoadBlockMeta(h)
+		if blockMeta == nil {
+			return nil, fmt.Errorf("block meta at height %d is not available", h)
+		}
+		numTxs += int64(blockMeta.NumTxs)
+	}
+	return &ResultTxCount{MinHeight: minHeight, MaxHeight: maxHeight, NumTxs: numTxs}, nil
+}
+
+// VerifyChain walks the block metas in the inclusive range [minHeight,
+// maxHeight] and checks that the LastBlockID of each header matches the hash
+// of the header below it, reporting the first discontinuity found, if any. A
+// missing block meta is also a discontinuity. A minHeight or maxHeight of 0
+// defaults to the lowest or highest block available in the block store
+// respectively. The range may span at most maxVerifyChainRange heights.
+func (env *environment) VerifyChain(ctx *rpctypes.Context, minHeight, maxHeight int64) (*ResultVerifyChain, error) {
+	minHeight, maxHeight, err := env.heightRange(minHeight, maxHeight, maxVerifyChainRange)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResultVerifyChain{MinHeight: minHeight, MaxHeight: maxHeight}
+	var prevHash tmbytes.HexBytes
+	for h := minHeight; h <= maxHeight; h++ {
+		blockMeta := env.BlockStore.LoadBlockMeta(h)
+		if blockMeta == nil {
+			result.Discontinuity = &ChainDiscontinuity{Height: h, Reason: "block meta is not available"}
+			return result, nil
+		}
+		if h > minHeight && !bytes.Equal(blockMeta.Header.LastBlockID.Hash, prevHash) {
+			result.Discontinuity = &ChainDiscontinuity{
+				Height:        h,
+				Reason:        "last block ID doesn't match the hash of the previous header",
+				LastBlockHash: blockMeta.Header.LastBlockID.Hash,
+				PreviousHash:  prevHash,
+			}
+			return result, nil
+		}
+		prevHash = blockMeta.Header.Hash()
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// AppHash returns the app hash recorded in the header of the block at the given
+// height, i.e. the app hash resulting from executing the block at the previous
+// height. It is read from the block meta in the block store, without loading
+// the block. A height of 0 defaults to the highest block available in the
+// block store.
+func (env *environment) AppHash(ctx *rpctypes.Context, height int64) (*ResultAppHash, error) {
+	if height < 0 {
+		return nil, errors.New("height must be non negative")
+	}
+	if height == 0 {
+		height = env.BlockStore.Height()
+	}
+
+	blockMeta := env.BlockStore.LoadBlockMeta(height)
+	if blockMeta == nil {
+		return nil, fmt.Errorf("block meta at height %d is not available", height)
+	}
+	return &ResultAppHash{Height: height, AppHash: blockMeta.Header.AppHash}, nil
+}
+
+// LightBlock returns the light block at the given height, built exactly as it
+// is served by the state sync reactor to backfilling peers. A height of 0
+// defaults to the highest block available in the block store.
+func (env *environment) LightBlock(ctx *rpctypes.Context, height int64) (*ResultLightBlock, error) {
+	if height < 0 {
+		return nil, errors.New("height must be non negative")
+	}
+	if height == 0 {
+		height = env.BlockStore.Height()
+	}
+
+	lb, err := statesync.LoadLightBlock(env.BlockStore, env.StateStore, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load light block at height %d: %w", height, err)
+	}
+	if lb == nil {
+		return nil, fmt.Errorf("light block at height %d is not available", height)
+	}
+	return &ResultLightBlock{LightBlock: lb}, nil
+}
+
+// StateDiff compares the validator sets and consensus params of the states at
+// heightA and heightB, as retained by the state store. Validators are reported
+// as added, removed or changed in voting power going from heightA to heightB.
+// Consensus params are only reported if they differ.
+func (env *environment) StateDiff(ctx *rpctypes.Context, heightA, heightB int64) (*ResultStateDiff, error) {
+	if heightA <= 0 || heightB <= 0 {
+		return nil, errors.New("heights must be greater than 0")
+	}
+
+	valsA, paramsA, err := env.loadState(heightA)
+	if err != nil {
+		return nil, err
+	}
+	valsB, paramsB, err := env.loadState(heightB)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ResultStateDiff{
+		HeightA:           heightA,
+		HeightB:           heightB,
+		AddedValidators:   []*types.Validator{},
+		RemovedValidators: []*types.Validator{},
+		ChangedValidators: []ValidatorChange{},
+	}
+	for _, valA := range valsA.Validators {
+		_, valB := valsB.GetByAddress(valA.Address)
+		switch {
+		case valB == nil:
+			diff.RemovedValidators = append(diff.RemovedValidators, valA)
+		case valA.VotingPower != valB.VotingPower:
+			diff.ChangedValidators = append(diff.ChangedValidators, ValidatorChange{
+				Address:      valA.Address,
+				VotingPowerA: valA.VotingPower,
+				VotingPowerB: valB.VotingPower,
+			})
+		}
+	}
+	for _, valB := range valsB.Validators {
+		if !valsA.HasAddress(valB.Address) {
+			diff.AddedValidators = append(diff.AddedValidators, valB)
+		}
+	}
+
+	var sections []string
+	if paramsA.Block != paramsB.Block {
+		sections = append(sections, "block")
+	}
+	if paramsA.Evidence != paramsB.Evidence {
+		sections = append(sections, "evidence")
+	}
+	if !tmstrings.StringSliceEqual(paramsA.Validator.PubKeyTypes, paramsB.Validator.PubKeyTypes) {
+		sections = append(sections, "validator")
+	}
+	if paramsA.Version != paramsB.Version {
+		sections = append(sections, "version")
+	}
+	if len(sections) > 0 {
+		diff.ConsensusParams = &ConsensusParamsChange{
+			Sections: sections,
+			ParamsA:  paramsA,
+			ParamsB:  paramsB,
+		}
+	}
+
+	return diff, nil
+}
+
+// ValidatorSetHeights returns the ranges of heights for which the state store
+// has a validator set, such as those saved by backfill, so that gaps in the
+// historical validator data retained by the node can be diagnosed.
+func (env *environment) ValidatorSetHeights(ctx *rpctypes.Context) (*ResultValidatorSetHeights, error) {
+	ranges, err := env.StateStore.LoadValidatorSetHeights()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validator set heights: %w", err)
+	}
+	return &ResultValidatorSetHeights{Ranges: ranges}, nil
+}
+
+// Commit returns the commit of the block at the given height, as served by the
+// commit route of the node. Following the height-addressed routes of the
+// Inspector, a height of 0 defaults to the highest block available in the
+// block store, as does an omitted height.
+func (env *environment) Commit(ctx *rpctypes.Context, heightPtr *int64) (*coretypes.ResultCommit, error) {
+	if heightPtr != nil && *heightPtr == 0 {
+		heightPtr = nil
+	}
+	return env.Environment.Commit(ctx, heightPtr)
+}
+
+// LatestCommit returns the commit of the highest block available in the block
+// store, sparing clients a separate query for the height. As with the commit
+// route, the seen commit is returned if the canonical one isn't stored yet.
+func (env *environment) LatestCommit(ctx *rpctypes.Context) (*coretypes.ResultCommit, error) {
+	height := env.BlockStore.Height()
+	if height == 0 {
+		return nil, errors.New("no blocks are available")
+	}
+
+	res, err := env.Commit(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, fmt.Errorf("commit at height %d is not available", height)
+	}
+	return res, nil
+}
+
+// SeenCommit returns the seen commit stored in the block store, such as the
+// commit saved by state sync for the height it restored, as opposed to the
+// canonical commit stored alongside the next block. The block store only keeps
+// the latest seen commit, so a height other than its own is an error. A height
+// of 0 returns the seen commit whatever its height.
+func (env *environment) SeenCommit(ctx *rpctypes.Context, height int64) (*ResultSeenCommit, error) {
+	if height < 0 {
+		return nil, errors.New("height must be non negative")
+	}
+
+	commit := env.BlockStore.LoadSeenCommit()
+	if commit == nil {
+		return nil, errors.New("no seen commit is stored")
+	}
+	if height != 0 && commit.Height != height {
+		return nil, fmt.Errorf("the stored seen commit is for height %d, not %d", commit.Height, height)
+	}
+	return &ResultSeenCommit{Height: commit.Height, Commit: commit}, nil
+}
+
+// EvidenceParams returns the evidence params in effect at the given height, as
+// retained by the state store. Backfill uses the params of the state it
+// restores to decide how far back to fetch light blocks: it stops
+// MaxAgeNumBlocks below that height once the blocks are older than
+// MaxAgeDuration. A height of 0 defaults to the highest block available in the
+// block store.
+func (env *environment) EvidenceParams(ctx *rpctypes.Context, height int64) (*ResultEvidenceParams, error) {
+	if height < 0 {
+		return nil, errors.New("height must be non negative")
+	}
+	if height == 0 {
+		height = env.BlockStore.Height()
+	}
+
+	params, err := env.StateStore.LoadConsensusParams(height)
+	if err != nil {
+		return nil, fmt.Errorf("consensus params at height %d are not available: %w", height, err)
+	}
+	return &ResultEvidenceParams{Height: height, EvidenceParams: params.Evidence}, nil
+}
+
+// ListSnapshots returns the metadata of the snapshots the app reports having
+// available, so that snapshot production can be verified on an inspected node.
+// It is only available if the Inspector is given a snapshot connection to the
+// app.
+func (env *environment) ListSnapshots(ctx *rpctypes.Context) (*ResultListSnapshots, error) {
+	if env.snapshotConn == nil {
+		return nil, errors.New("list_snapshots is not available: no app snapshot connection is configured")
+	}
+
+	resp, err := env.snapshotConn.ListSnapshotsSync(ctx.Context(), abci.RequestListSnapshots{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list app snapshots: %w", err)
+	}
+	snapshots := make([]AppSnapshot, 0, len(resp.Snapshots))
+	for _, s := range resp.Snapshots {
+		if s == nil {
+			continue
+		}
+		snapshots = append(snapshots, AppSnapshot{
+			Height:     s.Height,
+			Format:     s.Format,
+			Chunks:     s.Chunks,
+			Hash:       s.Hash,
+			Metadata:   s.Metadata,
+			BaseHeight: s.BaseHeight,
+		})
+	}
+	return &ResultListSnapshots{Snapshots: snapshots}, nil
+}
+
+// heightRange resolves the inclusive range [minHeight, maxHeight] of the block
+// store to operate on. A minHeight or maxHeight of 0 defaults to the lowest or
+// highest block available respectively, and heights outside of the available
+// blocks are clamped to them. It returns an error if the range is empty, or if
+// it spans more than limit heights, unless limit is 0.
+func (env *environment) heightRange(minHeight, maxHeight, limit int64) (int64, int64, error) {
+	base, height := env.BlockStore.Base(), env.BlockStore.Height()
+	if minHeight < 0 || maxHeight < 0 {
+		return 0, 0, errors.New("heights must be non negative")
+	}
+	if height == 0 {
+		return 0, 0, errors.New("no blocks available")
+	}
+	if minHeight == 0 || minHeight < base {
+		minHeight = base
+	}
+	if maxHeight == 0 || maxHeight > height {
+		maxHeight = height
+	}
+	if minHeight > maxHeight {
+		return 0, 0, fmt.Errorf("min height %d can't be greater than max height %d", minHeight, maxHeight)
+	}
+	if total := maxHeight - minHeight + 1; limit > 0 && total > limit {
+		return 0, 0, fmt.Errorf("requested range of %d blocks exceeds the maximum of %d", total, limit)
+	}
+	return minHeight, maxHeight, nil
+}
+
+// loadState loads the validator set and consensus params of the state at the
+// given height from the state store.
+func (env *environment) loadState(height int64) (*types.ValidatorSet, types.ConsensusParams, error) {
+	vals, err := env.StateStore.LoadValidators(height)
+	if err != nil {
+		return nil, types.ConsensusParams{}, fmt.Errorf("validators at height %d are not available: %w", height, err)
+	}
+	params, err := env.StateStore.LoadConsensusParams(height)
+	if err != nil {
+		return nil, types.ConsensusParams{}, fmt.Errorf("consensus params at height %d are not available: %w",
+			height, err)
+	}
+	return vals, params, nil
+}
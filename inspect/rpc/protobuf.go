@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/rpc/core"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// ContentTypeProtobuf is the media type with which clients request, and are
+// served, protobuf-encoded results.
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// protobufResults maps the routes whose results can be served as protobuf to
+// the conversion of their result:
+//   - block is served as a tendermint.types.Block
+//   - commit is served as a tendermint.types.SignedHeader
+//   - validators is served as a tendermint.types.ValidatorSet holding the
+//     validators of the requested page only
+//
+// A conversion returns a nil message if the result holds nothing to encode,
+// such as the block of a height whose block meta is missing.
+var protobufResults = map[string]func(interface{}) (proto.Message, error){
+	"block": func(result interface{}) (proto.Message, error) {
+		res := result.(*coretypes.ResultBlock)
+		if res == nil || res.Block == nil {
+			return nil, nil
+		}
+		return res.Block.ToProto()
+	},
+	"commit": func(result interface{}) (proto.Message, error) {
+		res := result.(*coretypes.ResultCommit)
+		if res == nil {
+			return nil, nil
+		}
+		return res.SignedHeader.ToProto(), nil
+	},
+	"validators": func(result interface{}) (proto.Message, error) {
+		res := result.(*coretypes.ResultValidators)
+		vals := &tmproto.ValidatorSet{Validators: make([]*tmproto.Validator, 0, len(res.Validators))}
+		for _, val := range res.Validators {
+			pv, err := val.ToProto()
+			if err != nil {
+				return nil, err
+			}
+			vals.Validators = append(vals.Validators, pv)
+		}
+		return vals, nil
+	},
+}
+
+// protobufHandler returns an http.Handler that serves the URI requests of the
+// routes listed in protobufResults as protobuf when the client accepts it.
+// All other requests, as well as the requests that fail or have nothing to
+// encode, are served as JSON by the local handler.
+func protobufHandler(routes core.RoutesMap, local http.Handler, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.Trim(r.URL.Path, "/")
+		toProto, ok := protobufResults[method]
+		rpcFunc, found := routes[method]
+		if !ok || !found || r.Method != http.MethodGet || !acceptsProtobuf(r) {
+			local.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := rpcFunc.Call(r)
+		if err != nil {
+			local.ServeHTTP(w, r)
+			return
+		}
+		msg, err := toProto(result)
+		if err != nil {
+			logger.Error("failed to convert result to protobuf", "method", method, "err", err)
+			http.Error(w, fmt.Sprintf("failed to convert result to protobuf: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if msg == nil {
+			local.ServeHTTP(w, r)
+			return
+		}
+		bz, err := proto.Marshal(msg)
+		if err != nil {
+			logger.Error("failed to encode protobuf result", "method", method, "err", err)
+			http.Error(w, fmt.Sprintf("failed to encode protobuf result: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentTypeProtobuf)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(bz); err != nil {
+			logger.Error("failed to write response", "method", method, "err", err)
+		}
+	})
+}
+
+// acceptsProtobuf returns true if the Accept header of r lists the protobuf
+// media type.
+func acceptsProtobuf(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(mediaRange)
+			if err == nil && mediaType == ContentTypeProtobuf {
+				return true
+			}
+		}
+	}
+	return false
+}
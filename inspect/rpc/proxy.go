@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/rpc/core"
+)
+
+// ProxyableMethods is the set of JSON-RPC methods that the Inspector forwards
+// to an upstream node when they are not served locally. It only contains
+// methods that do not modify the upstream node, so that the Inspector can never
+// be used to accidentally broadcast transactions or evidence or to dial peers.
+var ProxyableMethods = map[string]bool{
+	"health":               true,
+	"status":               true,
+	"net_info":             true,
+	"genesis":              true,
+	"genesis_chunked":      true,
+	"dump_consensus_state": true,
+	"consensus_state":      true,
+	"unconfirmed_txs":      true,
+	"num_unconfirmed_txs":  true,
+	"abci_info":            true,
+	"abci_query":           true,
+}
+
+// proxyHandler returns an http.Handler that forwards the proxyable JSON-RPC
+// methods not present in routes to the upstream node and serves all other
+// requests using the local handler.
+func proxyHandler(upstream *url.URL, routes core.RoutesMap, local http.Handler, logger log.Logger) http.Handler {
+	rp := httputil.NewSingleHostReverseProxy(upstream)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := requestMethods(r)
+		if len(methods) == 0 {
+			local.ServeHTTP(w, r)
+			return
+		}
+		for _, method := range methods {
+			if _, ok := routes[method]; ok || !ProxyableMethods[method] {
+				local.ServeHTTP(w, r)
+				return
+			}
+		}
+		logger.Debug("proxying request to upstream", "methods", methods, "upstream", upstream.Host)
+		rp.ServeHTTP(w, r)
+	})
+}
+
+// requestMethods returns the JSON-RPC methods called by an HTTP request, either
+// through the URI of a GET request or the body of a single or batched POST
+// request. The request body is left intact to be read again.
+func requestMethods(r *http.Request) []string {
+	switch r.Method {
+	case http.MethodGet:
+		method := strings.Trim(r.URL.Path, "/")
+		if method == "" || method == "websocket" {
+			return nil
+		}
+		return []string{method}
+
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil
+		}
+
+		type request struct {
+			Method string `json:"method"`
+		}
+		var requests []request
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(body, &requests); err != nil {
+				return nil
+			}
+		} else {
+			var req request
+			if err := json.Unmarshal(body, &req); err != nil {
+				return nil
+			}
+			requests = append(requests, req)
+		}
+
+		methods := make([]string, 0, len(requests))
+		for _, req := range requests {
+			methods = append(methods, req.Method)
+		}
+		return methods
+
+	default:
+		return nil
+	}
+}
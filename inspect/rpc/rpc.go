@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/rs/cors"
@@ -11,6 +12,7 @@ import (
 	"github.com/tendermint/tendermint/internal/consensus"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/pubsub"
+	"github.com/tendermint/tendermint/proxy"
 	"github.com/tendermint/tendermint/rpc/core"
 	"github.com/tendermint/tendermint/rpc/jsonrpc/server"
 	"github.com/tendermint/tendermint/state"
@@ -26,36 +28,66 @@ type Server struct {
 	Config  *config.RPCConfig
 }
 
-// Routes returns the set of routes used by the Inspector server.
+// Routes returns the set of routes used by the Inspector server. The
+// list_snapshots route is only served if snapshotConn is not nil.
+//
+// Routes addressing a single height default to the highest block available in
+// the block store if the height is omitted. For the commit route and the
+// routes specific to the Inspector, such as app_hash, light_block and
+// evidence_params, a height of 0 means the same.
 //
 //nolint: lll
-func Routes(cfg config.RPCConfig, s state.Store, bs state.BlockStore, es []indexer.EventSink, logger log.Logger) core.RoutesMap {
-	env := &core.Environment{
-		Config:           cfg,
-		EventSinks:       es,
-		StateStore:       s,
-		BlockStore:       bs,
-		ConsensusReactor: waitSyncCheckerImpl{},
-		Logger:           logger,
+func Routes(cfg config.RPCConfig, s state.Store, bs state.BlockStore, es []indexer.EventSink, snapshotConn proxy.AppConnSnapshot, logger log.Logger) core.RoutesMap {
+	env := &environment{
+		Environment: &core.Environment{
+			Config:           cfg,
+			EventSinks:       es,
+			StateStore:       s,
+			BlockStore:       bs,
+			ConsensusReactor: waitSyncCheckerImpl{},
+			Logger:           logger,
+		},
+		snapshotConn: snapshotConn,
+		searchable:   indexer.KVSinkEnabled(es),
+	}
+	if cfg.MaxConcurrentSinkQueries > 0 {
+		env.sinkQueries = make(chan struct{}, cfg.MaxConcurrentSinkQueries)
 	}
 	return core.RoutesMap{
-		"blockchain":       server.NewRPCFunc(env.BlockchainInfo, "minHeight,maxHeight", true),
-		"consensus_params": server.NewRPCFunc(env.ConsensusParams, "height", true),
-		"block":            server.NewRPCFunc(env.Block, "height", true),
-		"block_by_hash":    server.NewRPCFunc(env.BlockByHash, "hash", true),
-		"block_results":    server.NewRPCFunc(env.BlockResults, "height", true),
-		"commit":           server.NewRPCFunc(env.Commit, "height", true),
-		"validators":       server.NewRPCFunc(env.Validators, "height,page,per_page", true),
-		"tx":               server.NewRPCFunc(env.Tx, "hash,prove", true),
-		"tx_search":        server.NewRPCFunc(env.TxSearch, "query,prove,page,per_page,order_by", false),
-		"block_search":     server.NewRPCFunc(env.BlockSearch, "query,page,per_page,order_by", false),
+		"blockchain":            server.NewRPCFunc(env.BlockchainInfo, "minHeight,maxHeight", true),
+		"consensus_params":      server.NewRPCFunc(env.ConsensusParams, "height", true),
+		"block":                 server.NewRPCFunc(env.Block, "height", true),
+		"block_by_hash":         server.NewRPCFunc(env.BlockByHash, "hash", true),
+		"block_results":         server.NewRPCFunc(env.BlockResults, "height", true),
+		"commit":                server.NewRPCFunc(env.Commit, "height", true),
+		"validators":            server.NewRPCFunc(env.Validators, "height,page,per_page", true),
+		"tx":                    server.NewRPCFunc(env.Tx, "hash,prove", true),
+		"tx_search":             server.NewRPCFunc(env.TxSearch, "query,prove,page,per_page,order_by", false),
+		"block_search":          server.NewRPCFunc(env.BlockSearch, "query,page,per_page,order_by", false),
+		"sinks_health":          server.NewRPCFunc(env.SinksHealth, "", false),
+		"blocks_stream":         server.NewWSRPCFunc(env.BlocksStream, "minHeight,maxHeight"),
+		"block_times":           server.NewRPCFunc(env.BlockTimes, "count", true),
+		"tx_counts":             server.NewRPCFunc(env.TxCounts, "minHeight,maxHeight,page,per_page", true),
+		"tx_count":              server.NewRPCFunc(env.TxCount, "minHeight,maxHeight", true),
+		"light_block":           server.NewRPCFunc(env.LightBlock, "height", true),
+		"app_hash":              server.NewRPCFunc(env.AppHash, "height", true),
+		"state_diff":            server.NewRPCFunc(env.StateDiff, "heightA,heightB", true),
+		"validator_set_heights": server.NewRPCFunc(env.ValidatorSetHeights, "", false),
+		"latest_commit":         server.NewRPCFunc(env.LatestCommit, "", false),
+		"evidence_params":       server.NewRPCFunc(env.EvidenceParams, "height", true),
+		"list_snapshots":        server.NewRPCFunc(env.ListSnapshots, "", false),
+		"seen_commit":           server.NewRPCFunc(env.SeenCommit, "height", true),
+		"verify_chain":          server.NewRPCFunc(env.VerifyChain, "minHeight,maxHeight", true),
 	}
 }
 
 // Handler returns the http.Handler configured for use with an Inspector server. Handler
 // registers the routes on the http.Handler and also registers the websocket handler
-// and the CORS handler if specified by the configuration options.
-func Handler(rpcConfig *config.RPCConfig, routes core.RoutesMap, logger log.Logger) http.Handler {
+// and the CORS handler if specified by the configuration options. If upstream is not
+// nil, the proxyable methods that are not present in routes are forwarded to it.
+// URI requests to the block, commit and validators routes are served as protobuf
+// rather than JSON if their Accept header lists ContentTypeProtobuf.
+func Handler(rpcConfig *config.RPCConfig, routes core.RoutesMap, upstream *url.URL, logger log.Logger) http.Handler {
 	mux := http.NewServeMux()
 	wmLogger := logger.With("protocol", "websocket")
 
@@ -74,9 +106,12 @@ func Handler(rpcConfig *config.RPCConfig, routes core.RoutesMap, logger log.Logg
 	mux.HandleFunc("/websocket", wm.WebsocketHandler)
 
 	server.RegisterRPCFuncs(mux, routes, logger)
-	var rootHandler http.Handler = mux
+	var rootHandler http.Handler = protobufHandler(routes, mux, logger)
+	if upstream != nil {
+		rootHandler = proxyHandler(upstream, routes, rootHandler, logger.With("module", "proxy"))
+	}
 	if rpcConfig.IsCorsEnabled() {
-		rootHandler = addCORSHandler(rpcConfig, mux)
+		rootHandler = addCORSHandler(rpcConfig, rootHandler)
 	}
 	return rootHandler
 }
@@ -132,6 +167,7 @@ func (srv *Server) ListenAndServeTLS(ctx context.Context, certFile, keyFile stri
 func serverRPCConfig(r *config.RPCConfig) *server.Config {
 	cfg := server.DefaultConfig()
 	cfg.MaxBodyBytes = r.MaxBodyBytes
+	cfg.MaxResponseBodyBytes = r.MaxResponseBodyBytes
 	cfg.MaxHeaderBytes = r.MaxHeaderBytes
 	// If necessary adjust global WriteTimeout to ensure it's greater than
 	// TimeoutBroadcastTxCommit.
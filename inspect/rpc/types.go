@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/state/indexer"
+	"github.com/tendermint/tendermint/types"
+)
+
+// SinkHealth reports the availability of a single event sink.
+type SinkHealth struct {
+	Type      indexer.EventSinkType `json:"type"`
+	Available bool                  `json:"available"`
+	Error     string                `json:"error,omitempty"`
+}
+
+// ResultSinksHealth is the result of the sinks_health route.
+type ResultSinksHealth struct {
+	Sinks []SinkHealth `json:"sinks"`
+}
+
+// ResultBlocksStream is the result of the blocks_stream route. It reports the
+// inclusive range of heights whose blocks are streamed after it.
+type ResultBlocksStream struct {
+	MinHeight int64 `json:"min_height"`
+	MaxHeight int64 `json:"max_height"`
+}
+
+// BlockTime is the timestamp of a single committed block.
+type BlockTime struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+}
+
+// ResultBlockTimes is the result of the block_times route, in ascending order
+// of height.
+type ResultBlockTimes struct {
+	BlockTimes []BlockTime `json:"block_times"`
+}
+
+// BlockTxCount is the time and number of transactions of a single committed
+// block.
+type BlockTxCount struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+	NumTxs int       `json:"num_txs"`
+}
+
+// ResultTxCounts is the result of the tx_counts route, in ascending order of
+// height. Total is the number of heights in the requested range, across all
+// pages.
+type ResultTxCounts struct {
+	TxCounts []BlockTxCount `json:"tx_counts"`
+	Total    int64          `json:"total"`
+}
+
+// ResultTxCount is the result of the tx_count route. NumTxs is the total
+// number of transactions in the blocks of the range [MinHeight, MaxHeight].
+type ResultTxCount struct {
+	MinHeight int64 `json:"min_height"`
+	MaxHeight int64 `json:"max_height"`
+	NumTxs    int64 `json:"num_txs"`
+}
+
+// ResultVerifyChain is the result of the verify_chain route over the range
+// [MinHeight, MaxHeight]. Discontinuity is the first one found, if the chain
+// isn't Valid.
+type ResultVerifyChain struct {
+	MinHeight     int64               `json:"min_height"`
+	MaxHeight     int64               `json:"max_height"`
+	Valid         bool                `json:"valid"`
+	Discontinuity *ChainDiscontinuity `json:"discontinuity,omitempty"`
+}
+
+// ChainDiscontinuity is a height at which the header chain is broken, either
+// because its block meta is missing or because the LastBlockID of its header
+// doesn't match the hash of the previous header.
+type ChainDiscontinuity struct {
+	Height        int64            `json:"height"`
+	Reason        string           `json:"reason"`
+	LastBlockHash tmbytes.HexBytes `json:"last_block_hash,omitempty"`
+	PreviousHash  tmbytes.HexBytes `json:"previous_hash,omitempty"`
+}
+
+// ResultAppHash is the result of the app_hash route.
+type ResultAppHash struct {
+	Height  int64            `json:"height"`
+	AppHash tmbytes.HexBytes `json:"app_hash"`
+}
+
+// ResultLightBlock is the result of the light_block route.
+type ResultLightBlock struct {
+	LightBlock *types.LightBlock `json:"light_block"`
+}
+
+// ValidatorChange is the change in voting power of a validator present at both
+// heights compared by the state_diff route.
+type ValidatorChange struct {
+	Address      crypto.Address `json:"address"`
+	VotingPowerA int64          `json:"voting_power_a"`
+	VotingPowerB int64          `json:"voting_power_b"`
+}
+
+// ConsensusParamsChange reports the consensus params at both heights compared
+// by the state_diff route, along with the sections that differ.
+type ConsensusParamsChange struct {
+	Sections []string              `json:"sections"`
+	ParamsA  types.ConsensusParams `json:"params_a"`
+	ParamsB  types.ConsensusParams `json:"params_b"`
+}
+
+// ResultStateDiff is the result of the state_diff route. ConsensusParams is
+// nil if the consensus params are the same at both heights.
+type ResultStateDiff struct {
+	HeightA           int64                  `json:"height_a"`
+	HeightB           int64                  `json:"height_b"`
+	AddedValidators   []*types.Validator     `json:"added_validators"`
+	RemovedValidators []*types.Validator     `json:"removed_validators"`
+	ChangedValidators []ValidatorChange      `json:"changed_validators"`
+	ConsensusParams   *ConsensusParamsChange `json:"consensus_params"`
+}
+
+// ResultValidatorSetHeights is the result of the validator_set_heights route.
+// It lists the inclusive ranges of heights for which the state store has a
+// validator set, in ascending order.
+type ResultValidatorSetHeights struct {
+	Ranges []sm.HeightRange `json:"ranges"`
+}
+
+// ResultEvidenceParams is the result of the evidence_params route.
+type ResultEvidenceParams struct {
+	Height         int64                `json:"height"`
+	EvidenceParams types.EvidenceParams `json:"evidence_params"`
+}
+
+// AppSnapshot is the metadata of a snapshot listed by the app. BaseHeight is
+// only set for delta snapshots.
+type AppSnapshot struct {
+	Height     uint64           `json:"height"`
+	Format     uint32           `json:"format"`
+	Chunks     uint32           `json:"chunks"`
+	Hash       tmbytes.HexBytes `json:"hash"`
+	Metadata   tmbytes.HexBytes `json:"metadata"`
+	BaseHeight uint64           `json:"base_height,omitempty"`
+}
+
+// ResultListSnapshots is the result of the list_snapshots route, in the order
+// the snapshots are listed by the app.
+type ResultListSnapshots struct {
+	Snapshots []AppSnapshot `json:"snapshots"`
+}
+
+// ResultSeenCommit is the result of the seen_commit route.
+type ResultSeenCommit struct {
+	Height int64         `json:"height"`
+	Commit *types.Commit `json:"commit"`
+}
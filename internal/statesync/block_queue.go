@@ -22,6 +22,9 @@ type blockQueue struct {
 	fetchHeight  int64
 	verifyHeight int64
 
+	// number of blocks verified so far
+	verified int
+
 	// termination conditions
 	initialHeight int64
 	stopHeight    int64
@@ -34,6 +37,10 @@ type blockQueue struct {
 	retries    int
 	maxRetries int
 
+	// the maximum number of heights handed out for fetching ahead of the
+	// verify height, or 0 for no limit. Fetchers wait once it's reached.
+	maxPending int
+
 	// store inbound blocks and serve them to a verifying thread via a channel
 	pending  map[int64]lightBlockResponse
 	verifyCh chan lightBlockResponse
@@ -48,7 +55,7 @@ type blockQueue struct {
 func newBlockQueue(
 	startHeight, stopHeight, initialHeight int64,
 	stopTime time.Time,
-	maxRetries int,
+	maxRetries, maxPending int,
 ) *blockQueue {
 	return &blockQueue{
 		stopHeight:    stopHeight,
@@ -60,6 +67,7 @@ func newBlockQueue(
 		failed:        &maxIntHeap{},
 		retries:       0,
 		maxRetries:    maxRetries,
+		maxPending:    maxPending,
 		waiters:       make([]chan int64, 0),
 		doneCh:        make(chan struct{}),
 	}
@@ -118,7 +126,7 @@ func (q *blockQueue) nextHeight() <-chan int64 {
 		return ch
 	}
 
-	if q.terminal == nil && q.fetchHeight >= q.initialHeight {
+	if q.canFetch() {
 		// return and decrement the fetch height
 		ch <- q.fetchHeight
 		q.fetchHeight--
@@ -126,13 +134,24 @@ func (q *blockQueue) nextHeight() <-chan int64 {
 		return ch
 	}
 
-	// at this point there is no height that we know we need so we create a
-	// waiter to hold out for either an outgoing request to fail or a block to
-	// fail verification
+	// at this point there is no height that we know we need, or too many
+	// blocks are pending verification, so we create a waiter to hold out for
+	// either an outgoing request to fail, a block to fail verification or a
+	// block to be verified
 	q.waiters = append(q.waiters, ch)
 	return ch
 }
 
+// canFetch returns true if there are heights left to fetch and the number of
+// heights fetched ahead of the verify height is below maxPending.
+// CONTRACT: must hold the lock.
+func (q *blockQueue) canFetch() bool {
+	if q.terminal != nil || q.fetchHeight < q.initialHeight {
+		return false
+	}
+	return q.maxPending <= 0 || q.verifyHeight-q.fetchHeight < int64(q.maxPending)
+}
+
 // Finished returns true when the block queue has has all light blocks retrieved,
 // verified and stored. There is no more work left to be done
 func (q *blockQueue) done() <-chan struct{} {
@@ -205,8 +224,34 @@ func (q *blockQueue) success(height int64) {
 	defer q.mtx.Unlock()
 	if q.terminal != nil && q.verifyHeight == q.terminal.Height {
 		q._closeChannels()
+		q.verifyHeight--
+		q.verified++
+		return
 	}
 	q.verifyHeight--
+	q.verified++
+
+	select {
+	case <-q.doneCh:
+		return
+	default:
+	}
+
+	// the verified block makes room for waiting fetchers
+	for len(q.waiters) > 0 && q.canFetch() {
+		q.waiters[0] <- q.fetchHeight
+		close(q.waiters[0])
+		q.waiters = q.waiters[1:]
+		q.fetchHeight--
+	}
+}
+
+// Verified returns the number of light blocks that have been successfully
+// verified and processed so far
+func (q *blockQueue) Verified() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.verified
 }
 
 func (q *blockQueue) error() error {
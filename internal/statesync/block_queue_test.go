@@ -25,7 +25,7 @@ func TestBlockQueueBasic(t *testing.T) {
 	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
 	require.NoError(t, err)
 
-	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1, 0)
 	wg := &sync.WaitGroup{}
 
 	// asynchronously fetch blocks and add it to the queue
@@ -65,6 +65,7 @@ loop:
 
 	wg.Wait()
 	assert.Less(t, trackingHeight, stopHeight)
+	assert.Equal(t, int(startHeight-trackingHeight), queue.Verified())
 }
 
 // Test with spurious failures and retries
@@ -72,7 +73,7 @@ func TestBlockQueueWithFailures(t *testing.T) {
 	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
 	require.NoError(t, err)
 
-	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 200)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 200, 0)
 	wg := &sync.WaitGroup{}
 
 	failureRate := 4
@@ -121,7 +122,7 @@ func TestBlockQueueWithFailures(t *testing.T) {
 func TestBlockQueueBlocks(t *testing.T) {
 	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
 	require.NoError(t, err)
-	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 2)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 2, 0)
 	expectedHeight := startHeight
 	retryHeight := stopHeight + 2
 
@@ -168,7 +169,7 @@ loop:
 func TestBlockQueueAcceptsNoMoreBlocks(t *testing.T) {
 	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
 	require.NoError(t, err)
-	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1, 0)
 	defer queue.close()
 
 loop:
@@ -188,13 +189,43 @@ loop:
 	require.Len(t, queue.pending, int(startHeight-stopHeight)+1)
 }
 
+func TestBlockQueueMaxPending(t *testing.T) {
+	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
+	require.NoError(t, err)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1, 3)
+	defer queue.close()
+
+	// only three heights are handed out ahead of the verify height
+	for i := int64(0); i < 3; i++ {
+		height := <-queue.nextHeight()
+		require.Equal(t, startHeight-i, height)
+		queue.add(mockLBResp(t, peerID, height, endTime))
+	}
+	waiter := queue.nextHeight()
+	select {
+	case <-waiter:
+		require.Fail(t, "queue handed out a height past the pending limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// verifying a block lets the waiting fetcher proceed
+	resp := <-queue.verifyNext()
+	queue.success(resp.block.Height)
+	select {
+	case height := <-waiter:
+		require.Equal(t, startHeight-3, height)
+	case <-time.After(time.Second):
+		require.Fail(t, "queue didn't hand out a height after a block was verified")
+	}
+}
+
 // Test a scenario where more blocks are needed then just the stopheight because
 // we haven't found a block with a small enough time.
 func TestBlockQueueStopTime(t *testing.T) {
 	peerID, err := types.NewNodeID("0011223344556677889900112233445566778899")
 	require.NoError(t, err)
 
-	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1)
+	queue := newBlockQueue(startHeight, stopHeight, 1, stopTime, 1, 0)
 	wg := &sync.WaitGroup{}
 
 	baseTime := stopTime.Add(-50 * time.Second)
@@ -238,7 +269,7 @@ func TestBlockQueueInitialHeight(t *testing.T) {
 	require.NoError(t, err)
 	const initialHeight int64 = 120
 
-	queue := newBlockQueue(startHeight, stopHeight, initialHeight, stopTime, 1)
+	queue := newBlockQueue(startHeight, stopHeight, initialHeight, stopTime, 1, 0)
 	wg := &sync.WaitGroup{}
 
 	// asynchronously fetch blocks and add it to the queue
@@ -1,14 +1,19 @@
 package statesync
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/tendermint/tendermint/config"
 	tmsync "github.com/tendermint/tendermint/internal/libs/sync"
 	"github.com/tendermint/tendermint/types"
 )
@@ -16,13 +21,18 @@ import (
 // errDone is returned by chunkQueue.Next() when all chunks have been returned.
 var errDone = errors.New("chunk queue has completed")
 
+// appliedChunkPrefix prefixes the file names of retained chunks that have been
+// applied to the app.
+const appliedChunkPrefix = "applied-"
+
 // chunk contains data for a chunk.
 type chunk struct {
-	Height uint64
-	Format uint32
-	Index  uint32
-	Chunk  []byte
-	Sender types.NodeID
+	Height     uint64
+	Format     uint32
+	Index      uint32
+	Chunk      []byte
+	Sender     types.NodeID
+	BaseHeight uint64
 }
 
 // chunkQueue manages chunks for a state sync process, ordering them if requested. It acts as an
@@ -37,6 +47,7 @@ type chunkQueue struct {
 	chunkAllocated map[uint32]bool            // chunks that have been allocated via Allocate()
 	chunkReturned  map[uint32]bool            // chunks returned via Next()
 	waiters        map[uint32][]chan<- uint32 // signals WaitFor() waiters about chunk arrival
+	retain         bool                       // whether to keep applied chunk files on Close()
 }
 
 // newChunkQueue creates a new chunk queue for a snapshot, using a temp dir for storage.
@@ -50,6 +61,51 @@ func newChunkQueue(snapshot *snapshot, tempDir string) (*chunkQueue, error) {
 		return nil, errors.New("snapshot has no chunks")
 	}
 
+	return makeChunkQueue(snapshot, dir), nil
+}
+
+// newRetainedChunkQueue creates a new chunk queue for a snapshot which keeps the files of
+// applied chunks on Close(), in a subdirectory of tempDir named after the given prefix and
+// the snapshot. Applied chunks retained by a previous queue for the same snapshot are
+// restored and never allocated for fetching, while any other files left behind are removed.
+// Callers must call Close() when done, or Remove() to also remove the retained chunks.
+func newRetainedChunkQueue(snapshot *snapshot, tempDir, prefix string) (*chunkQueue, error) {
+	if snapshot.Chunks == 0 {
+		return nil, errors.New("snapshot has no chunks")
+	}
+	key := snapshot.Key()
+	dir := filepath.Join(tempDir, fmt.Sprintf("%s-%X", prefix, key[:]))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create temp dir for state sync chunks: %w", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read retained state sync chunks: %w", err)
+	}
+
+	q := makeChunkQueue(snapshot, dir)
+	q.retain = true
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		if strings.HasPrefix(file.Name(), appliedChunkPrefix) {
+			index, err := strconv.ParseUint(strings.TrimPrefix(file.Name(), appliedChunkPrefix), 10, 32)
+			if err == nil && uint32(index) < snapshot.Chunks {
+				q.chunkFiles[uint32(index)] = path
+				q.chunkAllocated[uint32(index)] = true
+				continue
+			}
+		}
+		// chunks that weren't applied may be incomplete, so they're fetched again
+		if err := os.RemoveAll(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale chunk file %v: %w", path, err)
+		}
+	}
+
+	return q, nil
+}
+
+// makeChunkQueue creates an empty chunk queue for a snapshot, storing chunks in dir.
+func makeChunkQueue(snapshot *snapshot, dir string) *chunkQueue {
 	return &chunkQueue{
 		snapshot:       snapshot,
 		dir:            dir,
@@ -58,7 +114,7 @@ func newChunkQueue(snapshot *snapshot, tempDir string) (*chunkQueue, error) {
 		chunkAllocated: make(map[uint32]bool, snapshot.Chunks),
 		chunkReturned:  make(map[uint32]bool, snapshot.Chunks),
 		waiters:        make(map[uint32][]chan<- uint32),
-	}, nil
+	}
 }
 
 // Add adds a chunk to the queue. It ignores chunks that already exist, returning false.
@@ -79,6 +135,9 @@ func (q *chunkQueue) Add(chunk *chunk) (bool, error) {
 	if chunk.Format != q.snapshot.Format {
 		return false, fmt.Errorf("invalid chunk format %v, expected %v", chunk.Format, q.snapshot.Format)
 	}
+	if chunk.BaseHeight != q.snapshot.BaseHeight {
+		return false, fmt.Errorf("invalid chunk base height %v, expected %v", chunk.BaseHeight, q.snapshot.BaseHeight)
+	}
 	if chunk.Index >= q.snapshot.Chunks {
 		return false, fmt.Errorf("received unexpected chunk %v", chunk.Index)
 	}
@@ -130,23 +189,37 @@ func (q *chunkQueue) Allocate() (uint32, error) {
 	return 0, errDone
 }
 
-// Close closes the chunk queue, cleaning up all temporary files.
+// Close closes the chunk queue, cleaning up all temporary files unless the queue retains
+// applied chunks.
 func (q *chunkQueue) Close() error {
+	return q.close(!q.retain)
+}
+
+// Remove closes the chunk queue like Close(), but always cleans up all temporary files,
+// including retained chunks.
+func (q *chunkQueue) Remove() error {
+	return q.close(true)
+}
+
+// close closes the chunk queue, cleaning up all temporary files if remove is true.
+func (q *chunkQueue) close(remove bool) error {
 	q.Lock()
 	defer q.Unlock()
 
-	if q.snapshot == nil {
-		return nil
-	}
-
-	for _, waiters := range q.waiters {
-		for _, waiter := range waiters {
-			close(waiter)
+	if q.snapshot != nil {
+		for _, waiters := range q.waiters {
+			for _, waiter := range waiters {
+				close(waiter)
+			}
 		}
+
+		q.waiters = nil
+		q.snapshot = nil
 	}
 
-	q.waiters = nil
-	q.snapshot = nil
+	if !remove {
+		return nil
+	}
 
 	if err := os.RemoveAll(q.dir); err != nil {
 		return fmt.Errorf("failed to clean up state sync tempdir %v: %w", q.dir, err)
@@ -214,6 +287,31 @@ func (q *chunkQueue) GetSender(index uint32) types.NodeID {
 	return q.chunkSenders[index]
 }
 
+// MarkApplied records that a chunk has been applied to the app, so that a queue retaining
+// applied chunks restores it when recreated for the same snapshot. It does nothing for other
+// queues, or if the chunk is not in the queue.
+func (q *chunkQueue) MarkApplied(index uint32) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if !q.retain || q.snapshot == nil {
+		return nil
+	}
+
+	path := q.chunkFiles[index]
+	appliedPath := filepath.Join(q.dir, appliedChunkPrefix+strconv.FormatUint(uint64(index), 10))
+	if path == "" || path == appliedPath {
+		return nil
+	}
+
+	if err := os.Rename(path, appliedPath); err != nil {
+		return fmt.Errorf("failed to retain chunk %v: %w", index, err)
+	}
+	q.chunkFiles[index] = appliedPath
+
+	return nil
+}
+
 // Has checks whether a chunk exists in the queue.
 func (q *chunkQueue) Has(index uint32) bool {
 	q.Lock()
@@ -235,11 +333,12 @@ func (q *chunkQueue) load(index uint32) (*chunk, error) {
 	}
 
 	return &chunk{
-		Height: q.snapshot.Height,
-		Format: q.snapshot.Format,
-		Index:  index,
-		Chunk:  body,
-		Sender: q.chunkSenders[index],
+		Height:     q.snapshot.Height,
+		Format:     q.snapshot.Format,
+		Index:      index,
+		Chunk:      body,
+		Sender:     q.chunkSenders[index],
+		BaseHeight: q.snapshot.BaseHeight,
 	}, nil
 }
 
@@ -355,3 +454,140 @@ func (q *chunkQueue) WaitFor(index uint32) <-chan uint32 {
 
 	return ch
 }
+
+// chunkReadAhead caches the chunks loaded from the app ahead of the chunk requests of each
+// peer, for the last snapshot the peer requested chunks of. The chunks cached across all peers
+// take at most maxBytes bytes.
+type chunkReadAhead struct {
+	mtx      tmsync.Mutex
+	peers    map[types.NodeID]*readAheadChunks
+	filling  map[types.NodeID]bool
+	bytes    int
+	maxBytes int
+}
+
+// readAheadChunks are the chunks of a snapshot read ahead for a peer, by index.
+type readAheadChunks struct {
+	height     uint64
+	format     uint32
+	baseHeight uint64
+	chunks     map[uint32][]byte
+}
+
+func newChunkReadAhead(maxBytes int) *chunkReadAhead {
+	return &chunkReadAhead{
+		peers:    make(map[types.NodeID]*readAheadChunks),
+		filling:  make(map[types.NodeID]bool),
+		maxBytes: maxBytes,
+	}
+}
+
+// take removes and returns the chunk with the given index of the given snapshot if it has
+// been read ahead for the peer. The chunks of lower indexes are dropped, as the peer has
+// moved past them.
+func (c *chunkReadAhead) take(peer types.NodeID, height uint64, format uint32, baseHeight uint64,
+	index uint32) ([]byte, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	cached := c.peers[peer]
+	if cached == nil || cached.height != height || cached.format != format || cached.baseHeight != baseHeight {
+		return nil, false
+	}
+	for i, chunk := range cached.chunks {
+		if i < index {
+			c.bytes -= len(chunk)
+			delete(cached.chunks, i)
+		}
+	}
+	chunk, ok := cached.chunks[index]
+	c.bytes -= len(chunk)
+	delete(cached.chunks, index)
+	return chunk, ok
+}
+
+// fill reads ahead the window chunks following the one with the given index using load, caching
+// them for the peer in place of the chunks of any other snapshot. Chunks already cached aren't
+// loaded again, and reading stops at the first chunk load fails to return or that doesn't fit
+// in the cache. Only one fill runs at a time for each peer: fill returns right away if another
+// one is running.
+func (c *chunkReadAhead) fill(peer types.NodeID, height uint64, format uint32, baseHeight uint64,
+	index, window uint32, load func(index uint32) ([]byte, error)) {
+	c.mtx.Lock()
+	if c.filling[peer] {
+		c.mtx.Unlock()
+		return
+	}
+	c.filling[peer] = true
+	c.mtx.Unlock()
+	defer func() {
+		c.mtx.Lock()
+		delete(c.filling, peer)
+		c.mtx.Unlock()
+	}()
+
+	for i := index + 1; i <= index+window && i > index; i++ {
+		c.mtx.Lock()
+		cached := c.peers[peer]
+		if cached == nil || cached.height != height || cached.format != format || cached.baseHeight != baseHeight {
+			c.removePeer(peer)
+			cached = &readAheadChunks{
+				height:     height,
+				format:     format,
+				baseHeight: baseHeight,
+				chunks:     make(map[uint32][]byte, window),
+			}
+			c.peers[peer] = cached
+		}
+		_, ok := cached.chunks[i]
+		c.mtx.Unlock()
+		if ok {
+			continue
+		}
+
+		chunk, err := load(i)
+		if err != nil || chunk == nil {
+			return
+		}
+		c.mtx.Lock()
+		// the peer may have moved on to another snapshot or disconnected
+		if c.peers[peer] != cached || c.bytes+len(chunk) > c.maxBytes {
+			c.mtx.Unlock()
+			return
+		}
+		cached.chunks[i] = chunk
+		c.bytes += len(chunk)
+		c.mtx.Unlock()
+	}
+}
+
+// remove drops the chunks read ahead for the peer.
+func (c *chunkReadAhead) remove(peer types.NodeID) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.removePeer(peer)
+}
+
+// removePeer drops the chunks read ahead for the peer. The caller must hold mtx.
+func (c *chunkReadAhead) removePeer(peer types.NodeID) {
+	if cached := c.peers[peer]; cached != nil {
+		for _, chunk := range cached.chunks {
+			c.bytes -= len(chunk)
+		}
+	}
+	delete(c.peers, peer)
+}
+
+// chunkChecksum computes the checksum of a chunk using the given algorithm.
+func chunkChecksum(algorithm string, chunk []byte) ([]byte, error) {
+	switch algorithm {
+	case config.ChunkChecksumSHA256:
+		sum := sha256.Sum256(chunk)
+		return sum[:], nil
+	case config.ChunkChecksumBlake2b:
+		sum := blake2b.Sum256(chunk)
+		return sum[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+}
@@ -3,11 +3,13 @@ package statesync
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -54,6 +56,54 @@ func TestNewChunkQueue_TempDir(t *testing.T) {
 	assert.Len(t, files, 0)
 }
 
+func TestChunkQueue_RetainApplied(t *testing.T) {
+	snapshot := &snapshot{
+		Height:   3,
+		Format:   1,
+		Chunks:   3,
+		Hash:     []byte{7},
+		Metadata: nil,
+	}
+	dir, err := ioutil.TempDir("", "retainedchunkqueue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	queue, err := newRetainedChunkQueue(snapshot, dir, "tm-test")
+	require.NoError(t, err)
+	for i := uint32(0); i < 2; i++ {
+		index, err := queue.Allocate()
+		require.NoError(t, err)
+		_, err = queue.Add(&chunk{Height: 3, Format: 1, Index: index, Chunk: []byte{3, 1, byte(index)}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, queue.MarkApplied(0))
+	require.NoError(t, queue.Close())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasPrefix(files[0].Name(), "tm-test-"))
+
+	// Only the applied chunk is restored, and never allocated again
+	queue, err = newRetainedChunkQueue(snapshot, dir, "tm-test")
+	require.NoError(t, err)
+	assert.True(t, queue.Has(0))
+	assert.False(t, queue.Has(1))
+	for _, expect := range []uint32{1, 2} {
+		index, err := queue.Allocate()
+		require.NoError(t, err)
+		assert.EqualValues(t, expect, index)
+	}
+	c, err := queue.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{3, 1, 0}, c.Chunk)
+
+	// Removing the queue removes the retained chunks
+	require.NoError(t, queue.Remove())
+	files, err = ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
 func TestChunkQueue(t *testing.T) {
 	queue, teardown := setupChunkQueue(t)
 	defer teardown()
@@ -121,11 +171,12 @@ func TestChunkQueue_Add_ChunkErrors(t *testing.T) {
 	testcases := map[string]struct {
 		chunk *chunk
 	}{
-		"nil chunk":     {nil},
-		"nil body":      {&chunk{Height: 3, Format: 1, Index: 0, Chunk: nil}},
-		"wrong height":  {&chunk{Height: 9, Format: 1, Index: 0, Chunk: []byte{3, 1, 0}}},
-		"wrong format":  {&chunk{Height: 3, Format: 9, Index: 0, Chunk: []byte{3, 1, 0}}},
-		"invalid index": {&chunk{Height: 3, Format: 1, Index: 5, Chunk: []byte{3, 1, 0}}},
+		"nil chunk":         {nil},
+		"nil body":          {&chunk{Height: 3, Format: 1, Index: 0, Chunk: nil}},
+		"wrong height":      {&chunk{Height: 9, Format: 1, Index: 0, Chunk: []byte{3, 1, 0}}},
+		"wrong format":      {&chunk{Height: 3, Format: 9, Index: 0, Chunk: []byte{3, 1, 0}}},
+		"wrong base height": {&chunk{Height: 3, Format: 1, Index: 0, Chunk: []byte{3, 1, 0}, BaseHeight: 2}},
+		"invalid index":     {&chunk{Height: 3, Format: 1, Index: 5, Chunk: []byte{3, 1, 0}}},
 	}
 	for name, tc := range testcases {
 		tc := tc
@@ -552,3 +603,82 @@ func TestChunkQueue_WaitFor(t *testing.T) {
 	_, ok = <-w
 	assert.False(t, ok)
 }
+
+func TestChunkChecksum(t *testing.T) {
+	chunk := []byte{1, 2, 3}
+	for _, algorithm := range []string{config.ChunkChecksumSHA256, config.ChunkChecksumBlake2b} {
+		sum, err := chunkChecksum(algorithm, chunk)
+		require.NoError(t, err)
+		require.Len(t, sum, 32)
+
+		other, err := chunkChecksum(algorithm, []byte{1, 2, 4})
+		require.NoError(t, err)
+		require.NotEqual(t, sum, other)
+	}
+
+	sha, err := chunkChecksum(config.ChunkChecksumSHA256, chunk)
+	require.NoError(t, err)
+	blake, err := chunkChecksum(config.ChunkChecksumBlake2b, chunk)
+	require.NoError(t, err)
+	require.NotEqual(t, sha, blake)
+
+	_, err = chunkChecksum("md5", chunk)
+	require.Error(t, err)
+}
+
+func TestChunkReadAhead(t *testing.T) {
+	peer := types.NodeID("aa")
+	loaded := []uint32{}
+	load := func(index uint32) ([]byte, error) {
+		loaded = append(loaded, index)
+		if index >= 5 {
+			return nil, nil
+		}
+		return []byte{byte(index)}, nil
+	}
+
+	readAhead := newChunkReadAhead(1024)
+	_, ok := readAhead.take(peer, 3, 1, 0, 1)
+	assert.False(t, ok)
+
+	readAhead.fill(peer, 3, 1, 0, 0, 2, load)
+	assert.Equal(t, []uint32{1, 2}, loaded)
+
+	// Chunks of other snapshots aren't served from the cache
+	_, ok = readAhead.take(peer, 4, 1, 0, 1)
+	assert.False(t, ok)
+
+	chunk, ok := readAhead.take(peer, 3, 1, 0, 1)
+	require.True(t, ok)
+	assert.Equal(t, []byte{1}, chunk)
+	_, ok = readAhead.take(peer, 3, 1, 0, 1)
+	assert.False(t, ok)
+
+	// Only the chunks not already cached are loaded, stopping at missing ones
+	loaded = nil
+	readAhead.fill(peer, 3, 1, 0, 1, 5, load)
+	assert.Equal(t, []uint32{3, 4, 5}, loaded)
+
+	// Taking a chunk drops the chunks of lower indexes
+	chunk, ok = readAhead.take(peer, 3, 1, 0, 3)
+	require.True(t, ok)
+	assert.Equal(t, []byte{3}, chunk)
+	_, ok = readAhead.take(peer, 3, 1, 0, 2)
+	assert.False(t, ok)
+
+	// Removing the peer drops its chunks
+	readAhead.remove(peer)
+	_, ok = readAhead.take(peer, 3, 1, 0, 4)
+	assert.False(t, ok)
+	assert.Zero(t, readAhead.bytes)
+
+	// Reading stops at the first chunk that doesn't fit in the cache
+	readAhead = newChunkReadAhead(2)
+	loaded = nil
+	readAhead.fill(peer, 3, 1, 0, 0, 4, load)
+	assert.Equal(t, []uint32{1, 2, 3}, loaded)
+	assert.Equal(t, 2, readAhead.bytes)
+	_, ok = readAhead.take(peer, 3, 1, 0, 3)
+	assert.False(t, ok)
+	assert.Zero(t, readAhead.bytes)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/tendermint/tendermint/internal/p2p"
 	"github.com/tendermint/tendermint/light/provider"
@@ -18,6 +19,7 @@ var (
 	errUnsolicitedResponse = errors.New("unsolicited light block response")
 	errPeerAlreadyBusy     = errors.New("peer is already processing a request")
 	errDisconnected        = errors.New("dispatcher disconnected")
+	errRequestCanceled     = errors.New("light block request canceled")
 )
 
 // A Dispatcher multiplexes concurrent requests by multiple peers for light blocks.
@@ -30,15 +32,28 @@ type Dispatcher struct {
 	closeCh   chan struct{}
 
 	mtx sync.Mutex
+	// the default time to wait for a response, or 0 to wait for as long as the
+	// context of the call allows
+	timeout time.Duration
 	// all pending calls that have been dispatched and are awaiting an answer
-	calls map[types.NodeID]chan *types.LightBlock
+	calls map[types.NodeID]*dispatchedCall
 }
 
-func NewDispatcher(requestCh chan<- p2p.Envelope) *Dispatcher {
+// dispatchedCall is a light block request awaiting a response from a peer.
+type dispatchedCall struct {
+	height   int64
+	respCh   chan *types.LightBlock
+	cancelCh chan struct{} // closed by Cancel
+}
+
+// NewDispatcher creates a dispatcher sending light block requests on requestCh.
+// Calls to LightBlock wait at most timeout for a response, unless it is 0.
+func NewDispatcher(requestCh chan<- p2p.Envelope, timeout time.Duration) *Dispatcher {
 	return &Dispatcher{
 		requestCh: requestCh,
 		closeCh:   make(chan struct{}),
-		calls:     make(map[types.NodeID]chan *types.LightBlock),
+		timeout:   timeout,
+		calls:     make(map[types.NodeID]*dispatchedCall),
 	}
 }
 
@@ -46,27 +61,60 @@ func NewDispatcher(requestCh chan<- p2p.Envelope) *Dispatcher {
 // tracking, the call and waiting for the reactor to pass back the response. A nil
 // LightBlock response is used to signal that the peer doesn't have the requested LightBlock.
 func (d *Dispatcher) LightBlock(ctx context.Context, height int64, peer types.NodeID) (*types.LightBlock, error) {
+	d.mtx.Lock()
+	timeout := d.timeout
+	d.mtx.Unlock()
+	return d.LightBlockTimeout(ctx, height, peer, timeout)
+}
+
+// SetTimeout changes the default time LightBlock waits for a response. It
+// applies to the calls made after it returns.
+func (d *Dispatcher) SetTimeout(timeout time.Duration) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.timeout = timeout
+}
+
+// LightBlockTimeout is like LightBlock, but waits at most timeout for the
+// response instead of the default timeout of the dispatcher. A timeout of 0
+// waits for as long as ctx allows.
+func (d *Dispatcher) LightBlockTimeout(
+	ctx context.Context,
+	height int64,
+	peer types.NodeID,
+	timeout time.Duration,
+) (*types.LightBlock, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// dispatch the request to the peer
-	callCh, err := d.dispatch(peer, height)
+	call, err := d.dispatch(peer, height)
 	if err != nil {
 		return nil, err
 	}
 
-	// clean up the call after a response is returned
+	// clean up the call after a response is returned, unless it was already
+	// cleaned up by Cancel, which frees the peer for other calls
 	defer func() {
 		d.mtx.Lock()
 		defer d.mtx.Unlock()
-		if call, ok := d.calls[peer]; ok {
+		if d.calls[peer] == call {
 			delete(d.calls, peer)
-			close(call)
+			close(call.respCh)
 		}
 	}()
 
 	// wait for a response, cancel or timeout
 	select {
-	case resp := <-callCh:
+	case resp := <-call.respCh:
 		return resp, nil
 
+	case <-call.cancelCh:
+		return nil, errRequestCanceled
+
 	case <-ctx.Done():
 		return nil, ctx.Err()
 
@@ -75,9 +123,9 @@ func (d *Dispatcher) LightBlock(ctx context.Context, height int64, peer types.No
 	}
 }
 
-// dispatch takes a peer and allocates it a channel so long as it's not already
+// dispatch takes a peer and allocates it a call so long as it's not already
 // busy and the receiving channel is still running. It then dispatches the message
-func (d *Dispatcher) dispatch(peer types.NodeID, height int64) (chan *types.LightBlock, error) {
+func (d *Dispatcher) dispatch(peer types.NodeID, height int64) (*dispatchedCall, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 	select {
@@ -86,14 +134,16 @@ func (d *Dispatcher) dispatch(peer types.NodeID, height int64) (chan *types.Ligh
 	default:
 	}
 
-	ch := make(chan *types.LightBlock, 1)
-
 	// check if a request for the same peer has already been made
 	if _, ok := d.calls[peer]; ok {
-		close(ch)
-		return ch, errPeerAlreadyBusy
+		return nil, errPeerAlreadyBusy
+	}
+	call := &dispatchedCall{
+		height:   height,
+		respCh:   make(chan *types.LightBlock, 1),
+		cancelCh: make(chan struct{}),
 	}
-	d.calls[peer] = ch
+	d.calls[peer] = call
 
 	// send request
 	d.requestCh <- p2p.Envelope{
@@ -103,7 +153,25 @@ func (d *Dispatcher) dispatch(peer types.NodeID, height int64) (chan *types.Ligh
 		},
 	}
 
-	return ch, nil
+	return call, nil
+}
+
+// Cancel cancels the pending call for the light block at the given height
+// from the given peer, if any, so that it returns errRequestCanceled right
+// away instead of waiting for a response or its timeout. The peer is free for
+// other calls as soon as Cancel returns, and a late response from it is
+// treated as unsolicited. It returns false if no such call is pending.
+func (d *Dispatcher) Cancel(height int64, peer types.NodeID) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	call, ok := d.calls[peer]
+	if !ok || call.height != height {
+		return false
+	}
+	delete(d.calls, peer)
+	close(call.cancelCh)
+	return true
 }
 
 // Respond allows the underlying process which receives requests on the
@@ -114,7 +182,7 @@ func (d *Dispatcher) Respond(lb *proto.LightBlock, peer types.NodeID) error {
 	defer d.mtx.Unlock()
 
 	// check that the response came from a request
-	answerCh, ok := d.calls[peer]
+	call, ok := d.calls[peer]
 	if !ok {
 		// this can also happen if the response came in after the timeout
 		return errUnsolicitedResponse
@@ -123,7 +191,7 @@ func (d *Dispatcher) Respond(lb *proto.LightBlock, peer types.NodeID) error {
 	// If lb is nil we take that to mean that the peer didn't have the requested light
 	// block and thus pass on the nil to the caller.
 	if lb == nil {
-		answerCh <- nil
+		call.respCh <- nil
 		return nil
 	}
 
@@ -132,7 +200,7 @@ func (d *Dispatcher) Respond(lb *proto.LightBlock, peer types.NodeID) error {
 		return err
 	}
 
-	answerCh <- block
+	call.respCh <- block
 	return nil
 }
 
@@ -144,7 +212,7 @@ func (d *Dispatcher) Close() {
 	close(d.closeCh)
 	for peer, call := range d.calls {
 		delete(d.calls, peer)
-		close(call)
+		close(call.respCh)
 	}
 }
 
@@ -258,6 +326,19 @@ func (l *peerList) Pop(ctx context.Context) types.NodeID {
 			return peer
 
 		case <-ctx.Done():
+			l.mtx.Lock()
+			defer l.mtx.Unlock()
+			for i, w := range l.waiting {
+				if w == wait {
+					l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+					return ""
+				}
+			}
+			// a peer was handed over in the meantime, so it must be put back
+			// rather than lost
+			if peer, ok := <-wait; ok {
+				l.appendPeer(peer)
+			}
 			return ""
 		}
 	}
@@ -271,6 +352,12 @@ func (l *peerList) Pop(ctx context.Context) types.NodeID {
 func (l *peerList) Append(peer types.NodeID) {
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
+	l.appendPeer(peer)
+}
+
+// appendPeer hands peer over to the first Pop waiting for one, if any, or
+// appends it to the list. The caller must hold mtx.
+func (l *peerList) appendPeer(peer types.NodeID) {
 	if len(l.waiting) > 0 {
 		wait := l.waiting[0]
 		l.waiting = l.waiting[1:]
@@ -292,8 +379,27 @@ func (l *peerList) Remove(peer types.NodeID) {
 	}
 }
 
+// Take removes up to n peers other than except from the list without waiting
+// for any, and returns them. The caller must Append them back once done.
+func (l *peerList) Take(n int, except types.NodeID) []types.NodeID {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	taken := make([]types.NodeID, 0, n)
+	remaining := make([]types.NodeID, 0, len(l.peers))
+	for _, peer := range l.peers {
+		if len(taken) < n && peer != except {
+			taken = append(taken, peer)
+			continue
+		}
+		remaining = append(remaining, peer)
+	}
+	l.peers = remaining
+	return taken
+}
+
+// All returns a copy of the peers currently in the list.
 func (l *peerList) All() []types.NodeID {
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
-	return l.peers
+	return append([]types.NodeID(nil), l.peers...)
 }
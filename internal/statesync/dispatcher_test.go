@@ -26,7 +26,7 @@ func TestDispatcherBasic(t *testing.T) {
 	closeCh := make(chan struct{})
 	defer close(closeCh)
 
-	d := NewDispatcher(ch)
+	d := NewDispatcher(ch, 0)
 	go handleRequests(t, d, ch, closeCh)
 
 	peers := createPeerSet(numPeers)
@@ -53,7 +53,7 @@ func TestDispatcherBasic(t *testing.T) {
 func TestDispatcherReturnsNoBlock(t *testing.T) {
 	t.Cleanup(leaktest.Check(t))
 	ch := make(chan p2p.Envelope, 100)
-	d := NewDispatcher(ch)
+	d := NewDispatcher(ch, 0)
 	doneCh := make(chan struct{})
 	peer := factory.NodeID("a")
 
@@ -73,7 +73,7 @@ func TestDispatcherReturnsNoBlock(t *testing.T) {
 func TestDispatcherTimeOutWaitingOnLightBlock(t *testing.T) {
 	t.Cleanup(leaktest.Check(t))
 	ch := make(chan p2p.Envelope, 100)
-	d := NewDispatcher(ch)
+	d := NewDispatcher(ch, 0)
 	peer := factory.NodeID("a")
 
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Millisecond)
@@ -86,6 +86,67 @@ func TestDispatcherTimeOutWaitingOnLightBlock(t *testing.T) {
 	require.Nil(t, lb)
 }
 
+func TestDispatcherTimeouts(t *testing.T) {
+	t.Cleanup(leaktest.Check(t))
+	ch := make(chan p2p.Envelope, 100)
+	d := NewDispatcher(ch, 10*time.Millisecond)
+	peer := factory.NodeID("a")
+
+	// calls time out after the default timeout
+	start := time.Now()
+	lb, err := d.LightBlock(context.Background(), 1, peer)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Nil(t, lb)
+	require.Less(t, time.Since(start), time.Second)
+
+	// unless it's overridden
+	go func() {
+		<-ch
+		<-ch
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, d.Respond(nil, peer))
+	}()
+	lb, err = d.LightBlockTimeout(context.Background(), 1, peer, time.Second)
+	require.NoError(t, err)
+	require.Nil(t, lb)
+}
+
+func TestDispatcherCancel(t *testing.T) {
+	t.Cleanup(leaktest.Check(t))
+	ch := make(chan p2p.Envelope, 100)
+	d := NewDispatcher(ch, time.Minute)
+	peer := factory.NodeID("a")
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := d.LightBlock(context.Background(), 5, peer)
+		errCh <- err
+	}()
+	<-ch
+
+	// only the pending call of the given height is canceled
+	require.False(t, d.Cancel(4, peer))
+	require.False(t, d.Cancel(5, factory.NodeID("b")))
+	require.True(t, d.Cancel(5, peer))
+	select {
+	case err := <-errCh:
+		require.Equal(t, errRequestCanceled, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "canceled call didn't return")
+	}
+
+	// the peer is free for other calls, and a late response is unsolicited
+	require.Equal(t, errUnsolicitedResponse, d.Respond(nil, peer))
+	go func() {
+		<-ch
+		require.NoError(t, d.Respond(nil, peer))
+	}()
+	lb, err := d.LightBlock(context.Background(), 6, peer)
+	require.NoError(t, err)
+	require.Nil(t, lb)
+	require.Empty(t, d.calls)
+}
+
 func TestDispatcherProviders(t *testing.T) {
 	t.Cleanup(leaktest.Check(t))
 
@@ -94,7 +155,7 @@ func TestDispatcherProviders(t *testing.T) {
 	closeCh := make(chan struct{})
 	defer close(closeCh)
 
-	d := NewDispatcher(ch)
+	d := NewDispatcher(ch, 0)
 	go handleRequests(t, d, ch, closeCh)
 
 	peers := createPeerSet(5)
@@ -197,6 +258,27 @@ func TestEmptyPeerListReturnsWhenContextCanceled(t *testing.T) {
 	}
 }
 
+func TestPeerListCanceledPopKeepsPeers(t *testing.T) {
+	t.Cleanup(leaktest.Check(t))
+	peerList := newPeerList()
+	peer := createPeerSet(1)[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh := make(chan struct{})
+	go func() {
+		require.Equal(t, types.NodeID(""), peerList.Pop(ctx))
+		close(doneCh)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-doneCh
+
+	// the peer is not handed over to the canceled call
+	peerList.Append(peer)
+	require.Equal(t, 1, peerList.Len())
+	require.Equal(t, peer, peerList.Pop(context.Background()))
+}
+
 func TestPeerListConcurrent(t *testing.T) {
 	t.Cleanup(leaktest.Check(t))
 	peerList := newPeerList()
@@ -262,6 +344,25 @@ func TestPeerListRemove(t *testing.T) {
 	}
 }
 
+func TestPeerListTake(t *testing.T) {
+	peerList := newPeerList()
+	peerSet := createPeerSet(4)
+	for _, peer := range peerSet {
+		peerList.Append(peer)
+	}
+
+	// the excluded peer is skipped and stays in the list
+	taken := peerList.Take(2, peerSet[0])
+	require.Equal(t, peerSet[1:3], taken)
+	require.Equal(t, []types.NodeID{peerSet[0], peerSet[3]}, peerList.All())
+
+	// no more peers than available are taken, without waiting for any
+	taken = peerList.Take(3, peerSet[0])
+	require.Equal(t, peerSet[3:], taken)
+	require.Equal(t, peerSet[:1], peerList.All())
+	require.Empty(t, peerList.Take(1, peerSet[0]))
+}
+
 // handleRequests is a helper function usually run in a separate go routine to
 // imitate the expected responses of the reactor wired to the dispatcher
 func handleRequests(t *testing.T, d *Dispatcher, ch chan p2p.Envelope, closeCh chan struct{}) {
@@ -287,3 +388,32 @@ func createPeerSet(num int) []types.NodeID {
 	}
 	return peers
 }
+
+func BenchmarkPeerListPopAppend(b *testing.B) {
+	for _, fetchers := range []int{1, 4, 16, 64} {
+		for _, numPeers := range []int{4, 64} {
+			b.Run(fmt.Sprintf("fetchers=%d/peers=%d", fetchers, numPeers), func(b *testing.B) {
+				peerList := newPeerList()
+				for _, peer := range createPeerSet(numPeers) {
+					peerList.Append(peer)
+				}
+				ctx := context.Background()
+
+				// every fetcher pops a peer and appends it back once done, like
+				// the backfill fetchers do around each request
+				b.ResetTimer()
+				wg := sync.WaitGroup{}
+				for i := 0; i < fetchers; i++ {
+					wg.Add(1)
+					go func(n int) {
+						defer wg.Done()
+						for j := 0; j < n; j++ {
+							peerList.Append(peerList.Pop(ctx))
+						}
+					}(b.N / fetchers)
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
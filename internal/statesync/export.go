@@ -0,0 +1,175 @@
+package statesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/internal/libs/protoio"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	sm "github.com/tendermint/tendermint/state"
+)
+
+// Snapshot export format
+//
+// An exported snapshot is a stream of varint length-delimited protobuf
+// messages. The first message is an abci.Snapshot describing the snapshot
+// (height, format, number of chunks, hash and metadata). It is followed by one
+// ssproto.ChunkResponse per chunk, in ascending chunk index order, each
+// carrying the height, format and index of the snapshot and chunk it belongs
+// to. No message may exceed chunkMsgSize bytes.
+
+// ExportSnapshot writes the full snapshot of the given height and format held by
+// the local application to w, using the format described above. It returns an
+// error if the application does not have such a snapshot.
+func (r *Reactor) ExportSnapshot(height uint64, format uint32, w io.Writer) error {
+	resp, err := r.conn.ListSnapshotsSync(context.Background(), abci.RequestListSnapshots{})
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshot *abci.Snapshot
+	for _, s := range resp.Snapshots {
+		if s.Height == height && s.Format == format && s.BaseHeight == 0 {
+			snapshot = s
+			break
+		}
+	}
+	if snapshot == nil {
+		return fmt.Errorf("snapshot at height %d with format %d not found", height, format)
+	}
+
+	pw := protoio.NewDelimitedWriter(w)
+	if _, err := pw.WriteMsg(snapshot); err != nil {
+		return fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	for index := uint32(0); index < snapshot.Chunks; index++ {
+		resp, err := r.conn.LoadSnapshotChunkSync(context.Background(), abci.RequestLoadSnapshotChunk{
+			Height: height,
+			Format: format,
+			Chunk:  index,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load chunk %d: %w", index, err)
+		}
+		if resp.Chunk == nil {
+			return fmt.Errorf("chunk %d of snapshot at height %d not found", index, height)
+		}
+
+		if _, err := pw.WriteMsg(&ssproto.ChunkResponse{
+			Height: height,
+			Format: format,
+			Index:  index,
+			Chunk:  resp.Chunk,
+		}); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", index, err)
+		}
+	}
+
+	return nil
+}
+
+// ImportSnapshot restores the application from a snapshot previously written by
+// ExportSnapshot, instead of discovering and fetching one from peers. The
+// snapshot is verified against a trusted app hash obtained through the state
+// provider, like in Sync. On success, the state store is bootstrapped and the
+// commit persisted, but no backfill is performed; callers that need it should
+// call Backfill with the returned state.
+//
+// Since chunks are only read from rd, a snapshot whose chunks are rejected or
+// refetched by the application cannot complete, and ImportSnapshot blocks
+// until ctx is canceled.
+func (r *Reactor) ImportSnapshot(ctx context.Context, rd io.Reader) (state sm.State, err error) {
+	defer r.beginOperation()()
+
+	// All chunks come from rd, so there is nothing for the fetchers to do, nor
+	// any peer to probe
+	cfg := r.cfg
+	cfg.Fetchers = 0
+	cfg.MinChunkServingPeers = 0
+
+	if err := r.startSyncer(ctx, cfg, 0); err != nil {
+		return sm.State{}, err
+	}
+	defer func() { r.stopSyncer(state, err) }()
+
+	snapshot, chunks, err := r.readSnapshot(rd)
+	if err != nil {
+		return sm.State{}, err
+	}
+	defer chunks.Close()
+
+	state, commit, err := r.syncer.Sync(ctx, snapshot, chunks)
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	err = r.stateStore.Bootstrap(state)
+	if err != nil {
+		return sm.State{}, fmt.Errorf("failed to bootstrap node with new state: %w", err)
+	}
+
+	err = r.blockStore.SaveSeenCommit(state.LastBlockHeight, commit)
+	if err != nil {
+		return sm.State{}, fmt.Errorf("failed to store last seen commit: %w", err)
+	}
+
+	return state, nil
+}
+
+// readSnapshot reads an exported snapshot from rd, queueing all of its chunks.
+// The caller must close the returned chunk queue.
+func (r *Reactor) readSnapshot(rd io.Reader) (*snapshot, *chunkQueue, error) {
+	pr := protoio.NewDelimitedReader(rd, chunkMsgSize)
+
+	header := &abci.Snapshot{}
+	if _, err := pr.ReadMsg(header); err != nil {
+		return nil, nil, fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+	if header.Chunks == 0 {
+		return nil, nil, errors.New("snapshot has no chunks")
+	}
+
+	snapshot := &snapshot{
+		Height:   header.Height,
+		Format:   header.Format,
+		Chunks:   header.Chunks,
+		Hash:     header.Hash,
+		Metadata: header.Metadata,
+	}
+	chunks, err := newChunkQueue(snapshot, r.tempDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create chunk queue: %w", err)
+	}
+
+	for index := uint32(0); index < snapshot.Chunks; index++ {
+		msg := &ssproto.ChunkResponse{}
+		if _, err := pr.ReadMsg(msg); err != nil {
+			chunks.Close()
+			return nil, nil, fmt.Errorf("failed to read chunk %d: %w", index, err)
+		}
+		if msg.Height != snapshot.Height || msg.Format != snapshot.Format || msg.Index != index {
+			chunks.Close()
+			return nil, nil, fmt.Errorf("unexpected chunk %d for snapshot at height %d with format %d, expected chunk %d",
+				msg.Index, msg.Height, msg.Format, index)
+		}
+		if msg.Chunk == nil {
+			msg.Chunk = []byte{}
+		}
+
+		if _, err := chunks.Add(&chunk{
+			Height: msg.Height,
+			Format: msg.Format,
+			Index:  msg.Index,
+			Chunk:  msg.Chunk,
+		}); err != nil {
+			chunks.Close()
+			return nil, nil, fmt.Errorf("failed to add chunk %d: %w", index, err)
+		}
+	}
+
+	return snapshot, chunks, nil
+}
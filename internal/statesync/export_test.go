@@ -0,0 +1,89 @@
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/internal/libs/protoio"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+)
+
+func TestReactor_ExportSnapshot(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", context.Background(), abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: []*abci.Snapshot{
+			{Height: 1, Format: 1, Chunks: 1, Hash: []byte{1}},
+			{Height: 2, Format: 1, Chunks: 3, Hash: []byte{2}, Metadata: []byte("meta")},
+		},
+	}, nil)
+	chunks := [][]byte{{1, 2}, {3, 4}, {5, 6}}
+	for i, c := range chunks {
+		conn.On("LoadSnapshotChunkSync", context.Background(), abci.RequestLoadSnapshotChunk{
+			Height: 2, Format: 1, Chunk: uint32(i),
+		}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: c}, nil)
+	}
+
+	r := &Reactor{conn: conn, tempDir: t.TempDir()}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, r.ExportSnapshot(2, 1, buf))
+	conn.AssertExpectations(t)
+
+	s, queue, err := r.readSnapshot(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer queue.Close()
+
+	require.Equal(t, &snapshot{
+		Height: 2, Format: 1, Chunks: 3, Hash: []byte{2}, Metadata: []byte("meta"),
+	}, s)
+	for i, c := range chunks {
+		next, err := queue.Next()
+		require.NoError(t, err)
+		require.Equal(t, &chunk{Height: 2, Format: 1, Index: uint32(i), Chunk: c}, next)
+	}
+	_, err = queue.Next()
+	require.Equal(t, errDone, err)
+
+	// unknown snapshots can't be exported
+	require.Error(t, r.ExportSnapshot(3, 1, &bytes.Buffer{}))
+}
+
+func TestReactor_ReadSnapshot_Invalid(t *testing.T) {
+	r := &Reactor{tempDir: t.TempDir()}
+
+	write := func(msgs ...proto.Message) []byte {
+		buf := &bytes.Buffer{}
+		w := protoio.NewDelimitedWriter(buf)
+		for _, msg := range msgs {
+			_, err := w.WriteMsg(msg)
+			require.NoError(t, err)
+		}
+		return buf.Bytes()
+	}
+	header := &abci.Snapshot{Height: 1, Format: 1, Chunks: 2, Hash: []byte{1}}
+
+	testcases := map[string][]byte{
+		"empty":     {},
+		"no chunks": write(&abci.Snapshot{Height: 1, Format: 1}),
+		"truncated": write(header, &ssproto.ChunkResponse{Height: 1, Format: 1, Index: 0, Chunk: []byte{1}}),
+		"wrong index": write(header,
+			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{1}},
+			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 0, Chunk: []byte{1}}),
+		"wrong height": write(header,
+			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 0, Chunk: []byte{1}},
+			&ssproto.ChunkResponse{Height: 2, Format: 1, Index: 1, Chunk: []byte{1}}),
+	}
+	for name, data := range testcases {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			_, _, err := r.readSnapshot(bytes.NewReader(data))
+			require.Error(t, err)
+		})
+	}
+}
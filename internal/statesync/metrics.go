@@ -0,0 +1,67 @@
+package statesync
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsSubsystem is a subsystem shared by all metrics exposed by this
+	// package.
+	MetricsSubsystem = "statesync"
+)
+
+// Metrics contains metrics exposed by this package.
+type Metrics struct {
+	// Number of light client provider additions and removals waiting to be
+	// applied to the state provider.
+	ProviderQueueDepth metrics.Gauge
+	// Time spent by the backfill verifier waiting for light blocks to be
+	// fetched.
+	BackfillVerifierIdleSeconds metrics.Counter
+	// Number of light blocks of the wrong height returned by peers during
+	// backfill. Backfill keeps the count of each peer itself, to report the
+	// peers returning them repeatedly.
+	BackfillWrongHeightResponses metrics.Counter
+}
+
+// PrometheusMetrics returns Metrics build using Prometheus client library.
+// Optionally, labels can be provided along with their values ("foo",
+// "fooValue").
+func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
+	labels := []string{}
+	for i := 0; i < len(labelsAndValues); i += 2 {
+		labels = append(labels, labelsAndValues[i])
+	}
+	return &Metrics{
+		ProviderQueueDepth: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "provider_queue_depth",
+			Help:      "Number of light client provider updates waiting to be applied.",
+		}, labels).With(labelsAndValues...),
+		BackfillVerifierIdleSeconds: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "backfill_verifier_idle_seconds",
+			Help:      "Time spent by the backfill verifier waiting for light blocks.",
+		}, labels).With(labelsAndValues...),
+		BackfillWrongHeightResponses: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "backfill_wrong_height_responses",
+			Help:      "Number of light blocks of the wrong height returned by peers during backfill.",
+		}, labels).With(labelsAndValues...),
+	}
+}
+
+// NopMetrics returns no-op Metrics.
+func NopMetrics() *Metrics {
+	return &Metrics{
+		ProviderQueueDepth:           discard.NewGauge(),
+		BackfillVerifierIdleSeconds:  discard.NewCounter(),
+		BackfillWrongHeightResponses: discard.NewCounter(),
+	}
+}
@@ -0,0 +1,97 @@
+package statesync
+
+import (
+	"sync"
+
+	"github.com/tendermint/tendermint/internal/p2p"
+	"github.com/tendermint/tendermint/types"
+)
+
+// peerUpdateQueue buffers peer updates between their reception and their
+// processing, so that an update that is slow to process, for instance because
+// the light client is busy, doesn't hold up the reception of the following
+// ones.
+//
+// Updates are coalesced so that at most one update is pending per peer, which
+// bounds the queue by the number of peers. An update with the same status as
+// the pending update of the peer is dropped, as it has no effect. An update
+// with the opposite status cancels the pending update of the peer, as the two
+// have no net effect, leaving the peer in the state it was in before.
+//
+// Pending updates are processed in the order in which they were received.
+type peerUpdateQueue struct {
+	mtx     sync.Mutex
+	pending []p2p.PeerUpdate
+
+	// readyCh is signaled whenever an update is queued
+	readyCh chan struct{}
+}
+
+func newPeerUpdateQueue() *peerUpdateQueue {
+	return &peerUpdateQueue{
+		pending: make([]p2p.PeerUpdate, 0),
+		readyCh: make(chan struct{}, 1),
+	}
+}
+
+// push queues an update, coalescing it with the pending update of the same
+// peer if any.
+func (q *peerUpdateQueue) push(update p2p.PeerUpdate) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if i := q.find(update.NodeID); i >= 0 {
+		if q.pending[i].Status != update.Status {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+		}
+		return
+	}
+
+	q.pending = append(q.pending, update)
+	select {
+	case q.readyCh <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes and returns the oldest pending update. It returns false if there
+// are none.
+func (q *peerUpdateQueue) pop() (p2p.PeerUpdate, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if len(q.pending) == 0 {
+		return p2p.PeerUpdate{}, false
+	}
+	update := q.pending[0]
+	q.pending = q.pending[1:]
+	return update, true
+}
+
+// clear drops all pending updates.
+func (q *peerUpdateQueue) clear() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.pending = q.pending[:0]
+}
+
+// ready returns a channel that is signaled when updates are queued.
+func (q *peerUpdateQueue) ready() <-chan struct{} {
+	return q.readyCh
+}
+
+// len returns the number of pending updates.
+func (q *peerUpdateQueue) len() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return len(q.pending)
+}
+
+func (q *peerUpdateQueue) find(peer types.NodeID) int {
+	for i, update := range q.pending {
+		if update.NodeID == peer {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+package statesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/internal/p2p"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestPeerUpdateQueue(t *testing.T) {
+	up := func(peer string) p2p.PeerUpdate {
+		return p2p.PeerUpdate{NodeID: types.NodeID(peer), Status: p2p.PeerStatusUp}
+	}
+	down := func(peer string) p2p.PeerUpdate {
+		return p2p.PeerUpdate{NodeID: types.NodeID(peer), Status: p2p.PeerStatusDown}
+	}
+
+	testcases := map[string]struct {
+		pushed   []p2p.PeerUpdate
+		expected []p2p.PeerUpdate
+	}{
+		"in order":        {[]p2p.PeerUpdate{up("a"), up("b"), down("c")}, []p2p.PeerUpdate{up("a"), up("b"), down("c")}},
+		"repeated status": {[]p2p.PeerUpdate{up("a"), up("b"), up("a")}, []p2p.PeerUpdate{up("a"), up("b")}},
+		"up then down":    {[]p2p.PeerUpdate{up("a"), up("b"), down("a")}, []p2p.PeerUpdate{up("b")}},
+		"down then up":    {[]p2p.PeerUpdate{down("a"), up("a"), up("b")}, []p2p.PeerUpdate{up("b")}},
+		"flapping":        {[]p2p.PeerUpdate{up("a"), down("a"), up("a")}, []p2p.PeerUpdate{up("a")}},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			queue := newPeerUpdateQueue()
+			for _, update := range tc.pushed {
+				queue.push(update)
+			}
+			require.Equal(t, len(tc.expected), queue.len())
+
+			select {
+			case <-queue.ready():
+			default:
+				t.Fatal("expected the queue to be ready")
+			}
+
+			popped := []p2p.PeerUpdate{}
+			for {
+				update, ok := queue.pop()
+				if !ok {
+					break
+				}
+				popped = append(popped, update)
+			}
+			require.Equal(t, tc.expected, popped)
+		})
+	}
+}
+
+func TestReactor_PeerUpdatesSlowProcessing(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	// hold the reactor lock so that peer updates can't be processed, as if
+	// processing one of them were slow
+	rts.reactor.mtx.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			rts.reactor.mtx.Unlock()
+		}
+	}()
+
+	// peer updates are still received
+	for _, peer := range []string{"a", "b", "c", "d", "e"} {
+		select {
+		case rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID(peer), Status: p2p.PeerStatusUp}:
+		case <-time.After(time.Second):
+			t.Fatal("peer update was not received")
+		}
+	}
+
+	rts.reactor.mtx.Unlock()
+	locked = false
+	require.Eventually(t, func() bool {
+		return rts.reactor.peers.Len() == 5
+	}, time.Second, 10*time.Millisecond)
+}
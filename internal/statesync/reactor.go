@@ -5,15 +5,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime/debug"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/internal/libs/flowrate"
 	tmsync "github.com/tendermint/tendermint/internal/libs/sync"
 	"github.com/tendermint/tendermint/internal/p2p"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
 	"github.com/tendermint/tendermint/light"
@@ -29,60 +37,94 @@ var (
 	_ service.Service = (*Reactor)(nil)
 	_ p2p.Wrapper     = (*ssproto.Message)(nil)
 
-	// ChannelShims contains a map of ChannelDescriptorShim objects, where each
-	// object wraps a reference to a legacy p2p ChannelDescriptor and the corresponding
-	// p2p proto.Message the new p2p Channel is responsible for handling.
-	//
-	//
-	// TODO: Remove once p2p refactor is complete.
-	// ref: https://github.com/tendermint/tendermint/issues/5670
-	ChannelShims = map[p2p.ChannelID]*p2p.ChannelDescriptorShim{
+	// errBackfillTimeExceeded is returned by backfill when the configured
+	// MaxBackfillTime elapses before the stop height has been reached.
+	errBackfillTimeExceeded = errors.New("backfill time limit exceeded")
+
+	// errInsufficientPeers is returned by Sync when not enough peers connect
+	// within the configured PeerWaitTimeout.
+	errInsufficientPeers = errors.New("insufficient peers for state sync")
+
+	// errSyncInProgress is returned by Sync when another state sync is running.
+	errSyncInProgress = errors.New("a state sync is already in progress")
+
+	// errOperationInProgress is returned by Restart when a state sync, backfill
+	// or fetch of light blocks is running with the current config.
+	errOperationInProgress = errors.New("a state sync, backfill or light block fetch is in progress")
+
+	// errNoStateProvider is returned by Sync when neither the P2P nor the RPC
+	// state provider is configured.
+	errNoStateProvider = errors.New("no state provider configured: enable use-p2p or set rpc-servers")
+
+	// errPersistFailed is returned by Sync when the state restored from a
+	// snapshot could not be persisted, after the snapshot has been applied.
+	errPersistFailed = errors.New("failed to persist state after applying snapshot")
+
+	// errInconsistentStores is returned by VerifyStoreConsistency when the
+	// heights of the block store and the state store disagree.
+	errInconsistentStores = errors.New("block store and state store heights disagree")
+
+	// errCrossCheckPeers is returned by backfill when BackfillCrossCheck is set
+	// but fewer than two peers are connected, so that no light block can ever be
+	// confirmed by another peer.
+	errCrossCheckPeers = errors.New("not enough peers connected to cross-check light blocks")
+)
+
+// GetChannelShims returns a map of ChannelDescriptorShim objects, where each
+// object wraps a reference to a legacy p2p ChannelDescriptor and the corresponding
+// p2p proto.Message the new p2p Channel is responsible for handling. Channel
+// priorities and MaxSendBytes are taken from cfg.
+//
+// TODO: Remove once p2p refactor is complete.
+// ref: https://github.com/tendermint/tendermint/issues/5670
+func GetChannelShims(cfg *config.StateSyncConfig) map[p2p.ChannelID]*p2p.ChannelDescriptorShim {
+	return map[p2p.ChannelID]*p2p.ChannelDescriptorShim{
 		SnapshotChannel: {
 			MsgType: new(ssproto.Message),
 			Descriptor: &p2p.ChannelDescriptor{
 				ID:                  byte(SnapshotChannel),
-				Priority:            6,
+				Priority:            cfg.SnapshotChannelPriority,
 				SendQueueCapacity:   10,
 				RecvMessageCapacity: snapshotMsgSize,
 				RecvBufferCapacity:  128,
-				MaxSendBytes:        400,
+				MaxSendBytes:        uint(cfg.SnapshotChannelMaxSendBytes),
 			},
 		},
 		ChunkChannel: {
 			MsgType: new(ssproto.Message),
 			Descriptor: &p2p.ChannelDescriptor{
 				ID:                  byte(ChunkChannel),
-				Priority:            3,
+				Priority:            cfg.ChunkChannelPriority,
 				SendQueueCapacity:   4,
 				RecvMessageCapacity: chunkMsgSize,
 				RecvBufferCapacity:  128,
-				MaxSendBytes:        400,
+				MaxSendBytes:        uint(cfg.ChunkChannelMaxSendBytes),
 			},
 		},
 		LightBlockChannel: {
 			MsgType: new(ssproto.Message),
 			Descriptor: &p2p.ChannelDescriptor{
 				ID:                  byte(LightBlockChannel),
-				Priority:            5,
+				Priority:            cfg.LightBlockChannelPriority,
 				SendQueueCapacity:   10,
 				RecvMessageCapacity: lightBlockMsgSize,
 				RecvBufferCapacity:  128,
-				MaxSendBytes:        400,
+				MaxSendBytes:        uint(cfg.LightBlockChannelMaxSendBytes),
 			},
 		},
 		ParamsChannel: {
 			MsgType: new(ssproto.Message),
 			Descriptor: &p2p.ChannelDescriptor{
 				ID:                  byte(ParamsChannel),
-				Priority:            2,
+				Priority:            cfg.ParamsChannelPriority,
 				SendQueueCapacity:   10,
 				RecvMessageCapacity: paramMsgSize,
 				RecvBufferCapacity:  128,
-				MaxSendBytes:        400,
+				MaxSendBytes:        uint(cfg.ParamsChannelMaxSendBytes),
 			},
 		},
 	}
-)
+}
 
 const (
 	// SnapshotChannel exchanges snapshot metadata
@@ -106,16 +148,20 @@ const (
 	// chunkMsgSize is the maximum size of a chunkResponseMessage
 	chunkMsgSize = int(16e6) // ~16MB
 
+	// maxReadAheadBytes is the maximum size of the chunks read ahead for all
+	// peers when ChunkReadAhead is set.
+	maxReadAheadBytes = 4 * chunkMsgSize
+
+	// readAheadTimeout is the maximum amount of time spent reading ahead the
+	// chunks following a chunk served to a peer.
+	readAheadTimeout = 30 * time.Second
+
 	// lightBlockMsgSize is the maximum size of a lightBlockResponseMessage
 	lightBlockMsgSize = int(1e7) // ~1MB
 
 	// paramMsgSize is the maximum size of a paramsResponseMessage
 	paramMsgSize = int(1e5) // ~100kb
 
-	// lightBlockResponseTimeout is how long the dispatcher waits for a peer to
-	// return a light block
-	lightBlockResponseTimeout = 10 * time.Second
-
 	// consensusParamsResponseTimeout is the time the p2p state provider waits
 	// before performing a secondary call
 	consensusParamsResponseTimeout = 5 * time.Second
@@ -123,6 +169,52 @@ const (
 	// maxLightBlockRequestRetries is the amount of retries acceptable before
 	// the backfill process aborts
 	maxLightBlockRequestRetries = 20
+
+	// maxWrongHeightResponses is the number of light blocks of the wrong height
+	// a peer may return during backfill before it is reported
+	maxWrongHeightResponses = 3
+
+	// fetchHeightAttempts is the number of peers a light block is requested
+	// from by FetchHeights before giving up on its height
+	fetchHeightAttempts = 5
+
+	// verifierIdleWindow is the period over which the share of time the
+	// backfill verifier spends waiting for light blocks to be fetched is
+	// checked against backfill-idle-warn-percent.
+	verifierIdleWindow = 1 * time.Minute
+
+	// backfillRetryBaseDelay is the delay before the first retry of a failed
+	// backfill, when the backfill failure policy is to retry.
+	backfillRetryBaseDelay = 5 * time.Second
+
+	// persistRetries is the number of times persisting the state restored from
+	// a snapshot is retried before failing the sync.
+	persistRetries = 3
+
+	// persistRetryBaseDelay is the delay before the first retry of a failed
+	// attempt to persist the state restored from a snapshot.
+	persistRetryBaseDelay = 500 * time.Millisecond
+
+	// backfillStopTimeTolerance is how much older than the stop time the block
+	// terminating backfill on the time criterion may be
+	backfillStopTimeTolerance = 1 * time.Hour
+
+	// serveRateWindow is the window over which the rate of chunks served to
+	// peers is averaged. Chunks are large, so a long window is used to smooth
+	// out the spike of every individual chunk.
+	serveRateWindow = 10 * time.Second
+
+	// lightBlockServeRateSample is the period over which the number of light
+	// block requests served to a peer is limited.
+	lightBlockServeRateSample = 1 * time.Second
+
+	// protocolVersion is the state sync protocol version spoken by this node,
+	// advertised to peers on each channel via Capabilities.
+	protocolVersion = 2
+
+	// legacyProtocolVersion is the protocol version of peers that never
+	// advertised one, i.e. that predate Capabilities.
+	legacyProtocolVersion = 1
 )
 
 // Reactor handles state sync, both restoring snapshots for the local node and
@@ -137,6 +229,7 @@ type Reactor struct {
 	blockStore    *store.BlockStore
 
 	conn        proxy.AppConnSnapshot
+	serveConn   proxy.AppConnSnapshot // serves peers' snapshot and chunk requests
 	connQuery   proxy.AppConnQuery
 	tempDir     string
 	snapshotCh  *p2p.Channel
@@ -150,6 +243,81 @@ type Reactor struct {
 	// peers used by the p2p state provider and in reverse sync.
 	dispatcher *Dispatcher
 	peers      *peerList
+	metrics    *Metrics
+
+	// providerUpdates queues the additions and removals of light client
+	// providers for the P2P state provider, which are applied one at a time so
+	// that a burst of peer updates doesn't overwhelm the light client.
+	providerUpdates *peerUpdateQueue
+
+	// serveDisabled is set when snapshots and chunks are served by another
+	// reactor, over a dedicated p2p listener, so that their requests are
+	// ignored on this reactor's channels.
+	serveDisabled bool
+
+	// serveMonitor tracks the rate at which chunks are served to peers, so
+	// that it can be limited while the node is itself syncing.
+	serveMonitor *flowrate.Monitor
+
+	// lightBlockMonitors track the rate at which light block requests are
+	// served to each peer, so that it can be limited. They are removed when
+	// the peer disconnects.
+	lightBlockMtx      tmsync.Mutex
+	lightBlockMonitors map[types.NodeID]*flowrate.Monitor
+
+	// peerPanics counts the panics recovered from while handling the messages
+	// of each peer. They are removed when the peer disconnects.
+	peerPanicsMtx tmsync.Mutex
+	peerPanics    map[types.NodeID]int
+
+	// connected holds the peers connected to the reactor, including those lent
+	// out of peers to in-flight light block requests.
+	connectedMtx tmsync.Mutex
+	connected    map[types.NodeID]bool
+
+	// readAhead caches the chunks read ahead of each peer's chunk requests
+	// when ChunkReadAhead is set. They are removed when the peer disconnects.
+	readAhead *chunkReadAhead
+
+	// peerVersions holds the protocol version advertised by each peer on each
+	// channel. They are removed when the peer disconnects.
+	peerVersionsMtx tmsync.RWMutex
+	peerVersions    map[types.NodeID]map[p2p.ChannelID]uint32
+
+	// advertisedVersion is the protocol version advertised to peers when they
+	// connect, or zero to not advertise one.
+	advertisedVersion uint32
+
+	// listSnapshotsCh receives the result of the ListSnapshots call to the app
+	// in flight, if any, and lastSnapshots holds the snapshots returned by the
+	// last successful one. They are only accessed by the snapshot channel
+	// handler.
+	listSnapshotsCh <-chan listSnapshotsResult
+	lastSnapshots   []*snapshot
+
+	// tracer creates the spans of state sync operations. It defaults to a
+	// no-op tracer.
+	tracer Tracer
+
+	// backfillVerified, if set, is called with every block verified during
+	// backfill.
+	backfillVerified BackfillVerifiedFunc
+
+	// verifyParams, if set, must approve the consensus params received by the
+	// P2P state provider.
+	verifyParams ParamsVerifier
+
+	// syncRetryDelay is the time given to peers to respond to snapshot
+	// requests before retrying a failed sync without discovery.
+	syncRetryDelay time.Duration
+
+	// backfillRetryDelay is the delay before the first retry of a failed
+	// backfill, doubled before every further one.
+	backfillRetryDelay time.Duration
+
+	// persistRetryDelay is the delay before the first retry of a failed
+	// attempt to persist the state restored from a snapshot.
+	persistRetryDelay time.Duration
 
 	// These will only be set when a state sync is in progress. It is used to feed
 	// received snapshots and chunks into the syncer and manage incoming and outgoing
@@ -158,15 +326,118 @@ type Reactor struct {
 	syncer        *syncer
 	providers     map[types.NodeID]*BlockProvider
 	stateProvider StateProvider
+
+	// trustOptions are the trust options the state provider of the ongoing or
+	// last sync was initialized with. They are guarded by mtx.
+	trustOptions light.TrustOptions
+
+	// backfillQueue is the block queue of the ongoing or last backfill. It is
+	// guarded by mtx.
+	backfillQueue *blockQueue
+
+	// run broadcasts the outcome of the state sync in progress to the callers
+	// of SyncOrWait. It is guarded by mtx.
+	run *syncRun
+
+	// operations counts the calls to Sync, ImportSnapshot, Backfill and
+	// FetchHeights in progress, which read cfg without locking, so that
+	// Restart doesn't change it under them. It is guarded by mtx.
+	operations int
+
+	// lastSyncResult is the result of the last successful Sync, if any. It is
+	// guarded by mtx.
+	lastSyncResult *SyncResult
+
+	// selectedSnapshot is the snapshot restored by the last sync that got as
+	// far as restoring one, if any. It is guarded by mtx.
+	selectedSnapshot *SelectedSnapshot
+
+	// formatsMtx guards the cache of the snapshot formats supported by the
+	// app. ABCI has no call returning them, so appFormats holds the formats of
+	// the snapshots listed by the app the first time it lists any, as apps
+	// take snapshots in the formats they support, or nil until then, and
+	// rejectedFormats holds the formats the app rejected when offered a
+	// snapshot, which outlive the syncer.
+	formatsMtx      tmsync.RWMutex
+	appFormats      map[uint32]bool
+	rejectedFormats map[uint32]bool
+
+	// The goroutines processing the p2p Channels are stopped and restarted by
+	// Restart to apply a new config, without touching the peer list or the p2p
+	// Channels themselves. restartMtx serializes Restart with OnStop.
+	restartMtx     tmsync.Mutex
+	handlersStopCh chan struct{}
+	handlersWG     sync.WaitGroup
+
+	// peerUpdatesWG tracks the goroutine processing queued peer updates, which
+	// sends on the p2p Channels, so that they are only closed once it exits.
+	peerUpdatesWG sync.WaitGroup
+
+	// queuedPeerUpdates buffers the received peer updates until they are
+	// processed. peerUpdateMtx serializes their processing with ResetPeers, so
+	// that updates received before a reset can't be applied after it.
+	queuedPeerUpdates *peerUpdateQueue
+	peerUpdateMtx     tmsync.Mutex
+}
+
+// syncRun is the outcome of a state sync, shared with every caller waiting on
+// it. state and err are only safe to read once doneCh is closed.
+type syncRun struct {
+	doneCh chan struct{}
+	state  sm.State
+	err    error
+}
+
+// SyncResult summarizes the outcome of a successful state sync, so that it can
+// be logged or verified without deriving values from the state.
+type SyncResult struct {
+	ChainID string
+	Height  int64
+	AppHash tmbytes.HexBytes
+
+	// BackfillCompleted is false if backfill failed or stopped early, in which
+	// case the node proceeded with the blocks backfilled so far, or if it was
+	// skipped.
+	BackfillCompleted bool
+
+	// BackfillSkipped is true if backfill wasn't run, as per skip-backfill.
+	BackfillSkipped bool
+
+	// BackfillAttempts is the number of times backfill was run, which is more
+	// than one if it was retried as per backfill-failure-policy.
+	BackfillAttempts int
+
+	// BackfillError is the error backfill failed or stopped early with, if
+	// any. It doesn't fail the sync, but lets callers retry backfill or alert.
+	BackfillError error
+
+	// SnapshotFormat and ChunksApplied are the format of the restored snapshot
+	// and the number of its chunks applied to the app.
+	SnapshotFormat uint32
+	ChunksApplied  uint32
+
+	// BackfillStartHeight and BackfillStopHeight are the heights backfill ran
+	// from and down to, or 0 if it was skipped.
+	BackfillStartHeight int64
+	BackfillStopHeight  int64
+
+	// StateProvider is the kind of state provider used, "p2p" or "rpc".
+	StateProvider string
+
+	// Duration is the time the whole sync took, backfill included.
+	Duration time.Duration
 }
 
 // NewReactor returns a reference to a new state sync reactor, which implements
 // the service.Service interface. It accepts a logger, connections for snapshots
 // and querying, references to p2p Channels and a channel to listen for peer
-// updates on. Note, the reactor will close all p2p Channels when stopping.
+// updates on. Temporary chunk files are stored in a subdirectory of tempDir that
+// is namespaced by the chain and node ID. Note, the reactor will close all p2p
+// Channels when stopping.
 func NewReactor(
 	chainID string,
 	initialHeight int64,
+	nodeID types.NodeID,
 	cfg config.StateSyncConfig,
 	logger log.Logger,
 	conn proxy.AppConnSnapshot,
@@ -176,12 +447,14 @@ func NewReactor(
 	stateStore sm.Store,
 	blockStore *store.BlockStore,
 	tempDir string,
+	metrics *Metrics,
 ) *Reactor {
 	r := &Reactor{
 		chainID:       chainID,
 		initialHeight: initialHeight,
 		cfg:           cfg,
 		conn:          conn,
+		serveConn:     conn,
 		connQuery:     connQuery,
 		snapshotCh:    snapshotCh,
 		chunkCh:       chunkCh,
@@ -189,18 +462,128 @@ func NewReactor(
 		paramsCh:      paramsCh,
 		peerUpdates:   peerUpdates,
 		closeCh:       make(chan struct{}),
-		tempDir:       tempDir,
+		tempDir:       namespacedTempDir(tempDir, cfg.TempDirPrefix, chainID, nodeID),
 		stateStore:    stateStore,
 		blockStore:    blockStore,
 		peers:         newPeerList(),
-		dispatcher:    NewDispatcher(blockCh.Out),
+		dispatcher:    NewDispatcher(blockCh.Out, cfg.LightBlockRequestTimeout),
 		providers:     make(map[types.NodeID]*BlockProvider),
+		metrics:       metrics,
+		serveMonitor:  flowrate.New(0, serveRateWindow),
+		tracer:        nopTracer{},
+
+		providerUpdates:    newPeerUpdateQueue(),
+		queuedPeerUpdates:  newPeerUpdateQueue(),
+		lightBlockMonitors: make(map[types.NodeID]*flowrate.Monitor),
+		peerPanics:         make(map[types.NodeID]int),
+		readAhead:          newChunkReadAhead(maxReadAheadBytes),
+		rejectedFormats:    make(map[uint32]bool),
+		peerVersions:       make(map[types.NodeID]map[p2p.ChannelID]uint32),
+
+		advertisedVersion:  protocolVersion,
+		syncRetryDelay:     minimumDiscoveryTime,
+		backfillRetryDelay: backfillRetryBaseDelay,
+		persistRetryDelay:  persistRetryBaseDelay,
 	}
 
 	r.BaseService = *service.NewBaseService(logger, "StateSync", r)
 	return r
 }
 
+// SetStores sets the state and block stores used by the reactor, for callers
+// whose stores only become available after the reactor is constructed. It
+// returns an error if the reactor has already been started.
+func (r *Reactor) SetStores(stateStore sm.Store, blockStore *store.BlockStore) error {
+	if r.IsRunning() {
+		return errors.New("cannot set stores after the reactor has started")
+	}
+	select {
+	case <-r.closeCh:
+		return errors.New("cannot set stores after the reactor has started")
+	default:
+	}
+
+	r.stateStore = stateStore
+	r.blockStore = blockStore
+	return nil
+}
+
+// SetServeConn sets a separate, read-only snapshot connection to the app used
+// to serve the snapshots and chunks requested by peers, so that serving them
+// doesn't compete with our own sync on the connection given to NewReactor. A
+// nil connection serves them on that connection again. It returns an error if
+// the reactor has already been started.
+func (r *Reactor) SetServeConn(conn proxy.AppConnSnapshot) error {
+	if r.IsRunning() {
+		return errors.New("cannot set serve connection after the reactor has started")
+	}
+	if conn == nil {
+		conn = r.conn
+	}
+
+	r.serveConn = conn
+	return nil
+}
+
+// SetServeSnapshots sets whether the snapshots and chunks requested by peers
+// are served, which they are by default. They aren't when another reactor
+// serves them over a dedicated p2p listener. It returns an error if the
+// reactor has already been started.
+func (r *Reactor) SetServeSnapshots(serve bool) error {
+	if r.IsRunning() {
+		return errors.New("cannot set snapshot serving after the reactor has started")
+	}
+
+	r.serveDisabled = !serve
+	return nil
+}
+
+// SetTracer sets the tracer used to create spans for state sync operations. A
+// nil tracer disables tracing. It returns an error if the reactor has already
+// been started.
+func (r *Reactor) SetTracer(tracer Tracer) error {
+	if r.IsRunning() {
+		return errors.New("cannot set tracer after the reactor has started")
+	}
+	if tracer == nil {
+		tracer = nopTracer{}
+	}
+
+	r.tracer = tracer
+	return nil
+}
+
+// BackfillVerifiedFunc is called with the height and validator set of each
+// block verified during backfill, in descending height order. It is called
+// from the backfill verification loop, so it must not block.
+type BackfillVerifiedFunc func(height int64, validators *types.ValidatorSet)
+
+// SetBackfillVerifiedFunc sets a function to call with every block verified
+// during backfill, allowing external indexers to build the validator set
+// history incrementally. A nil function disables the notifications. It returns
+// an error if the reactor has already been started.
+func (r *Reactor) SetBackfillVerifiedFunc(fn BackfillVerifiedFunc) error {
+	if r.IsRunning() {
+		return errors.New("cannot set backfill verified func after the reactor has started")
+	}
+
+	r.backfillVerified = fn
+	return nil
+}
+
+// SetParamsVerifier sets the function approving the consensus params received
+// by the P2P state provider, on top of the consensus hash check. A nil verifier
+// accepts any params matching the hash. It returns an error if the reactor has
+// already been started.
+func (r *Reactor) SetParamsVerifier(fn ParamsVerifier) error {
+	if r.IsRunning() {
+		return errors.New("cannot set params verifier after the reactor has started")
+	}
+
+	r.verifyParams = fn
+	return nil
+}
+
 // OnStart starts separate go routines for each p2p Channel and listens for
 // envelopes on each. In addition, it also listens for peer updates and handles
 // messages on that p2p channel accordingly. Note, we do not launch a go-routine to
@@ -208,19 +591,108 @@ func NewReactor(
 // The caller must be sure to execute OnStop to ensure the outbound p2p Channels are
 // closed. No error is returned.
 func (r *Reactor) OnStart() error {
-	go r.processSnapshotCh()
+	if r.cfg.VerifyStoreConsistency {
+		if err := r.VerifyStoreConsistency(); err != nil {
+			return err
+		}
+	}
+
+	r.startHandlers()
 
-	go r.processChunkCh()
+	r.peerUpdatesWG.Add(1)
+	go r.processPeerUpdates()
+	go r.processProviderUpdates()
 
-	go r.processBlockCh()
+	return nil
+}
 
-	go r.processParamsCh()
+// VerifyStoreConsistency checks that the height of the block store agrees with
+// the last block height of the state store, returning a descriptive error if
+// it doesn't. The block store may be a block ahead of the state, as the block
+// is saved before it's applied and the handshake replays it, but any other
+// difference is left behind by an interrupted state sync, after which the
+// node must be re-synced rather than start consensus.
+func (r *Reactor) VerifyStoreConsistency() error {
+	state, err := r.stateStore.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
 
-	go r.processPeerUpdates()
+	stateHeight, blockHeight := state.LastBlockHeight, r.blockStore.Height()
+	if blockHeight == stateHeight || blockHeight == stateHeight+1 {
+		return nil
+	}
+	return fmt.Errorf("%w: the block store is at height %d but the state is at height %d; "+
+		"a state sync may have been interrupted, so the node should be re-synced",
+		errInconsistentStores, blockHeight, stateHeight)
+}
 
+// startHandlers spawns the goroutines processing each p2p Channel.
+func (r *Reactor) startHandlers() {
+	r.handlersStopCh = make(chan struct{})
+	r.handlersWG.Add(4)
+
+	go r.processSnapshotCh(r.handlersStopCh)
+
+	go r.processChunkCh(r.handlersStopCh)
+
+	go r.processBlockCh(r.handlersStopCh)
+
+	go r.processParamsCh(r.handlersStopCh)
+}
+
+// Restart applies a new config to the running reactor, such as different
+// timeouts or fetcher counts. Only the goroutines processing the p2p Channels
+// are stopped and restarted, so that the connected peers are retained. The temp
+// dir is not moved, so changes to TempDir and TempDirPrefix are not applied.
+// It returns an error if a state sync, backfill or fetch of light blocks is in
+// progress, as they run with the current config.
+func (r *Reactor) Restart(cfg config.StateSyncConfig) error {
+	if err := cfg.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid state sync config: %w", err)
+	}
+
+	r.restartMtx.Lock()
+	defer r.restartMtx.Unlock()
+
+	if !r.IsRunning() {
+		return errors.New("reactor is not running")
+	}
+
+	// stop processing envelopes so that no handler observes the config change
+	close(r.handlersStopCh)
+	r.handlersWG.Wait()
+	defer r.startHandlers()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.syncer != nil {
+		return errSyncInProgress
+	}
+	if r.operations > 0 {
+		return errOperationInProgress
+	}
+	r.cfg = cfg
+	r.dispatcher.SetTimeout(cfg.LightBlockRequestTimeout)
+
+	r.Logger.Info("restarted state sync reactor with new config")
 	return nil
 }
 
+// beginOperation marks an operation reading cfg as in progress, until the
+// returned function is called.
+func (r *Reactor) beginOperation() func() {
+	r.mtx.Lock()
+	r.operations++
+	r.mtx.Unlock()
+	return func() {
+		r.mtx.Lock()
+		r.operations--
+		r.mtx.Unlock()
+	}
+}
+
 // OnStop stops the reactor by signaling to all spawned goroutines to exit and
 // blocking until they all exit.
 func (r *Reactor) OnStop() {
@@ -231,7 +703,9 @@ func (r *Reactor) OnStop() {
 
 	// Close closeCh to signal to all spawned goroutines to gracefully exit. All
 	// p2p Channels should execute Close().
+	r.restartMtx.Lock()
 	close(r.closeCh)
+	r.restartMtx.Unlock()
 
 	// Wait for all p2p Channels to be closed before returning. This ensures we
 	// can easily reason about synchronization of all p2p Channels and ensure no
@@ -248,23 +722,308 @@ func (r *Reactor) OnStop() {
 // store and persist the commit at that height so that either consensus or
 // blocksync can commence. It will then proceed to backfill the necessary amount
 // of historical blocks before participating in consensus
-func (r *Reactor) Sync(ctx context.Context) (sm.State, error) {
+func (r *Reactor) Sync(ctx context.Context) (state sm.State, err error) {
+	defer r.beginOperation()()
+
+	start := time.Now()
+	ctx, span := r.tracer.Start(ctx, "statesync.sync")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	// Fail right away rather than after waiting for peers if there is no way
+	// to initialize the state provider
+	if !r.cfg.UseP2P && len(r.cfg.RPCServers) == 0 {
+		return sm.State{}, errNoStateProvider
+	}
+
 	// We need at least two peers (for cross-referencing of light blocks) before we can
 	// begin state sync
-	r.waitForEnoughPeers(ctx, 2)
+	if err := r.waitForEnoughPeers(ctx, 2); err != nil {
+		return sm.State{}, err
+	}
+	if err := r.startSyncer(ctx, r.cfg, r.appHeight(ctx)); err != nil {
+		return sm.State{}, err
+	}
+	defer func() { r.stopSyncer(state, err) }()
+
+	state, commit, err := r.syncAny(ctx)
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	// the snapshot has been applied at this point, so transient storage errors
+	// are retried rather than discarding the restored app state
+	err = r.persist(ctx, "bootstrap node with new state", func() error {
+		return r.stateStore.Bootstrap(state)
+	})
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	err = r.persist(ctx, "store last seen commit", func() error {
+		return r.blockStore.SaveSeenCommit(state.LastBlockHeight, commit)
+	})
+	if err != nil {
+		return sm.State{}, err
+	}
+
+	// nodes that only want the verified state bootstrap right away, without
+	// any historical blocks
+	var (
+		backfillAttempts                        int
+		backfillStartHeight, backfillStopHeight int64
+	)
+	if r.cfg.SkipBackfill {
+		r.Logger.Info("skipping backfill")
+	} else {
+		backfillStartHeight = state.LastBlockHeight
+		backfillStopHeight, _ = backfillStop(state)
+		backfillAttempts, err = r.runBackfill(ctx, state)
+		if err != nil {
+			if r.cfg.BackfillFailurePolicy == config.BackfillFailureAbort && !errors.Is(err, errBackfillTimeExceeded) {
+				return sm.State{}, fmt.Errorf("backfill failed: %w", err)
+			}
+			r.Logger.Error("backfill failed. Proceeding optimistically...", "err", err)
+		}
+	}
+
+	result := SyncResult{
+		ChainID:             state.ChainID,
+		Height:              state.LastBlockHeight,
+		AppHash:             state.AppHash,
+		BackfillCompleted:   err == nil && !r.cfg.SkipBackfill,
+		BackfillSkipped:     r.cfg.SkipBackfill,
+		BackfillAttempts:    backfillAttempts,
+		BackfillError:       err,
+		BackfillStartHeight: backfillStartHeight,
+		BackfillStopHeight:  backfillStopHeight,
+		StateProvider:       "rpc",
+		Duration:            time.Since(start),
+	}
+	if r.cfg.UseP2P {
+		result.StateProvider = "p2p"
+	}
+	r.mtx.Lock()
+	r.syncer.mtx.RLock()
+	if selected := r.syncer.selected; selected != nil {
+		result.SnapshotFormat = selected.Format
+		result.ChunksApplied = selected.Chunks
+	}
+	r.syncer.mtx.RUnlock()
+	r.lastSyncResult = &result
+	r.mtx.Unlock()
+	r.Logger.Info("state sync completed", "chainID", result.ChainID, "height", result.Height,
+		"appHash", result.AppHash, "backfillCompleted", result.BackfillCompleted,
+		"backfillSkipped", result.BackfillSkipped, "backfillAttempts", result.BackfillAttempts,
+		"chunksApplied", result.ChunksApplied, "stateProvider", result.StateProvider, "duration", result.Duration)
+
+	return state, nil
+}
+
+// persist runs fn, which persists part of the state restored from a snapshot,
+// retrying it up to persistRetries times with a delay doubling between
+// attempts. Once the retries are exhausted, it returns an error wrapping
+// errPersistFailed.
+func (r *Reactor) persist(ctx context.Context, op string, fn func() error) error {
+	delay := r.persistRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt > persistRetries {
+			return fmt.Errorf("%w: failed to %s after %d attempts: %v", errPersistFailed, op, attempt, err)
+		}
+		r.Logger.Error("failed to persist synced state; retrying", "op", op, "attempt", attempt,
+			"maxAttempts", persistRetries+1, "delay", delay, "err", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: failed to %s: %v", errPersistFailed, op, err)
+		case <-r.closeCh:
+			return fmt.Errorf("%w: failed to %s: %v", errPersistFailed, op, err)
+		}
+		delay *= 2
+	}
+}
+
+// LastSyncResult returns the result of the last successful Sync. It returns
+// false if no Sync has succeeded yet.
+func (r *Reactor) LastSyncResult() (SyncResult, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.lastSyncResult == nil {
+		return SyncResult{}, false
+	}
+	return *r.lastSyncResult, true
+}
+
+// TrustOptions returns the trust options the light client verifying the state
+// of the ongoing or last state sync was initialized with. They are empty if no
+// state sync has been started yet.
+func (r *Reactor) TrustOptions() light.TrustOptions {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return r.trustOptions
+}
+
+// SelectedSnapshot returns the snapshot restored by the last state sync, along
+// with the peers that served it, for auditing what the node restored from. It
+// returns false if no snapshot has been restored yet.
+func (r *Reactor) SelectedSnapshot() (SelectedSnapshot, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.selectedSnapshot == nil {
+		return SelectedSnapshot{}, false
+	}
+	return *r.selectedSnapshot, true
+}
+
+// syncAny runs the snapshot discovery and restoration of the syncer up to
+// MaxSyncAttempts times, until one succeeds. Snapshots are requested from peers
+// again before every retry.
+func (r *Reactor) syncAny(ctx context.Context) (sm.State, *types.Commit, error) {
+	requestSnapshots := r.snapshotRequester(r.cfg, r.syncer.baseHeight)
+	for attempt := 1; ; attempt++ {
+		state, commit, err := r.syncer.SyncAny(ctx, r.cfg.DiscoveryTime, requestSnapshots)
+		if err == nil || ctx.Err() != nil || attempt >= r.cfg.MaxSyncAttempts {
+			return state, commit, err
+		}
+		r.Logger.Error("state sync attempt failed; retrying", "attempt", attempt,
+			"maxAttempts", r.cfg.MaxSyncAttempts, "err", err)
+
+		// when discovery is enabled, SyncAny requests snapshots itself before
+		// waiting for them, otherwise we give peers some time to respond
+		if r.cfg.DiscoveryTime == 0 {
+			requestSnapshots()
+			select {
+			case <-time.After(r.syncRetryDelay):
+			case <-ctx.Done():
+				return sm.State{}, nil, ctx.Err()
+			case <-r.closeCh:
+				return sm.State{}, nil, errors.New("reactor stopped")
+			}
+		}
+	}
+}
+
+// snapshotRequester returns the hook used by the syncer to request snapshots
+// from peers, following the discovery strategy of cfg. The sample strategy
+// requests snapshots from a random sample of the peers that weren't asked yet,
+// doubling the sample size on every call. Once all peers have been asked, the
+// sampling starts over. Delta snapshots are requested on top of baseHeight, if
+// non-zero.
+func (r *Reactor) snapshotRequester(cfg config.StateSyncConfig, baseHeight uint64) func() {
+	if cfg.DiscoveryStrategy != config.DiscoveryStrategySample {
+		return func() {
+			// request snapshots from all currently connected peers
+			r.snapshotCh.Out <- p2p.Envelope{
+				Broadcast: true,
+				Message:   newSnapshotsRequest(baseHeight),
+			}
+		}
+	}
+
+	var (
+		requested  = make(map[types.NodeID]bool)
+		sampleSize = cfg.DiscoverySampleSize
+	)
+	return func() {
+		var candidates []types.NodeID
+		for _, peer := range r.peers.All() {
+			if !requested[peer] {
+				candidates = append(candidates, peer)
+			}
+		}
+		if len(candidates) == 0 && len(requested) > 0 {
+			requested = make(map[types.NodeID]bool)
+			sampleSize = cfg.DiscoverySampleSize
+			candidates = append(candidates, r.peers.All()...)
+		}
+
+		rand.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+		if len(candidates) > sampleSize {
+			candidates = candidates[:sampleSize]
+		}
+
+		r.Logger.Debug("requesting snapshots from a sample of peers", "peers", len(candidates),
+			"sampleSize", sampleSize)
+		for _, peer := range candidates {
+			requested[peer] = true
+			r.snapshotCh.Out <- p2p.Envelope{
+				To:      peer,
+				Message: newSnapshotsRequest(baseHeight),
+			}
+		}
+		sampleSize *= 2
+	}
+}
+
+// SyncOrWait runs a state sync like Sync, unless one is already in progress, in
+// which case it waits for that sync to complete and returns its outcome
+// instead of failing. Any number of callers may wait on the same sync.
+func (r *Reactor) SyncOrWait(ctx context.Context) (sm.State, error) {
+	for {
+		r.mtx.RLock()
+		run := r.run
+		r.mtx.RUnlock()
+
+		if run == nil {
+			state, err := r.Sync(ctx)
+			// another sync may have started while we were waiting for peers
+			if errors.Is(err, errSyncInProgress) {
+				continue
+			}
+			return state, err
+		}
+
+		select {
+		case <-run.doneCh:
+			return run.state, run.err
+		case <-ctx.Done():
+			return sm.State{}, ctx.Err()
+		}
+	}
+}
+
+// startSyncer prepares the temp dir and state provider and creates the syncer
+// used to restore a snapshot, applying delta snapshots on top of the app state
+// at baseHeight if non-zero. The caller must call stopSyncer once done.
+func (r *Reactor) startSyncer(ctx context.Context, cfg config.StateSyncConfig, baseHeight uint64) error {
 	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
 	if r.syncer != nil {
-		r.mtx.Unlock()
-		return sm.State{}, errors.New("a state sync is already in progress")
+		return errSyncInProgress
+	}
+
+	if cfg.RetainAppliedChunks {
+		// chunks applied by a previous sync of the same snapshot are reused
+		if err := os.MkdirAll(r.tempDir, 0700); err != nil {
+			return fmt.Errorf("failed to prepare temp dir: %w", err)
+		}
+	} else if err := r.resetTempDir(); err != nil {
+		return fmt.Errorf("failed to prepare temp dir: %w", err)
 	}
 
 	if err := r.initStateProvider(ctx, r.chainID, r.initialHeight); err != nil {
-		return sm.State{}, err
+		return err
 	}
 
 	r.syncer = newSyncer(
-		r.cfg,
+		cfg,
 		r.Logger,
+		r.tracer,
 		r.conn,
 		r.connQuery,
 		r.stateProvider,
@@ -272,44 +1031,78 @@ func (r *Reactor) Sync(ctx context.Context) (sm.State, error) {
 		r.chunkCh.Out,
 		r.tempDir,
 	)
-	r.mtx.Unlock()
-	defer func() {
-		r.mtx.Lock()
-		// reset syncing objects at the close of Sync
-		r.syncer = nil
-		r.stateProvider = nil
-		r.mtx.Unlock()
-	}()
-
-	requestSnapshotsHook := func() {
-		// request snapshots from all currently connected peers
-		r.snapshotCh.Out <- p2p.Envelope{
-			Broadcast: true,
-			Message:   &ssproto.SnapshotsRequest{},
-		}
+	r.syncer.baseHeight = baseHeight
+	// formats rejected by the app during previous syncs are never offered again
+	r.formatsMtx.RLock()
+	for format := range r.rejectedFormats {
+		r.syncer.snapshots.RejectFormat(format)
 	}
+	r.formatsMtx.RUnlock()
+	r.run = &syncRun{doneCh: make(chan struct{})}
+	return nil
+}
 
-	state, commit, err := r.syncer.SyncAny(ctx, r.cfg.DiscoveryTime, requestSnapshotsHook)
-	if err != nil {
-		return sm.State{}, err
+// stopSyncer resets the syncing objects created by startSyncer, removes the
+// temp dir unless it retains applied chunks of a failed sync, and hands the
+// outcome of the sync to the callers waiting on it.
+func (r *Reactor) stopSyncer(state sm.State, err error) {
+	r.mtx.Lock()
+	r.formatsMtx.Lock()
+	for _, format := range r.syncer.snapshots.RejectedFormats() {
+		r.rejectedFormats[format] = true
 	}
-
-	err = r.stateStore.Bootstrap(state)
-	if err != nil {
-		return sm.State{}, fmt.Errorf("failed to bootstrap node with new state: %w", err)
+	r.formatsMtx.Unlock()
+	r.syncer.mtx.RLock()
+	if r.syncer.selected != nil {
+		r.selectedSnapshot = r.syncer.selected
 	}
+	r.syncer.mtx.RUnlock()
+	// retained chunks are kept for a later sync unless the snapshot was restored
+	cleanup := err == nil || !r.syncer.retainChunks
+	r.syncer = nil
+	r.stateProvider = nil
+	// pending provider updates only apply to the state provider of this sync
+	r.providerUpdates.clear()
+	r.metrics.ProviderQueueDepth.Set(0)
+	run := r.run
+	r.run = nil
+	r.mtx.Unlock()
 
-	err = r.blockStore.SaveSeenCommit(state.LastBlockHeight, commit)
-	if err != nil {
-		return sm.State{}, fmt.Errorf("failed to store last seen commit: %w", err)
+	run.state, run.err = state, err
+	close(run.doneCh)
+
+	if !cleanup {
+		return
 	}
+	if err := r.cleanupTempDir(); err != nil {
+		r.Logger.Error("failed to clean up temp dir", "dir", r.tempDir, "err", err)
+	}
+}
 
-	err = r.Backfill(ctx, state)
-	if err != nil {
-		r.Logger.Error("backfill failed. Proceeding optimistically...", "err", err)
+// Snapshots returns the snapshots discovered by the state sync in progress,
+// along with their acceptance status. It returns an error if no state sync is
+// in progress.
+func (r *Reactor) Snapshots() ([]SnapshotInfo, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.syncer == nil {
+		return nil, errors.New("no state sync in progress")
 	}
+	return r.syncer.Snapshots(), nil
+}
 
-	return state, nil
+// BackfilledBlocks returns the number of blocks verified and stored by the
+// ongoing backfill, or by the last one if none is in progress. It returns 0 if
+// no backfill has been started.
+func (r *Reactor) BackfilledBlocks() int {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if r.backfillQueue == nil {
+		return 0
+	}
+	return r.backfillQueue.Verified()
 }
 
 // Backfill sequentially fetches, verifies and stores light blocks in reverse
@@ -317,6 +1110,41 @@ func (r *Reactor) Sync(ctx context.Context) (sm.State, error) {
 // and time that is less or equal to the stopHeight and stopTime. The
 // trustedBlockID should be of the header at startHeight.
 func (r *Reactor) Backfill(ctx context.Context, state sm.State) error {
+	defer r.beginOperation()()
+
+	stopHeight, stopTime := backfillStop(state)
+
+	ctx, span := r.tracer.Start(ctx, "statesync.backfill",
+		"startHeight", state.LastBlockHeight, "stopHeight", stopHeight)
+	defer span.End()
+
+	err := r.backfill(
+		ctx,
+		state.ChainID,
+		state.LastBlockHeight,
+		stopHeight,
+		state.InitialHeight,
+		state.LastBlockID,
+		stopTime,
+	)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// BackfillStopHeight returns the height down to which Backfill would fetch
+// light blocks for the given state, so that the number of blocks to fetch can
+// be estimated before running it. Backfill may fetch further if the block at
+// this height is more recent than the evidence max age duration.
+func (r *Reactor) BackfillStopHeight(state sm.State) int64 {
+	stopHeight, _ := backfillStop(state)
+	return stopHeight
+}
+
+// backfillStop returns the stop height and stop time of a backfill from the
+// given state, as derived from its evidence params.
+func backfillStop(state sm.State) (int64, time.Time) {
 	params := state.ConsensusParams.Evidence
 	stopHeight := state.LastBlockHeight - params.MaxAgeNumBlocks
 	stopTime := state.LastBlockTime.Add(-params.MaxAgeDuration)
@@ -326,15 +1154,37 @@ func (r *Reactor) Backfill(ctx context.Context, state sm.State) error {
 		// this essentially makes stop time a void criteria for termination
 		stopTime = state.LastBlockTime
 	}
-	return r.backfill(
-		ctx,
-		state.ChainID,
-		state.LastBlockHeight,
-		stopHeight,
-		state.InitialHeight,
-		state.LastBlockID,
-		stopTime,
-	)
+	return stopHeight, stopTime
+}
+
+// runBackfill runs Backfill, running it again after a failure up to
+// BackfillRetries times if the backfill failure policy is to retry, with a
+// delay doubling between attempts. It returns the number of attempts made
+// along with the error of the last one.
+func (r *Reactor) runBackfill(ctx context.Context, state sm.State) (int, error) {
+	retries := 0
+	if r.cfg.BackfillFailurePolicy == config.BackfillFailureRetry {
+		retries = r.cfg.BackfillRetries
+	}
+
+	delay := r.backfillRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := r.Backfill(ctx, state)
+		if err == nil || attempt > retries || errors.Is(err, errBackfillTimeExceeded) || ctx.Err() != nil {
+			return attempt, err
+		}
+		r.Logger.Error("backfill attempt failed; retrying", "attempt", attempt,
+			"maxAttempts", retries+1, "delay", delay, "err", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return attempt, err
+		case <-r.closeCh:
+			return attempt, err
+		}
+		delay *= 2
+	}
 }
 
 func (r *Reactor) backfill(
@@ -347,19 +1197,56 @@ func (r *Reactor) backfill(
 	r.Logger.Info("starting backfill process...", "startHeight", startHeight,
 		"stopHeight", stopHeight, "stopTime", stopTime, "trustedBlockID", trustedBlockID)
 
+	// there is nothing to backfill if the start height is already below the
+	// stop height, e.g. when the state has no blocks past the initial height
+	if startHeight < stopHeight {
+		r.Logger.Info("backfill: no heights to backfill; skipping",
+			"startHeight", startHeight, "stopHeight", stopHeight)
+		return nil
+	}
+
 	const sleepTime = 1 * time.Second
 	var (
-		lastValidatorSet *types.ValidatorSet
-		lastChangeHeight = startHeight
+		lastValidatorSet   *types.ValidatorSet
+		lastChangeHeight   = startHeight
+		lastVerifiedHeight = startHeight
+
+		// the distinct peers that returned a block not matching the trusted
+		// block ID, by height, which are not reported yet
+		mismatches = make(map[int64][]types.NodeID)
+
+		// the time the verifier spends waiting for fetched light blocks
+		idle = newVerifierIdle(time.Now(), verifierIdleWindow)
+
+		// the number of light blocks of the wrong height returned by each
+		// peer, updated by the fetchers
+		wrongHeightsMtx sync.Mutex
+		wrongHeights    = make(map[types.NodeID]int)
 	)
 
-	queue := newBlockQueue(startHeight, stopHeight, initialHeight, stopTime, maxLightBlockRequestRetries)
+	queue := newBlockQueue(startHeight, stopHeight, initialHeight, stopTime, maxLightBlockRequestRetries,
+		r.cfg.BackfillMaxPendingBlocks)
+	r.mtx.Lock()
+	r.backfillQueue = queue
+	r.mtx.Unlock()
+
+	// if a maximum backfill time is configured, we stop at whatever height we
+	// have reached once it elapses
+	var deadlineCh <-chan time.Time
+	if r.cfg.MaxBackfillTime > 0 {
+		deadline := time.NewTimer(r.cfg.MaxBackfillTime)
+		defer deadline.Stop()
+		deadlineCh = deadline.C
+	}
 
 	// fetch light blocks across four workers. The aim with deploying concurrent
 	// workers is to equate the network messaging time with the verification
 	// time. Ideally we want the verification process to never have to be
 	// waiting on blocks. If it takes 4s to retrieve a block and 1s to verify
 	// it, then steady state involves four workers.
+	// the fetchers may outlive backfill by a request, so they don't read the
+	// config once it has returned
+	requestTimeout := r.cfg.BackfillRequestTimeout
 	for i := 0; i < int(r.cfg.Fetchers); i++ {
 		ctxWithCancel, cancel := context.WithCancel(ctx)
 		defer cancel()
@@ -370,18 +1257,18 @@ func (r *Reactor) backfill(
 					// pop the next peer of the list to send a request to
 					peer := r.peers.Pop(ctx)
 					r.Logger.Debug("fetching next block", "height", height, "peer", peer)
-					subCtx, cancel := context.WithTimeout(ctxWithCancel, lightBlockResponseTimeout)
-					defer cancel()
-					lb, err := func() (*types.LightBlock, error) {
-						defer cancel()
-						// request the light block with a timeout
-						return r.dispatcher.LightBlock(subCtx, height, peer)
-					}()
+					// request the light block with a timeout
+					lb, err := r.dispatcher.LightBlockTimeout(ctxWithCancel, height, peer, requestTimeout)
 					// once the peer has returned a value, add it back to the peer list to be used again
 					r.peers.Append(peer)
 					if errors.Is(err, context.Canceled) {
 						return
 					}
+					// a canceled request is no longer needed, so it isn't retried
+					if errors.Is(err, errRequestCanceled) {
+						r.Logger.Debug("backfill: light block request canceled", "height", height, "peer", peer)
+						continue
+					}
 					if err != nil {
 						queue.retry(height)
 						if errors.Is(err, errNoConnectedPeers) {
@@ -404,10 +1291,32 @@ func (r *Reactor) backfill(
 						continue
 					}
 
+					// drop blocks of another height before spending any time on
+					// validating them. Peers may be lagging or racing, so they are
+					// only reported once they do it repeatedly
+					if lb.Height != height {
+						r.metrics.BackfillWrongHeightResponses.Add(1)
+						wrongHeightsMtx.Lock()
+						wrongHeights[peer]++
+						count := wrongHeights[peer]
+						wrongHeightsMtx.Unlock()
+
+						r.Logger.Info("backfill: fetched light block of the wrong height, fetching from another peer",
+							"height", height, "receivedHeight", lb.Height, "peer", peer, "count", count)
+						queue.retry(height)
+						if count >= maxWrongHeightResponses {
+							r.blockCh.Error <- p2p.PeerError{
+								NodeID: peer,
+								Err:    fmt.Errorf("returned %d light blocks of the wrong height", count),
+							}
+						}
+						continue
+					}
+
 					// run a validate basic. This checks the validator set and commit
 					// hashes line up
 					err = lb.ValidateBasic(chainID)
-					if err != nil || lb.Height != height {
+					if err != nil {
 						r.Logger.Info("backfill: fetched light block failed validate basic, removing peer...",
 							"err", err, "height", height)
 						queue.retry(height)
@@ -418,6 +1327,23 @@ func (r *Reactor) backfill(
 						continue
 					}
 
+					// the header is authenticated by the hash chain from the
+					// trusted block, but the commit stored along with it isn't,
+					// so its signatures are checked against the validators
+					if r.cfg.BackfillVerifyCommits {
+						err = lb.ValidatorSet.VerifyCommitLight(chainID, lb.Commit.BlockID, lb.Height, lb.Commit)
+						if err != nil {
+							r.Logger.Info("backfill: fetched light block has an invalid commit, removing peer...",
+								"err", err, "height", height)
+							queue.retry(height)
+							r.blockCh.Error <- p2p.PeerError{
+								NodeID: peer,
+								Err:    fmt.Errorf("received light block with invalid commit: %w", err),
+							}
+							continue
+						}
+					}
+
 					// add block to queue to be verified
 					queue.add(lightBlockResponse{
 						block: lb,
@@ -434,6 +1360,7 @@ func (r *Reactor) backfill(
 
 	// verify all light blocks
 	for {
+		waitStart := time.Now()
 		select {
 		case <-r.closeCh:
 			queue.close()
@@ -442,6 +1369,17 @@ func (r *Reactor) backfill(
 			queue.close()
 			return nil
 		case resp := <-queue.verifyNext():
+			// if the verifier mostly waits on the fetchers, more of them are
+			// needed to keep up with it
+			now := time.Now()
+			waited := now.Sub(waitStart)
+			r.metrics.BackfillVerifierIdleSeconds.Add(waited.Seconds())
+			if percent, ok := idle.add(now, waited); ok &&
+				r.cfg.BackfillIdleWarnPercent > 0 && percent > r.cfg.BackfillIdleWarnPercent {
+				r.Logger.Info("backfill: verifier is mostly idle waiting for light blocks; consider increasing fetchers",
+					"idlePercent", percent, "fetchers", r.cfg.Fetchers, "height", resp.block.Height)
+			}
+
 			// validate the header hash. We take the last block id of the
 			// previous header (i.e. one height above) as the trusted hash which
 			// we equate to. ValidatorsHash and CommitHash have already been
@@ -449,25 +1387,63 @@ func (r *Reactor) backfill(
 			if w, g := trustedBlockID.Hash, resp.block.Hash(); !bytes.Equal(w, g) {
 				r.Logger.Info("received invalid light block. header hash doesn't match trusted LastBlockID",
 					"trustedHash", w, "receivedHash", g, "height", resp.block.Height)
-				r.blockCh.Error <- p2p.PeerError{
-					NodeID: resp.peer,
-					Err:    fmt.Errorf("received invalid light block. Expected hash %v, got: %v", w, g),
+				height := resp.block.Height
+				if !containsPeer(mismatches[height], resp.peer) {
+					mismatches[height] = append(mismatches[height], resp.peer)
+				}
+				// only report the peers once enough of them disagree with the
+				// trusted block ID, otherwise fetch the block from another peer
+				if len(mismatches[height]) >= r.cfg.BackfillMismatchPeers {
+					for _, peer := range mismatches[height] {
+						r.blockCh.Error <- p2p.PeerError{
+							NodeID: peer,
+							Err:    fmt.Errorf("received invalid light block. Expected hash %v, got: %v", w, g),
+						}
+					}
+					delete(mismatches, height)
 				}
-				queue.retry(resp.block.Height)
+				queue.retry(height)
 				continue
 			}
 
+			// confirm the block with other peers before storing it
+			if r.cfg.BackfillCrossCheck {
+				confirmed, err := r.crossCheckLightBlock(ctx, resp)
+				if err != nil {
+					queue.close()
+					return err
+				}
+				if !confirmed {
+					queue.retry(resp.block.Height)
+					continue
+				}
+			}
+
 			// save the signed headers
-			err := r.blockStore.SaveSignedHeader(resp.block.SignedHeader, trustedBlockID)
+			err := r.saveSignedHeader(resp.block.SignedHeader, trustedBlockID)
 			if err != nil {
+				// stop the fetchers, so that a retried backfill doesn't leave
+				// them behind
+				queue.close()
 				return err
 			}
 
+			// periodically persist a checkpoint so that the range backfilled so
+			// far can be verified independently
+			if interval := r.cfg.BackfillCheckpointInterval; interval > 0 && resp.block.Height%interval == 0 {
+				if err := r.blockStore.SaveBackfillCheckpoint(resp.block); err != nil {
+					queue.close()
+					return fmt.Errorf("failed to save backfill checkpoint: %w", err)
+				}
+				r.Logger.Debug("backfill: saved checkpoint", "height", resp.block.Height)
+			}
+
 			// check if there has been a change in the validator set
 			if lastValidatorSet != nil && !bytes.Equal(resp.block.Header.ValidatorsHash, resp.block.Header.NextValidatorsHash) {
 				// save all the heights that the last validator set was the same
 				err = r.stateStore.SaveValidatorSets(resp.block.Height+1, lastChangeHeight, lastValidatorSet)
 				if err != nil {
+					queue.close()
 					return err
 				}
 
@@ -476,35 +1452,427 @@ func (r *Reactor) backfill(
 			}
 
 			trustedBlockID = resp.block.LastBlockID
+			delete(mismatches, resp.block.Height)
 			queue.success(resp.block.Height)
 			r.Logger.Info("backfill: verified and stored light block", "height", resp.block.Height)
+			if r.backfillVerified != nil {
+				r.backfillVerified(resp.block.Height, resp.block.ValidatorSet)
+			}
 
 			lastValidatorSet = resp.block.ValidatorSet
+			lastVerifiedHeight = resp.block.Height
+
+		case <-deadlineCh:
+			queue.close()
+
+			// save the validators of the heights that were verified so far
+			if lastValidatorSet != nil {
+				if err := r.stateStore.SaveValidatorSets(lastVerifiedHeight, lastChangeHeight, lastValidatorSet); err != nil {
+					return err
+				}
+			}
+
+			r.Logger.Info("backfill: time limit exceeded; stopping early",
+				"maxBackfillTime", r.cfg.MaxBackfillTime, "heightReached", lastVerifiedHeight)
+			return fmt.Errorf("%w (%v); target height: %d, height reached: %d",
+				errBackfillTimeExceeded, r.cfg.MaxBackfillTime, stopHeight, lastVerifiedHeight)
 
 		case <-queue.done():
 			if err := queue.error(); err != nil {
 				return err
 			}
 
+			// the queue may be closed before any light block was verified, in
+			// which case there are no validators to save
+			if lastValidatorSet == nil || queue.terminal == nil {
+				return errors.New("backfill stopped before any light block was verified")
+			}
+
 			// save the final batch of validators
 			if err := r.stateStore.SaveValidatorSets(queue.terminal.Height, lastChangeHeight, lastValidatorSet); err != nil {
 				return err
 			}
 
+			if err := checkStopTime(queue.terminal, stopHeight, initialHeight, stopTime); err != nil {
+				r.Logger.Error("backfill: terminal block time is inconsistent with the stop time; "+
+					"block timestamps may have been manipulated", "err", err)
+			}
+
 			r.Logger.Info("successfully completed backfill process", "endHeight", queue.terminal.Height)
 			return nil
 		}
 	}
 }
 
+// verifierIdle measures the share of time the backfill verifier spends idle,
+// over consecutive windows of a fixed duration.
+type verifierIdle struct {
+	window      time.Duration
+	windowStart time.Time
+	idle        time.Duration
+}
+
+func newVerifierIdle(start time.Time, window time.Duration) *verifierIdle {
+	return &verifierIdle{window: window, windowStart: start}
+}
+
+// add records that the verifier was idle for d until now. Once the current
+// window is over, it returns the percentage of the window spent idle along
+// with true, and starts a new window.
+func (v *verifierIdle) add(now time.Time, d time.Duration) (int, bool) {
+	v.idle += d
+	elapsed := now.Sub(v.windowStart)
+	if elapsed < v.window || elapsed <= 0 {
+		return 0, false
+	}
+
+	percent := int(v.idle * 100 / elapsed)
+	v.windowStart = now
+	v.idle = 0
+	return percent, true
+}
+
+// containsPeer returns true if peers contains peer.
+func containsPeer(peers []types.NodeID, peer types.NodeID) bool {
+	for _, p := range peers {
+		if p == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// saveSignedHeader stores the signed header verified during backfill, unless
+// the block store already has one at that height, for instance from a
+// previous backfill or a duplicate response. An existing header is only
+// accepted if it is identical to the one being saved.
+func (r *Reactor) saveSignedHeader(sh *types.SignedHeader, blockID types.BlockID) error {
+	if meta := r.blockStore.LoadBlockMeta(sh.Height); meta != nil {
+		if w, g := meta.Header.Hash(), sh.Hash(); !bytes.Equal(w, g) {
+			return fmt.Errorf("conflicting header at height %d already saved: expected hash %v, got %v",
+				sh.Height, w, g)
+		}
+		r.Logger.Debug("backfill: light block already stored; skipping", "height", sh.Height)
+		return nil
+	}
+
+	return r.blockStore.SaveSignedHeader(sh, blockID)
+}
+
+// crossCheckLightBlock fetches the light block of resp again from up to two
+// other peers at once, taking them out of the peers rotation for the duration
+// of their requests, and looks for two byte-identical responses among them and
+// resp. Once a majority is found, the request still pending, if any, is
+// canceled instead of waited for. Peers whose response disagrees with this
+// majority are reported. It returns true only if the majority agrees with
+// resp; otherwise the height
+// should be fetched again, after waiting BackfillCrossCheckWait if no majority
+// was found. It returns errCrossCheckPeers if fewer than two peers are
+// connected.
+func (r *Reactor) crossCheckLightBlock(ctx context.Context, resp lightBlockResponse) (bool, error) {
+	type response struct {
+		peer types.NodeID
+		bz   []byte
+	}
+
+	if r.numConnectedPeers() < 2 {
+		return false, errCrossCheckPeers
+	}
+
+	bz, err := lightBlockBytes(resp.block)
+	if err != nil {
+		r.Logger.Error("backfill: failed to encode light block", "height", resp.block.Height, "err", err)
+		return false, nil
+	}
+	responses := []response{{peer: resp.peer, bz: bz}}
+
+	peers := r.peers.Take(2, resp.peer)
+	responseCh := make(chan response, len(peers))
+	for _, peer := range peers {
+		go func(peer types.NodeID) {
+			lb, err := r.dispatcher.LightBlockTimeout(ctx, resp.block.Height, peer, r.cfg.BackfillRequestTimeout)
+			r.peers.Append(peer)
+			if err != nil || lb == nil {
+				r.Logger.Debug("backfill: peer failed to provide light block for cross-check",
+					"height", resp.block.Height, "peer", peer, "err", err)
+				responseCh <- response{peer: peer}
+				return
+			}
+			bz, err := lightBlockBytes(lb)
+			if err != nil {
+				responseCh <- response{peer: peer}
+				return
+			}
+			responseCh <- response{peer: peer, bz: bz}
+		}(peer)
+	}
+
+	// look for two identical responses, which are a majority of the at most
+	// three responses compared
+	var majority []byte
+	pending := make(map[types.NodeID]bool, len(peers))
+	for _, peer := range peers {
+		pending[peer] = true
+	}
+	for range peers {
+		res := <-responseCh
+		delete(pending, res.peer)
+		if res.bz == nil {
+			continue
+		}
+		for _, other := range responses {
+			if majority == nil && bytes.Equal(res.bz, other.bz) {
+				majority = res.bz
+				// the light block is settled, so the remaining response
+				// can't change the outcome
+				for peer := range pending {
+					r.dispatcher.Cancel(resp.block.Height, peer)
+				}
+			}
+		}
+		responses = append(responses, res)
+	}
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	if majority == nil {
+		r.Logger.Info("backfill: no two peers agree on light block; fetching again",
+			"height", resp.block.Height, "responses", len(responses))
+		select {
+		case <-time.After(r.cfg.BackfillCrossCheckWait):
+		case <-ctx.Done():
+		}
+		return false, nil
+	}
+
+	for _, res := range responses {
+		if !bytes.Equal(res.bz, majority) {
+			r.Logger.Info("backfill: light block disagrees with the majority of peers",
+				"height", resp.block.Height, "peer", res.peer)
+			r.blockCh.Error <- p2p.PeerError{
+				NodeID: res.peer,
+				Err: fmt.Errorf("light block at height %d disagrees with other peers",
+					resp.block.Height),
+			}
+		}
+	}
+	return bytes.Equal(majority, responses[0].bz), nil
+}
+
+// numConnectedPeers returns the number of peers connected to the reactor.
+func (r *Reactor) numConnectedPeers() int {
+	r.connectedMtx.Lock()
+	defer r.connectedMtx.Unlock()
+	return len(r.connected)
+}
+
+// lightBlockBytes returns the protobuf encoding of lb.
+func lightBlockBytes(lb *types.LightBlock) ([]byte, error) {
+	pb, err := lb.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return pb.Marshal()
+}
+
+// checkStopTime checks that the time of the block that terminated backfill is
+// consistent with stopTime. When backfill stops below stopHeight, it is the
+// time criterion that terminated it, so the terminal block is the first one
+// before stopTime and should be close to it. A terminal block much older than
+// that means the blocks above it carried skewed timestamps.
+func checkStopTime(terminal *types.LightBlock, stopHeight, initialHeight int64, stopTime time.Time) error {
+	if terminal.Height == initialHeight || terminal.Height >= stopHeight {
+		return nil
+	}
+	if skew := stopTime.Sub(terminal.Time); skew > backfillStopTimeTolerance {
+		return fmt.Errorf("terminal block at height %d is %v older than the stop time %v (tolerance %v)",
+			terminal.Height, skew, stopTime, backfillStopTimeTolerance)
+	}
+	return nil
+}
+
+// VerifyBackfillCheckpoints validates the checkpoints persisted during
+// backfill. Every checkpoint must carry a commit signed by +2/3 of its
+// validator set, and the headers stored between two consecutive checkpoints
+// must form an unbroken hash chain linking them together.
+func (r *Reactor) VerifyBackfillCheckpoints() error {
+	checkpoints, err := r.blockStore.LoadBackfillCheckpoints()
+	if err != nil {
+		return fmt.Errorf("failed to load backfill checkpoints: %w", err)
+	}
+
+	for i, cp := range checkpoints {
+		if err := cp.ValidateBasic(r.chainID); err != nil {
+			return fmt.Errorf("invalid checkpoint at height %d: %w", cp.Height, err)
+		}
+		blockID := types.BlockID{Hash: cp.Hash(), PartSetHeader: cp.Commit.BlockID.PartSetHeader}
+		if err := cp.ValidatorSet.VerifyCommitLight(r.chainID, blockID, cp.Height, cp.Commit); err != nil {
+			return fmt.Errorf("invalid commit for checkpoint at height %d: %w", cp.Height, err)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		// walk down the stored headers from this checkpoint to the previous
+		// one, checking that each header hashes to the LastBlockID of the
+		// header above it
+		prev := checkpoints[i-1]
+		trustedHash := cp.LastBlockID.Hash
+		for height := cp.Height - 1; height > prev.Height; height-- {
+			meta := r.blockStore.LoadBlockMeta(height)
+			if meta == nil {
+				return fmt.Errorf("missing header at height %d between checkpoints %d and %d",
+					height, prev.Height, cp.Height)
+			}
+			if !bytes.Equal(trustedHash, meta.Header.Hash()) {
+				return fmt.Errorf("header at height %d does not link to checkpoint at height %d",
+					height, cp.Height)
+			}
+			trustedHash = meta.Header.LastBlockID.Hash
+		}
+
+		if !bytes.Equal(trustedHash, prev.Hash()) {
+			return fmt.Errorf("checkpoint at height %d does not link to checkpoint at height %d",
+				prev.Height, cp.Height)
+		}
+	}
+
+	return nil
+}
+
+// FetchHeights fetches, verifies and stores the light blocks at the given
+// heights, e.g. to recover heights missing from the block store. Every block
+// must link to an adjacent header already stored: its hash must match the
+// LastBlockID of the header above it or, failing that, its LastBlockID must
+// match the hash of the header below it, in which case its commit must also be
+// signed by the next validators of that header. Heights are processed from the
+// highest down, so that a run of missing heights can be recovered as long as
+// the header above it is stored. It returns the outcome of each height, which
+// is nil if its light block is stored.
+func (r *Reactor) FetchHeights(ctx context.Context, heights []int64) map[int64]error {
+	defer r.beginOperation()()
+
+	sorted := make([]int64, len(heights))
+	copy(sorted, heights)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	results := make(map[int64]error, len(sorted))
+	for _, height := range sorted {
+		if _, ok := results[height]; ok {
+			continue
+		}
+		results[height] = r.fetchHeight(ctx, height)
+		if err := results[height]; err != nil {
+			r.Logger.Info("failed to fetch light block", "height", height, "err", err)
+		}
+	}
+	return results
+}
+
+// fetchHeight fetches the light block at the given height from up to
+// fetchHeightAttempts peers, until one of them serves a block linking to an
+// adjacent stored header, and stores it.
+func (r *Reactor) fetchHeight(ctx context.Context, height int64) error {
+	if height <= 0 {
+		return fmt.Errorf("invalid height %d", height)
+	}
+	if r.blockStore.LoadBlockMeta(height) != nil {
+		return nil
+	}
+	above := r.blockStore.LoadBlockMeta(height + 1)
+	below := r.blockStore.LoadBlockMeta(height - 1)
+	if above == nil && below == nil {
+		return errors.New("no adjacent header is stored to verify against")
+	}
+
+	var err error
+	for attempt := 0; attempt < fetchHeightAttempts; attempt++ {
+		peer := r.peers.Pop(ctx)
+		if peer == "" {
+			return ctx.Err()
+		}
+		var lb *types.LightBlock
+		lb, err = r.dispatcher.LightBlockTimeout(ctx, height, peer, r.cfg.BackfillRequestTimeout)
+		r.peers.Append(peer)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+
+		var blockID types.BlockID
+		blockID, err = r.verifyFetchedLightBlock(lb, height, above, below)
+		if err != nil {
+			r.Logger.Debug("fetched invalid light block", "height", height, "peer", peer, "err", err)
+			continue
+		}
+
+		if err := r.blockStore.SaveSignedHeader(lb.SignedHeader, blockID); err != nil {
+			return fmt.Errorf("failed to save signed header: %w", err)
+		}
+		if err := r.stateStore.SaveValidatorSets(height, height, lb.ValidatorSet); err != nil {
+			return fmt.Errorf("failed to save validator set: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no valid light block received after %d attempts: %w", fetchHeightAttempts, err)
+}
+
+// verifyFetchedLightBlock checks that lb is a valid light block at the given
+// height linking to the adjacent header above or below it, at least one of
+// which is not nil. It returns the block ID to store the block under.
+func (r *Reactor) verifyFetchedLightBlock(
+	lb *types.LightBlock,
+	height int64,
+	above, below *types.BlockMeta,
+) (types.BlockID, error) {
+	if lb == nil {
+		return types.BlockID{}, errors.New("peer doesn't have the light block")
+	}
+	if lb.Height != height {
+		return types.BlockID{}, fmt.Errorf("expected light block at height %d, got %d", height, lb.Height)
+	}
+	if err := lb.ValidateBasic(r.chainID); err != nil {
+		return types.BlockID{}, err
+	}
+
+	if above != nil {
+		if w, g := above.Header.LastBlockID.Hash, lb.Hash(); !bytes.Equal(w, g) {
+			return types.BlockID{}, fmt.Errorf("header hash %v doesn't match the last block ID %v of the header above",
+				g, w)
+		}
+		return above.Header.LastBlockID, nil
+	}
+
+	if w, g := below.Header.Hash(), lb.LastBlockID.Hash; !bytes.Equal(w, g) {
+		return types.BlockID{}, fmt.Errorf("last block ID %v doesn't match the hash %v of the header below", g, w)
+	}
+	if w, g := below.Header.NextValidatorsHash, lb.ValidatorsHash; !bytes.Equal(w, g) {
+		return types.BlockID{}, fmt.Errorf("validators hash %v doesn't match the next validators hash %v of the header below",
+			g, w)
+	}
+	if err := lb.ValidatorSet.VerifyCommitLight(r.chainID, lb.Commit.BlockID, height, lb.Commit); err != nil {
+		return types.BlockID{}, fmt.Errorf("invalid commit: %w", err)
+	}
+	return lb.Commit.BlockID, nil
+}
+
 // handleSnapshotMessage handles envelopes sent from peers on the
 // SnapshotChannel. It returns an error only if the Envelope.Message is unknown
-// for this channel. This should never be called outside of handleMessage.
+// for this channel or carries metadata that can't be decompressed. This should
+// never be called outside of handleMessage.
 func (r *Reactor) handleSnapshotMessage(envelope p2p.Envelope) error {
 	logger := r.Logger.With("peer", envelope.From)
 
 	switch msg := envelope.Message.(type) {
 	case *ssproto.SnapshotsRequest:
+		if r.serveDisabled {
+			logger.Debug("ignoring snapshots request; snapshots are served over a dedicated listener")
+			return nil
+		}
+
 		snapshots, err := r.recentSnapshots(recentSnapshots)
 		if err != nil {
 			logger.Error("failed to fetch snapshots", "err", err)
@@ -512,22 +1880,43 @@ func (r *Reactor) handleSnapshotMessage(envelope p2p.Envelope) error {
 		}
 
 		for _, snapshot := range snapshots {
+			// only advertise the delta snapshots that apply to the peer's state
+			if snapshot.BaseHeight != 0 && snapshot.BaseHeight != msg.BaseHeight {
+				continue
+			}
+
 			logger.Info(
 				"advertising snapshot",
 				"height", snapshot.Height,
 				"format", snapshot.Format,
+				"baseHeight", snapshot.BaseHeight,
 				"peer", envelope.From,
 			)
-			r.snapshotCh.Out <- p2p.Envelope{
+
+			// only compress the metadata for peers that support it, and only
+			// if it's actually smaller
+			metadata, compressed := snapshot.Metadata, false
+			if r.cfg.CompressSnapshotMetadata && msg.AcceptCompressedMetadata && len(metadata) > 0 {
+				c, err := compressMetadata(metadata)
+				if err != nil {
+					logger.Error("failed to compress snapshot metadata", "height", snapshot.Height, "err", err)
+				} else if len(c) < len(metadata) {
+					metadata, compressed = c, true
+				}
+			}
+
+			r.send(r.snapshotCh, p2p.Envelope{
 				To: envelope.From,
 				Message: &ssproto.SnapshotsResponse{
-					Height:   snapshot.Height,
-					Format:   snapshot.Format,
-					Chunks:   snapshot.Chunks,
-					Hash:     snapshot.Hash,
-					Metadata: snapshot.Metadata,
+					Height:             snapshot.Height,
+					Format:             snapshot.Format,
+					Chunks:             snapshot.Chunks,
+					Hash:               snapshot.Hash,
+					Metadata:           metadata,
+					MetadataCompressed: compressed,
+					BaseHeight:         snapshot.BaseHeight,
 				},
-			}
+			})
 		}
 
 	case *ssproto.SnapshotsResponse:
@@ -540,12 +1929,34 @@ func (r *Reactor) handleSnapshotMessage(envelope p2p.Envelope) error {
 		}
 
 		logger.Info("received snapshot", "height", msg.Height, "format", msg.Format)
+		if r.formatRejected(msg.Format) {
+			logger.Debug("ignoring snapshot; format rejected by the app", "height", msg.Height,
+				"format", msg.Format)
+			return nil
+		}
+		// a snapshot without chunks can't restore any state, so it is always
+		// invalid
+		if msg.Chunks == 0 {
+			logger.Info("received snapshot with no chunks", "height", msg.Height, "format", msg.Format)
+			return fmt.Errorf("snapshot at height %d with format %d has no chunks", msg.Height, msg.Format)
+		}
+
+		metadata := msg.Metadata
+		if msg.MetadataCompressed {
+			var err error
+			metadata, err = decompressMetadata(msg.Metadata)
+			if err != nil {
+				return fmt.Errorf("invalid compressed snapshot metadata: %w", err)
+			}
+		}
+
 		_, err := r.syncer.AddSnapshot(envelope.From, &snapshot{
-			Height:   msg.Height,
-			Format:   msg.Format,
-			Chunks:   msg.Chunks,
-			Hash:     msg.Hash,
-			Metadata: msg.Metadata,
+			Height:     msg.Height,
+			Format:     msg.Format,
+			Chunks:     msg.Chunks,
+			Hash:       msg.Hash,
+			Metadata:   metadata,
+			BaseHeight: msg.BaseHeight,
 		})
 		if err != nil {
 			logger.Error(
@@ -560,14 +1971,15 @@ func (r *Reactor) handleSnapshotMessage(envelope p2p.Envelope) error {
 		logger.Info("added snapshot", "height", msg.Height, "format", msg.Format)
 
 	default:
-		return fmt.Errorf("received unknown message: %T", msg)
+		return r.handleUnknownMessage(SnapshotChannel, envelope)
 	}
 
 	return nil
 }
 
 // handleChunkMessage handles envelopes sent from peers on the ChunkChannel.
-// It returns an error only if the Envelope.Message is unknown for this channel.
+// It returns an error only if the Envelope.Message is unknown for this channel,
+// or carries a chunk larger than chunkMsgSize or not matching its checksum.
 // This should never be called outside of handleMessage.
 func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 	switch msg := envelope.Message.(type) {
@@ -579,11 +1991,43 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 			"chunk", msg.Index,
 			"peer", envelope.From,
 		)
-		resp, err := r.conn.LoadSnapshotChunkSync(context.Background(), abci.RequestLoadSnapshotChunk{
-			Height: msg.Height,
-			Format: msg.Format,
-			Chunk:  msg.Index,
-		})
+		if r.serveDisabled {
+			r.Logger.Debug("ignoring chunk request; chunks are served over a dedicated listener",
+				"peer", envelope.From)
+			return nil
+		}
+		if !r.servesFormat(msg.Format) || !r.appTakesFormat(msg.Format) {
+			r.Logger.Debug(
+				"rejecting chunk request; snapshot format isn't served",
+				"height", msg.Height,
+				"format", msg.Format,
+				"chunk", msg.Index,
+				"peer", envelope.From,
+			)
+			r.send(r.chunkCh, p2p.Envelope{
+				To: envelope.From,
+				Message: &ssproto.ChunkResponse{
+					Height:     msg.Height,
+					Format:     msg.Format,
+					Index:      msg.Index,
+					Missing:    true,
+					BaseHeight: msg.BaseHeight,
+				},
+			})
+			return nil
+		}
+		if r.throttleServing() {
+			r.Logger.Debug(
+				"ignoring chunk request; serve rate exceeded while syncing",
+				"height", msg.Height,
+				"format", msg.Format,
+				"chunk", msg.Index,
+				"peer", envelope.From,
+			)
+			return nil
+		}
+
+		resp, err := r.loadServedChunk(envelope.From, msg)
 		if err != nil {
 			r.Logger.Error(
 				"failed to load chunk",
@@ -596,6 +2040,23 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 			return nil
 		}
 
+		chunkResp := &ssproto.ChunkResponse{
+			Height:     msg.Height,
+			Format:     msg.Format,
+			Index:      msg.Index,
+			Chunk:      resp.Chunk,
+			Missing:    resp.Chunk == nil,
+			BaseHeight: msg.BaseHeight,
+		}
+		if algorithm := r.cfg.ChunkChecksumAlgorithm; algorithm != "" && resp.Chunk != nil {
+			chunkResp.Checksum, err = chunkChecksum(algorithm, resp.Chunk)
+			if err != nil {
+				r.Logger.Error("failed to checksum chunk", "chunk", msg.Index, "err", err)
+				return nil
+			}
+			chunkResp.ChecksumAlgorithm = algorithm
+		}
+
 		r.Logger.Debug(
 			"sending chunk",
 			"height", msg.Height,
@@ -603,18 +2064,35 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 			"chunk", msg.Index,
 			"peer", envelope.From,
 		)
-		r.chunkCh.Out <- p2p.Envelope{
-			To: envelope.From,
-			Message: &ssproto.ChunkResponse{
-				Height:  msg.Height,
-				Format:  msg.Format,
-				Index:   msg.Index,
-				Chunk:   resp.Chunk,
-				Missing: resp.Chunk == nil,
-			},
+		r.send(r.chunkCh, p2p.Envelope{
+			To:      envelope.From,
+			Message: chunkResp,
+		})
+		r.serveMonitor.Update(len(resp.Chunk))
+
+		if r.cfg.ChunkReadAhead > 0 && resp.Chunk != nil {
+			r.readAheadChunks(envelope.From, msg)
 		}
 
 	case *ssproto.ChunkResponse:
+		// don't rely solely on the transport to bound the size of the chunks
+		// buffered by the syncer
+		if len(msg.Chunk) > chunkMsgSize {
+			return fmt.Errorf("received chunk of %d bytes, exceeding the maximum of %d bytes",
+				len(msg.Chunk), chunkMsgSize)
+		}
+
+		// verify the checksum of the chunk, if the peer provided one
+		if len(msg.Checksum) > 0 {
+			checksum, err := chunkChecksum(msg.ChecksumAlgorithm, msg.Chunk)
+			if err != nil {
+				return fmt.Errorf("invalid chunk checksum: %w", err)
+			}
+			if !bytes.Equal(checksum, msg.Checksum) {
+				return fmt.Errorf("chunk %d checksum mismatch: expected %X, got %X", msg.Index, msg.Checksum, checksum)
+			}
+		}
+
 		r.mtx.RLock()
 		defer r.mtx.RUnlock()
 
@@ -622,6 +2100,11 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 			r.Logger.Debug("received unexpected chunk; no state sync in progress", "peer", envelope.From)
 			return nil
 		}
+		if r.formatRejected(msg.Format) {
+			r.Logger.Debug("ignoring chunk; format rejected by the app", "height", msg.Height,
+				"format", msg.Format, "chunk", msg.Index, "peer", envelope.From)
+			return nil
+		}
 
 		r.Logger.Debug(
 			"received chunk; adding to sync",
@@ -631,11 +2114,12 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 			"peer", envelope.From,
 		)
 		_, err := r.syncer.AddChunk(&chunk{
-			Height: msg.Height,
-			Format: msg.Format,
-			Index:  msg.Index,
-			Chunk:  msg.Chunk,
-			Sender: envelope.From,
+			Height:     msg.Height,
+			Format:     msg.Format,
+			Index:      msg.Index,
+			Chunk:      msg.Chunk,
+			Sender:     envelope.From,
+			BaseHeight: msg.BaseHeight,
 		})
 		if err != nil {
 			r.Logger.Error(
@@ -650,28 +2134,87 @@ func (r *Reactor) handleChunkMessage(envelope p2p.Envelope) error {
 		}
 
 	default:
-		return fmt.Errorf("received unknown message: %T", msg)
+		return r.handleUnknownMessage(ChunkChannel, envelope)
 	}
 
 	return nil
 }
 
+// loadServedChunk returns the chunk requested by a peer, from the chunks read
+// ahead for the peer if there, or else loaded from the app.
+func (r *Reactor) loadServedChunk(
+	peer types.NodeID,
+	msg *ssproto.ChunkRequest,
+) (*abci.ResponseLoadSnapshotChunk, error) {
+	if r.readAhead != nil {
+		chunk, ok := r.readAhead.take(peer, msg.Height, msg.Format, msg.BaseHeight, msg.Index)
+		if ok {
+			return &abci.ResponseLoadSnapshotChunk{Chunk: chunk}, nil
+		}
+	}
+
+	return r.serveConn.LoadSnapshotChunkSync(context.Background(), abci.RequestLoadSnapshotChunk{
+		Height:     msg.Height,
+		Format:     msg.Format,
+		Chunk:      msg.Index,
+		BaseHeight: msg.BaseHeight,
+	})
+}
+
+// readAheadChunks loads the ChunkReadAhead chunks following the one requested
+// by a peer from the app in the background, so that the peer's next requests
+// of the snapshot's chunks are served without waiting on the app. Reading
+// ahead is bounded by readAheadTimeout, and by maxReadAheadBytes across peers.
+func (r *Reactor) readAheadChunks(peer types.NodeID, msg *ssproto.ChunkRequest) {
+	if r.readAhead == nil {
+		return
+	}
+
+	window := uint32(r.cfg.ChunkReadAhead)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), readAheadTimeout)
+		defer cancel()
+
+		r.readAhead.fill(peer, msg.Height, msg.Format, msg.BaseHeight, msg.Index, window,
+			func(index uint32) ([]byte, error) {
+				resp, err := r.serveConn.LoadSnapshotChunkSync(ctx, abci.RequestLoadSnapshotChunk{
+					Height:     msg.Height,
+					Format:     msg.Format,
+					Chunk:      index,
+					BaseHeight: msg.BaseHeight,
+				})
+				if err != nil {
+					r.Logger.Debug("failed to read ahead chunk", "height", msg.Height, "format", msg.Format,
+						"chunk", index, "err", err, "peer", peer)
+					return nil, err
+				}
+				return resp.Chunk, nil
+			})
+	}()
+}
+
 func (r *Reactor) handleLightBlockMessage(envelope p2p.Envelope) error {
 	switch msg := envelope.Message.(type) {
 	case *ssproto.LightBlockRequest:
 		r.Logger.Info("received light block request", "height", msg.Height)
+		if !r.allowLightBlockRequest(envelope.From) {
+			r.Logger.Debug("dropping light block request; serve rate limit exceeded",
+				"height", msg.Height, "peer", envelope.From)
+			return nil
+		}
+
 		lb, err := r.fetchLightBlock(msg.Height)
 		if err != nil {
 			r.Logger.Error("failed to retrieve light block", "err", err, "height", msg.Height)
 			return err
 		}
 		if lb == nil {
-			r.blockCh.Out <- p2p.Envelope{
+			r.send(r.blockCh, p2p.Envelope{
 				To: envelope.From,
 				Message: &ssproto.LightBlockResponse{
 					LightBlock: nil,
 				},
-			}
+			})
 			return nil
 		}
 
@@ -683,12 +2226,12 @@ func (r *Reactor) handleLightBlockMessage(envelope p2p.Envelope) error {
 
 		// NOTE: If we don't have the light block we will send a nil light block
 		// back to the requested node, indicating that we don't have it.
-		r.blockCh.Out <- p2p.Envelope{
+		r.send(r.blockCh, p2p.Envelope{
 			To: envelope.From,
 			Message: &ssproto.LightBlockResponse{
 				LightBlock: lbproto,
 			},
-		}
+		})
 
 	case *ssproto.LightBlockResponse:
 		var height int64 = 0
@@ -696,12 +2239,24 @@ func (r *Reactor) handleLightBlockMessage(envelope p2p.Envelope) error {
 			height = msg.LightBlock.SignedHeader.Header.Height
 		}
 		r.Logger.Info("received light block response", "peer", envelope.From, "height", height)
+
+		// light blocks of another chain are rejected before reaching backfill
+		// or the state provider, whose pending request gets no light block
+		if msg.LightBlock != nil && msg.LightBlock.SignedHeader.Header.ChainID != r.chainID {
+			chainID := msg.LightBlock.SignedHeader.Header.ChainID
+			if err := r.dispatcher.Respond(nil, envelope.From); err != nil {
+				r.Logger.Debug("error processing light block response", "err", err, "height", height)
+			}
+			return fmt.Errorf("received light block at height %d with chain ID %q, expected %q",
+				height, chainID, r.chainID)
+		}
+
 		if err := r.dispatcher.Respond(msg.LightBlock, envelope.From); err != nil {
 			r.Logger.Error("error processing light block response", "err", err, "height", height)
 		}
 
 	default:
-		return fmt.Errorf("received unknown message: %T", msg)
+		return r.handleUnknownMessage(LightBlockChannel, envelope)
 	}
 
 	return nil
@@ -718,37 +2273,170 @@ func (r *Reactor) handleParamsMessage(envelope p2p.Envelope) error {
 		}
 
 		cpproto := cp.ToProto()
-		r.paramsCh.Out <- p2p.Envelope{
+		r.send(r.paramsCh, p2p.Envelope{
 			To: envelope.From,
 			Message: &ssproto.ParamsResponse{
 				Height:          msg.Height,
 				ConsensusParams: cpproto,
 			},
-		}
+		})
 
 	case *ssproto.ParamsResponse:
 		r.mtx.RLock()
 		defer r.mtx.RUnlock()
 		r.Logger.Debug("received consensus params response", "height", msg.Height)
 
+		// malformed params are rejected with a peer error, so that they never
+		// reach the state provider
 		cp := types.ConsensusParamsFromProto(msg.ConsensusParams)
+		if err := cp.ValidateConsensusParams(); err != nil {
+			return fmt.Errorf("received invalid consensus params at height %d: %w", msg.Height, err)
+		}
 
-		if sp, ok := r.stateProvider.(*stateProviderP2P); ok {
-			select {
-			case sp.paramsRecvCh <- cp:
-			default:
-			}
-		} else {
-			r.Logger.Debug("received unexpected params response; using RPC state provider", "peer", envelope.From)
+		// Responses are only handed to the active P2P state provider, and only
+		// if it is still waiting for them. Late responses, e.g. for a provider
+		// that has since been replaced, are expected and quietly dropped.
+		sp, ok := r.stateProvider.(*stateProviderP2P)
+		if !ok || !sp.deliverParams(msg.Height, cp) {
+			r.Logger.Debug("discarding stale consensus params response", "peer", envelope.From, "height", msg.Height)
 		}
 
 	default:
-		return fmt.Errorf("received unknown message: %T", msg)
+		return r.handleUnknownMessage(ParamsChannel, envelope)
 	}
 
 	return nil
 }
 
+// throttleServing returns true if chunk requests from peers should be ignored
+// because the node is itself state syncing and is serving chunks faster than
+// the configured SyncingServeRate.
+func (r *Reactor) throttleServing() bool {
+	if r.cfg.SyncingServeRate <= 0 {
+		return false
+	}
+
+	r.mtx.RLock()
+	syncing := r.syncer != nil
+	r.mtx.RUnlock()
+
+	return syncing && r.serveMonitor.Status().CurRate > r.cfg.SyncingServeRate
+}
+
+// allowLightBlockRequest reports whether a light block request from peer can be
+// served without exceeding LightBlockServeRateLimit, counting it if so.
+func (r *Reactor) allowLightBlockRequest(peer types.NodeID) bool {
+	limit := r.cfg.LightBlockServeRateLimit
+	if limit <= 0 {
+		return true
+	}
+
+	r.lightBlockMtx.Lock()
+	monitor, ok := r.lightBlockMonitors[peer]
+	if !ok {
+		monitor = flowrate.New(lightBlockServeRateSample, 0)
+		r.lightBlockMonitors[peer] = monitor
+	}
+	r.lightBlockMtx.Unlock()
+
+	if monitor.Limit(1, limit, false) == 0 {
+		return false
+	}
+	monitor.Update(1)
+	return true
+}
+
+// handleUnknownMessage handles an envelope whose message is not handled on the
+// channel chID. Peers advertising a later protocol version than ours on the
+// channel may send message types we don't know, which are ignored, whereas it
+// is an error for any other peer.
+func (r *Reactor) handleUnknownMessage(chID p2p.ChannelID, envelope p2p.Envelope) error {
+	if r.peerVersion(envelope.From, chID) > protocolVersion {
+		r.Logger.Debug("ignoring message of a later protocol version",
+			"peer", envelope.From, "ch_id", chID, "message", reflect.TypeOf(envelope.Message))
+		return nil
+	}
+	return fmt.Errorf("received unknown message: %T", envelope.Message)
+}
+
+// advertiseCapabilities sends our protocol version to peer on every channel.
+// It is sent regardless of the peer's version: peers predating Capabilities
+// fail to unwrap it and drop it in the router without erroring the peer.
+func (r *Reactor) advertiseCapabilities(peer types.NodeID) {
+	if r.advertisedVersion == 0 {
+		return
+	}
+	for _, ch := range []*p2p.Channel{r.snapshotCh, r.chunkCh, r.blockCh, r.paramsCh} {
+		select {
+		case ch.Out <- p2p.Envelope{
+			To:      peer,
+			Message: &ssproto.Capabilities{Version: r.advertisedVersion},
+		}:
+		case <-r.closeCh:
+			return
+		}
+	}
+}
+
+// peerVersion returns the protocol version peer advertised on channel chID,
+// or legacyProtocolVersion if it didn't advertise one.
+func (r *Reactor) peerVersion(peer types.NodeID, chID p2p.ChannelID) uint32 {
+	r.peerVersionsMtx.RLock()
+	defer r.peerVersionsMtx.RUnlock()
+
+	if version, ok := r.peerVersions[peer][chID]; ok {
+		return version
+	}
+	return legacyProtocolVersion
+}
+
+// peerSupports reports whether peer understands msg on channel chID. Message
+// types added to the protocol must only be sent to peers that support them.
+func (r *Reactor) peerSupports(peer types.NodeID, chID p2p.ChannelID, msg proto.Message) bool {
+	return r.peerVersion(peer, chID) >= messageVersion(msg)
+}
+
+// messageVersion returns the protocol version a peer must speak to understand
+// msg, i.e. the version that introduced its type or the fields it sets that
+// change its meaning. Fields that peers of earlier versions can safely ignore,
+// like chunk checksums or the hints of SnapshotsRequest, don't count. Message
+// types and such fields added to the protocol must be listed here.
+func messageVersion(msg proto.Message) uint32 {
+	switch msg := msg.(type) {
+	case *ssproto.Capabilities:
+		return 2
+	case *ssproto.SnapshotsResponse:
+		if msg.MetadataCompressed || msg.BaseHeight != 0 {
+			return 2
+		}
+	case *ssproto.ChunkRequest:
+		if msg.BaseHeight != 0 {
+			return 2
+		}
+	case *ssproto.ChunkResponse:
+		if msg.BaseHeight != 0 {
+			return 2
+		}
+	}
+	return legacyProtocolVersion
+}
+
+// send sends envelope to its peer on channel ch, unless the peer doesn't
+// support its message, in which case it is dropped. The responses to peers'
+// requests are sent with send. As a peer advertises its version on a channel
+// before sending requests on it, its version is known by then. The requests
+// sent by the syncer and the dispatcher only use baseline messages and fields,
+// except for the chunk requests of delta snapshots, which are only sent to
+// peers that advertised them and hence support them.
+func (r *Reactor) send(ch *p2p.Channel, envelope p2p.Envelope) {
+	if !r.peerSupports(envelope.To, ch.ID, envelope.Message) {
+		r.Logger.Debug("dropping message not supported by peer", "peer", envelope.To, "ch_id", ch.ID,
+			"message", reflect.TypeOf(envelope.Message), "version", r.peerVersion(envelope.To, ch.ID))
+		return
+	}
+	ch.Out <- envelope
+}
+
 // handleMessage handles an Envelope sent from a peer on a specific p2p Channel.
 // It will handle errors and any possible panics gracefully. A caller can handle
 // any error returned by sending a PeerError on the respective channel.
@@ -761,11 +2449,25 @@ func (r *Reactor) handleMessage(chID p2p.ChannelID, envelope p2p.Envelope) (err
 				"err", err,
 				"stack", string(debug.Stack()),
 			)
+			if !r.countPeerPanic(envelope.From) {
+				err = nil
+			}
 		}
 	}()
 
 	r.Logger.Debug("received message", "message", reflect.TypeOf(envelope.Message), "peer", envelope.From)
 
+	// capabilities are handled the same way on every channel
+	if msg, ok := envelope.Message.(*ssproto.Capabilities); ok {
+		r.peerVersionsMtx.Lock()
+		if r.peerVersions[envelope.From] == nil {
+			r.peerVersions[envelope.From] = make(map[p2p.ChannelID]uint32)
+		}
+		r.peerVersions[envelope.From][chID] = msg.Version
+		r.peerVersionsMtx.Unlock()
+		return nil
+	}
+
 	switch chID {
 	case SnapshotChannel:
 		err = r.handleSnapshotMessage(envelope)
@@ -786,34 +2488,46 @@ func (r *Reactor) handleMessage(chID p2p.ChannelID, envelope p2p.Envelope) (err
 	return err
 }
 
+// countPeerPanic records a panic recovered from while handling a message of
+// peer, returning true once the peer has triggered MaxPeerPanics of them and
+// should be reported.
+func (r *Reactor) countPeerPanic(peer types.NodeID) bool {
+	r.peerPanicsMtx.Lock()
+	defer r.peerPanicsMtx.Unlock()
+
+	r.peerPanics[peer]++
+	return r.peerPanics[peer] >= r.cfg.MaxPeerPanics
+}
+
 // processSnapshotCh initiates a blocking process where we listen for and handle
 // envelopes on the SnapshotChannel.
-func (r *Reactor) processSnapshotCh() {
-	r.processCh(r.snapshotCh, "snapshot")
+func (r *Reactor) processSnapshotCh(stopCh <-chan struct{}) {
+	r.processCh(r.snapshotCh, "snapshot", stopCh)
 }
 
 // processChunkCh initiates a blocking process where we listen for and handle
 // envelopes on the ChunkChannel.
-func (r *Reactor) processChunkCh() {
-	r.processCh(r.chunkCh, "chunk")
+func (r *Reactor) processChunkCh(stopCh <-chan struct{}) {
+	r.processCh(r.chunkCh, "chunk", stopCh)
 }
 
 // processBlockCh initiates a blocking process where we listen for and handle
 // envelopes on the LightBlockChannel.
-func (r *Reactor) processBlockCh() {
-	r.processCh(r.blockCh, "light block")
+func (r *Reactor) processBlockCh(stopCh <-chan struct{}) {
+	r.processCh(r.blockCh, "light block", stopCh)
 }
 
-func (r *Reactor) processParamsCh() {
-	r.processCh(r.paramsCh, "consensus params")
+func (r *Reactor) processParamsCh(stopCh <-chan struct{}) {
+	r.processCh(r.paramsCh, "consensus params", stopCh)
 }
 
 // processCh routes state sync messages to their respective handlers. Any error
 // encountered during message execution will result in a PeerError being sent on
 // the respective channel. When the reactor is stopped, we will catch the signal
-// and close the p2p Channel gracefully.
-func (r *Reactor) processCh(ch *p2p.Channel, chName string) {
-	defer ch.Close()
+// and close the p2p Channel gracefully. When stopCh is closed, as done by
+// Restart, processing stops but the p2p Channel is left open.
+func (r *Reactor) processCh(ch *p2p.Channel, chName string, stopCh <-chan struct{}) {
+	defer r.handlersWG.Done()
 
 	for {
 		select {
@@ -829,6 +2543,12 @@ func (r *Reactor) processCh(ch *p2p.Channel, chName string) {
 
 		case <-r.closeCh:
 			r.Logger.Debug(fmt.Sprintf("stopped listening on %s channel; closing...", chName))
+			r.peerUpdatesWG.Wait()
+			ch.Close()
+			return
+
+		case <-stopCh:
+			r.Logger.Debug(fmt.Sprintf("stopped listening on %s channel; restarting...", chName))
 			return
 		}
 	}
@@ -842,8 +2562,36 @@ func (r *Reactor) processPeerUpdate(peerUpdate p2p.PeerUpdate) {
 	switch peerUpdate.Status {
 	case p2p.PeerStatusUp:
 		r.peers.Append(peerUpdate.NodeID)
+		r.advertiseCapabilities(peerUpdate.NodeID)
+
+		r.connectedMtx.Lock()
+		if r.connected == nil {
+			r.connected = make(map[types.NodeID]bool)
+		}
+		r.connected[peerUpdate.NodeID] = true
+		r.connectedMtx.Unlock()
 	case p2p.PeerStatusDown:
 		r.peers.Remove(peerUpdate.NodeID)
+
+		r.connectedMtx.Lock()
+		delete(r.connected, peerUpdate.NodeID)
+		r.connectedMtx.Unlock()
+
+		r.lightBlockMtx.Lock()
+		delete(r.lightBlockMonitors, peerUpdate.NodeID)
+		r.lightBlockMtx.Unlock()
+
+		r.peerPanicsMtx.Lock()
+		delete(r.peerPanics, peerUpdate.NodeID)
+		r.peerPanicsMtx.Unlock()
+
+		if r.readAhead != nil {
+			r.readAhead.remove(peerUpdate.NodeID)
+		}
+
+		r.peerVersionsMtx.Lock()
+		delete(r.peerVersions, peerUpdate.NodeID)
+		r.peerVersionsMtx.Unlock()
 	}
 
 	r.mtx.Lock()
@@ -857,30 +2605,82 @@ func (r *Reactor) processPeerUpdate(peerUpdate p2p.PeerUpdate) {
 	case p2p.PeerStatusUp:
 		newProvider := NewBlockProvider(peerUpdate.NodeID, r.chainID, r.dispatcher)
 		r.providers[peerUpdate.NodeID] = newProvider
-		r.syncer.AddPeer(peerUpdate.NodeID)
-		if sp, ok := r.stateProvider.(*stateProviderP2P); ok {
-			// we do this in a separate routine to not block whilst waiting for the light client to finish
-			// whatever call it's currently executing
-			go sp.addProvider(newProvider)
+		// when sampling, new peers are only asked for snapshots if they are
+		// part of a later sample
+		if r.cfg.DiscoveryStrategy != config.DiscoveryStrategySample {
+			r.syncer.AddPeer(peerUpdate.NodeID)
+		}
+		if _, ok := r.stateProvider.(*stateProviderP2P); ok {
+			// providers are added separately to not block whilst waiting for the
+			// light client to finish whatever call it's currently executing
+			r.pushProviderUpdate(peerUpdate)
 		}
 
 	case p2p.PeerStatusDown:
 		delete(r.providers, peerUpdate.NodeID)
 		r.syncer.RemovePeer(peerUpdate.NodeID)
+		if _, ok := r.stateProvider.(*stateProviderP2P); ok {
+			r.pushProviderUpdate(peerUpdate)
+		}
 	}
 	r.Logger.Info("processed peer update", "peer", peerUpdate.NodeID, "status", peerUpdate.Status)
 }
 
+// ResetPeers replaces the reactor's view of the connected peers with current,
+// the authoritative set of peers connected at the p2p layer. Peers the reactor
+// knows of that aren't in current are removed as if they had disconnected,
+// recovering from missed peer-down events, and peers in current it doesn't
+// know of are added as if they had just connected. Peers lent out to in-flight
+// backfill requests are only known again once their requests complete.
+func (r *Reactor) ResetPeers(current []types.NodeID) {
+	r.peerUpdateMtx.Lock()
+	defer r.peerUpdateMtx.Unlock()
+
+	// apply the updates received so far first, so that they can't undo the
+	// reset once processed
+	r.drainPeerUpdates()
+
+	known := make(map[types.NodeID]bool)
+	for _, peer := range r.peers.All() {
+		known[peer] = true
+	}
+	r.mtx.RLock()
+	for peer := range r.providers {
+		known[peer] = true
+	}
+	r.mtx.RUnlock()
+
+	connected := make(map[types.NodeID]bool, len(current))
+	for _, peer := range current {
+		connected[peer] = true
+	}
+
+	for peer := range known {
+		if !connected[peer] {
+			r.processPeerUpdate(p2p.PeerUpdate{NodeID: peer, Status: p2p.PeerStatusDown})
+		}
+	}
+	for peer := range connected {
+		if !known[peer] {
+			r.processPeerUpdate(p2p.PeerUpdate{NodeID: peer, Status: p2p.PeerStatusUp})
+		}
+	}
+}
+
 // processPeerUpdates initiates a blocking process where we listen for and handle
 // PeerUpdate messages. When the reactor is stopped, we will catch the signal and
 // close the p2p PeerUpdatesCh gracefully.
 func (r *Reactor) processPeerUpdates() {
 	defer r.peerUpdates.Close()
 
+	// updates are processed separately, so that a slow one doesn't stall the
+	// reception of the following ones
+	go r.processPeerUpdateQueue()
+
 	for {
 		select {
 		case peerUpdate := <-r.peerUpdates.Updates():
-			r.processPeerUpdate(peerUpdate)
+			r.queuedPeerUpdates.push(peerUpdate)
 
 		case <-r.closeCh:
 			r.Logger.Debug("stopped listening on peer updates channel; closing...")
@@ -889,13 +2689,132 @@ func (r *Reactor) processPeerUpdates() {
 	}
 }
 
-// recentSnapshots fetches the n most recent snapshots from the app
+// processPeerUpdateQueue processes the queued peer updates, in the order in
+// which they were received, until the reactor is stopped.
+func (r *Reactor) processPeerUpdateQueue() {
+	defer r.peerUpdatesWG.Done()
+
+	for {
+		select {
+		case <-r.queuedPeerUpdates.ready():
+			r.peerUpdateMtx.Lock()
+			r.drainPeerUpdates()
+			r.peerUpdateMtx.Unlock()
+
+		case <-r.closeCh:
+			return
+		}
+	}
+}
+
+// drainPeerUpdates processes the queued peer updates until there are none
+// left. The caller must hold peerUpdateMtx.
+func (r *Reactor) drainPeerUpdates() {
+	for {
+		peerUpdate, ok := r.queuedPeerUpdates.pop()
+		if !ok {
+			return
+		}
+		r.processPeerUpdate(peerUpdate)
+	}
+}
+
+// pushProviderUpdate queues the addition or removal of the light client
+// provider of a peer, coalescing it with the pending update of the peer if any.
+func (r *Reactor) pushProviderUpdate(peerUpdate p2p.PeerUpdate) {
+	r.providerUpdates.push(peerUpdate)
+	r.metrics.ProviderQueueDepth.Set(float64(r.providerUpdates.len()))
+}
+
+// processProviderUpdates applies the queued light client provider updates to
+// the P2P state provider one at a time, in the order in which they were
+// queued, until the reactor is stopped. Updates queued for a state provider
+// that is no longer in use are dropped.
+func (r *Reactor) processProviderUpdates() {
+	for {
+		select {
+		case <-r.providerUpdates.ready():
+			for {
+				peerUpdate, ok := r.providerUpdates.pop()
+				if !ok {
+					break
+				}
+				r.metrics.ProviderQueueDepth.Set(float64(r.providerUpdates.len()))
+
+				r.mtx.RLock()
+				sp, ok := r.stateProvider.(*stateProviderP2P)
+				provider := r.providers[peerUpdate.NodeID]
+				r.mtx.RUnlock()
+				if !ok {
+					continue
+				}
+
+				switch peerUpdate.Status {
+				case p2p.PeerStatusUp:
+					if provider != nil {
+						sp.addProvider(provider)
+					}
+				case p2p.PeerStatusDown:
+					sp.removeProvider(peerUpdate.NodeID)
+				}
+			}
+
+		case <-r.closeCh:
+			return
+		}
+	}
+}
+
+// recentSnapshots fetches the n most recent snapshots from the app. If the app
+// doesn't respond within ListSnapshotsTimeout, the snapshots fetched by the
+// last successful call are returned instead, so that a slow app doesn't hold up
+// snapshot serving. A call that timed out is awaited by the next one rather
+// than being issued again.
 func (r *Reactor) recentSnapshots(n uint32) ([]*snapshot, error) {
-	resp, err := r.conn.ListSnapshotsSync(context.Background(), abci.RequestListSnapshots{})
-	if err != nil {
-		return nil, err
+	if r.listSnapshotsCh == nil {
+		timeout := r.cfg.ListSnapshotsTimeout
+		resultCh := make(chan listSnapshotsResult, 1)
+		go func() {
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			resp, err := r.serveConn.ListSnapshotsSync(ctx, abci.RequestListSnapshots{})
+			resultCh <- listSnapshotsResult{resp: resp, err: err}
+		}()
+		r.listSnapshotsCh = resultCh
+	}
+
+	var timeoutCh <-chan time.Time
+	if r.cfg.ListSnapshotsTimeout > 0 {
+		timer := time.NewTimer(r.cfg.ListSnapshotsTimeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	var result listSnapshotsResult
+	select {
+	case result = <-r.listSnapshotsCh:
+		r.listSnapshotsCh = nil
+	case <-timeoutCh:
+		r.Logger.Info("timed out listing snapshots; serving the previous ones",
+			"timeout", r.cfg.ListSnapshotsTimeout)
+		return r.lastSnapshots, nil
 	}
 
+	if errors.Is(result.err, context.DeadlineExceeded) {
+		r.Logger.Info("timed out listing snapshots; serving the previous ones",
+			"timeout", r.cfg.ListSnapshotsTimeout)
+		return r.lastSnapshots, nil
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	resp := result.resp
+	r.cacheAppFormats(resp.Snapshots)
+
 	sort.Slice(resp.Snapshots, func(i, j int) bool {
 		a := resp.Snapshots[i]
 		b := resp.Snapshots[j]
@@ -911,39 +2830,115 @@ func (r *Reactor) recentSnapshots(n uint32) ([]*snapshot, error) {
 	})
 
 	snapshots := make([]*snapshot, 0, n)
-	for i, s := range resp.Snapshots {
-		if i >= recentSnapshots {
+	for _, s := range resp.Snapshots {
+		if len(snapshots) >= recentSnapshots {
 			break
 		}
+		if !r.servesFormat(s.Format) {
+			continue
+		}
 
 		snapshots = append(snapshots, &snapshot{
-			Height:   s.Height,
-			Format:   s.Format,
-			Chunks:   s.Chunks,
-			Hash:     s.Hash,
-			Metadata: s.Metadata,
+			Height:     s.Height,
+			Format:     s.Format,
+			Chunks:     s.Chunks,
+			Hash:       s.Hash,
+			Metadata:   s.Metadata,
+			BaseHeight: s.BaseHeight,
 		})
 	}
 
+	r.lastSnapshots = snapshots
 	return snapshots, nil
 }
 
+// servesFormat reports whether snapshots of the given format are advertised
+// and served to peers, as restricted by ServeFormats.
+func (r *Reactor) servesFormat(format uint32) bool {
+	if len(r.cfg.ServeFormats) == 0 {
+		return true
+	}
+	for _, served := range r.cfg.ServeFormats {
+		if served == format {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheAppFormats records the formats of the snapshots listed by the app, the
+// first time it lists any.
+func (r *Reactor) cacheAppFormats(snapshots []*abci.Snapshot) {
+	r.formatsMtx.Lock()
+	defer r.formatsMtx.Unlock()
+
+	if r.appFormats != nil || len(snapshots) == 0 {
+		return
+	}
+	r.appFormats = make(map[uint32]bool)
+	for _, s := range snapshots {
+		r.appFormats[s.Format] = true
+	}
+}
+
+// appTakesFormat reports whether the app takes snapshots of the given format,
+// and can hence serve their chunks. Any format is assumed to be taken until
+// the app has listed snapshots.
+func (r *Reactor) appTakesFormat(format uint32) bool {
+	r.formatsMtx.RLock()
+	defer r.formatsMtx.RUnlock()
+
+	return r.appFormats == nil || r.appFormats[format]
+}
+
+// formatRejected reports whether the app rejected the given snapshot format
+// during a previous sync.
+func (r *Reactor) formatRejected(format uint32) bool {
+	r.formatsMtx.RLock()
+	defer r.formatsMtx.RUnlock()
+
+	return r.rejectedFormats[format]
+}
+
+// listSnapshotsResult is the outcome of a ListSnapshots call to the app.
+type listSnapshotsResult struct {
+	resp *abci.ResponseListSnapshots
+	err  error
+}
+
 // fetchLightBlock works out whether the node has a light block at a particular
-// height and if so returns it so it can be gossiped to peers
+// height and if so returns it so it can be gossiped to peers.
 func (r *Reactor) fetchLightBlock(height uint64) (*types.LightBlock, error) {
-	h := int64(height)
+	lb, err := LoadLightBlock(r.blockStore, r.stateStore, int64(height))
+	if err == nil && lb == nil {
+		r.Logger.Debug("no light block available", "height", height)
+	}
+	return lb, err
+}
 
-	blockMeta := r.blockStore.LoadBlockMeta(h)
+// LoadLightBlock builds the light block at the given height from the block and
+// state stores, as it is served to peers. If the canonical commit for the
+// height has not been stored yet, as is the case for the latest block, the seen
+// commit is used in its place. It returns nil if the block, its commit or its
+// validators are not available.
+func LoadLightBlock(blockStore sm.BlockStore, stateStore sm.Store, height int64) (*types.LightBlock, error) {
+	blockMeta := blockStore.LoadBlockMeta(height)
 	if blockMeta == nil {
 		return nil, nil
 	}
 
-	commit := r.blockStore.LoadBlockCommit(h)
+	commit := blockStore.LoadBlockCommit(height)
 	if commit == nil {
-		return nil, nil
+		// the canonical commit is only stored alongside the next block, so
+		// fall back to the seen commit if it is for this height
+		seenCommit := blockStore.LoadSeenCommit()
+		if seenCommit == nil || seenCommit.Height != height {
+			return nil, nil
+		}
+		commit = seenCommit
 	}
 
-	vals, err := r.stateStore.LoadValidators(h)
+	vals, err := stateStore.LoadValidators(height)
 	if err != nil {
 		return nil, err
 	}
@@ -960,21 +2955,88 @@ func (r *Reactor) fetchLightBlock(height uint64) (*types.LightBlock, error) {
 	}, nil
 }
 
-func (r *Reactor) waitForEnoughPeers(ctx context.Context, numPeers int) {
-	t := time.NewTicker(200 * time.Millisecond)
+// namespacedTempDir returns the directory within tempDir that holds the
+// temporary files of the node with the given ID on the given chain. If tempDir
+// is empty, os.TempDir() is used.
+func namespacedTempDir(tempDir, prefix, chainID string, nodeID types.NodeID) string {
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	return filepath.Join(tempDir, fmt.Sprintf("%s-%s-%s", prefix, chainID, nodeID))
+}
+
+// resetTempDir removes any files left behind in the node's temp directory by a
+// previous state sync and recreates the directory.
+func (r *Reactor) resetTempDir() error {
+	if err := r.cleanupTempDir(); err != nil {
+		return err
+	}
+	return os.MkdirAll(r.tempDir, 0700)
+}
+
+// cleanupTempDir removes the node's temp directory along with all the files in
+// it. Only the node's own namespace is touched, so that nodes sharing the same
+// parent directory don't remove each other's chunk files.
+func (r *Reactor) cleanupTempDir() error {
+	return os.RemoveAll(r.tempDir)
+}
+
+// appHeight returns the height of the app state, on top of which delta
+// snapshots can be applied. It returns 0, disabling deltas, if the app has no
+// state or can't be queried.
+func (r *Reactor) appHeight(ctx context.Context) uint64 {
+	resp, err := r.connQuery.InfoSync(ctx, proxy.RequestInfo)
+	if err != nil {
+		r.Logger.Info("failed to query app height; delta snapshots disabled", "err", err)
+		return 0
+	}
+	if resp.LastBlockHeight <= 0 {
+		return 0
+	}
+	return uint64(resp.LastBlockHeight)
+}
+
+// waitForEnoughPeers blocks until at least numPeers peers are connected. It
+// returns an error if the context is canceled or if the configured
+// PeerWaitTimeout elapses first.
+func (r *Reactor) waitForEnoughPeers(ctx context.Context, numPeers int) error {
+	var timeoutCh <-chan time.Time
+	if r.cfg.PeerWaitTimeout > 0 {
+		timeout := time.NewTimer(r.cfg.PeerWaitTimeout)
+		defer timeout.Stop()
+		timeoutCh = timeout.C
+	}
+
+	t := time.NewTimer(r.peerCheckInterval())
 	defer t.Stop()
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return ctx.Err()
+		case <-timeoutCh:
+			return fmt.Errorf("%w: %d of %d peers connected after %v",
+				errInsufficientPeers, r.peers.Len(), numPeers, r.cfg.PeerWaitTimeout)
 		case <-t.C:
 			if r.peers.Len() >= numPeers {
-				return
+				return nil
 			}
+			t.Reset(r.peerCheckInterval())
 		}
 	}
 }
 
+// peerCheckInterval returns the time to wait before checking again whether
+// enough peers are connected: PeerCheckInterval plus a random jitter of up to
+// PeerCheckJitter, so that nodes started together don't check in lockstep.
+func (r *Reactor) peerCheckInterval() time.Duration {
+	interval := r.cfg.PeerCheckInterval
+	if r.cfg.PeerCheckJitter > 0 {
+		jitter := rand.Int63n(int64(r.cfg.PeerCheckJitter)) // nolint:gosec // G404: Use of weak random number generator
+		interval += time.Duration(jitter)
+	}
+	return interval
+}
+
 func (r *Reactor) initStateProvider(ctx context.Context, chainID string, initialHeight int64) error {
 	var err error
 	to := light.TrustOptions{
@@ -985,6 +3047,7 @@ func (r *Reactor) initStateProvider(ctx context.Context, chainID string, initial
 	spLogger := r.Logger.With("module", "stateprovider")
 	spLogger.Info("initializing state provider", "trustPeriod", to.Period,
 		"trustHeight", to.Height, "useP2P", r.cfg.UseP2P)
+	r.trustOptions = to
 
 	if r.cfg.UseP2P {
 		peers := r.peers.All()
@@ -993,7 +3056,8 @@ func (r *Reactor) initStateProvider(ctx context.Context, chainID string, initial
 			providers[idx] = NewBlockProvider(p, chainID, r.dispatcher)
 		}
 
-		r.stateProvider, err = NewP2PStateProvider(ctx, chainID, initialHeight, providers, to, r.paramsCh.Out, spLogger)
+		r.stateProvider, err = NewP2PStateProvider(ctx, chainID, initialHeight, providers,
+			r.cfg.MaxStateProviders, r.cfg.StateProviderQuorum, to, r.paramsCh.Out, r.verifyParams, spLogger)
 		if err != nil {
 			return fmt.Errorf("failed to initialize P2P state provider: %w", err)
 		}
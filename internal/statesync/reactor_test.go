@@ -1,8 +1,13 @@
 package statesync
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -19,11 +24,16 @@ import (
 	"github.com/tendermint/tendermint/internal/statesync/mocks"
 	"github.com/tendermint/tendermint/internal/test/factory"
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/service"
+	"github.com/tendermint/tendermint/light"
 	"github.com/tendermint/tendermint/light/provider"
+	lightmocks "github.com/tendermint/tendermint/light/provider/mocks"
+	lightdb "github.com/tendermint/tendermint/light/store/db"
 	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	"github.com/tendermint/tendermint/proxy"
 	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+	sm "github.com/tendermint/tendermint/state"
 	smmocks "github.com/tendermint/tendermint/state/mocks"
 	"github.com/tendermint/tendermint/store"
 	"github.com/tendermint/tendermint/types"
@@ -144,6 +154,7 @@ func setup(
 	rts.reactor = NewReactor(
 		factory.DefaultTestChainID,
 		1,
+		types.NodeID("00ff"),
 		*cfg,
 		log.TestingLogger(),
 		conn,
@@ -156,11 +167,15 @@ func setup(
 		rts.stateStore,
 		rts.blockStore,
 		"",
+		NopMetrics(),
 	)
+	// tests read the p2p channels directly, so they don't expect capabilities
+	rts.reactor.advertisedVersion = 0
 
 	rts.syncer = newSyncer(
 		*cfg,
 		log.NewNopLogger(),
+		nopTracer{},
 		conn,
 		connQuery,
 		stateProvider,
@@ -181,6 +196,15 @@ func setup(
 }
 
 func TestReactor_Sync(t *testing.T) {
+	for _, skipBackfill := range []bool{false, true} {
+		skipBackfill := skipBackfill
+		t.Run(fmt.Sprintf("skipBackfill=%v", skipBackfill), func(t *testing.T) {
+			testReactorSync(t, skipBackfill)
+		})
+	}
+}
+
+func testReactorSync(t *testing.T, skipBackfill bool) {
 	const snapshotHeight = 7
 	rts := setup(t, nil, nil, nil, 2)
 	chain := buildLightBlockChain(t, 1, 10, time.Now())
@@ -226,10 +250,355 @@ func TestReactor_Sync(t *testing.T) {
 	rts.reactor.cfg.TrustHeight = 1
 	rts.reactor.cfg.TrustHash = fmt.Sprintf("%X", chain[1].Hash())
 	rts.reactor.cfg.DiscoveryTime = 1 * time.Second
+	rts.reactor.cfg.SkipBackfill = skipBackfill
+
+	_, ok := rts.reactor.LastSyncResult()
+	require.False(t, ok)
+	_, ok = rts.reactor.SelectedSnapshot()
+	require.False(t, ok)
 
 	// Run state sync
+	state, err := rts.reactor.Sync(context.Background())
+	require.NoError(t, err)
+
+	result, ok := rts.reactor.LastSyncResult()
+	require.True(t, ok)
+	require.Equal(t, state.ChainID, result.ChainID)
+	require.Equal(t, state.LastBlockHeight, result.Height)
+	require.Equal(t, state.AppHash, []byte(result.AppHash))
+	require.Equal(t, result.BackfillError == nil && !skipBackfill, result.BackfillCompleted)
+	require.Equal(t, skipBackfill, result.BackfillSkipped)
+	require.Equal(t, uint32(1), result.SnapshotFormat)
+	require.Equal(t, uint32(1), result.ChunksApplied)
+	require.Equal(t, "p2p", result.StateProvider)
+	require.Greater(t, result.Duration, time.Duration(0))
+	if skipBackfill {
+		require.Zero(t, result.BackfillStartHeight)
+		require.Zero(t, result.BackfillStopHeight)
+	} else {
+		require.Equal(t, state.LastBlockHeight, result.BackfillStartHeight)
+		require.Equal(t, rts.reactor.BackfillStopHeight(state), result.BackfillStopHeight)
+	}
+
+	// the restored snapshot is recorded along with the peers serving it
+	selected, ok := rts.reactor.SelectedSnapshot()
+	require.True(t, ok)
+	require.Equal(t, uint64(snapshotHeight), selected.Height)
+	require.Equal(t, uint32(1), selected.Format)
+	require.NotEmpty(t, selected.Peers)
+	if skipBackfill {
+		// nothing was backfilled
+		rts.stateStore.AssertNotCalled(t, "SaveValidatorSets", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestReactor_Persist(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.persistRetryDelay = 10 * time.Millisecond
+	ctx := context.Background()
+
+	// transient failures are retried
+	calls := 0
+	err := rts.reactor.persist(ctx, "store", func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	// and the sync fails once the retries are exhausted
+	calls = 0
+	err = rts.reactor.persist(ctx, "store", func() error {
+		calls++
+		return errors.New("persistent")
+	})
+	require.ErrorIs(t, err, errPersistFailed)
+	require.Contains(t, err.Error(), "persistent")
+	require.Equal(t, persistRetries+1, calls)
+}
+
+func TestReactor_SyncPeerWaitTimeout(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	// only a single peer ever connects
+	rts.peerUpdateCh <- p2p.PeerUpdate{
+		NodeID: types.NodeID("aa"),
+		Status: p2p.PeerStatusUp,
+	}
+
+	rts.reactor.cfg.UseP2P = true
+	rts.reactor.cfg.PeerWaitTimeout = 1 * time.Second
+	_, err := rts.reactor.Sync(context.Background())
+	require.ErrorIs(t, err, errInsufficientPeers)
+
+	// no state sync was started
+	_, err = rts.reactor.Snapshots()
+	require.Error(t, err)
+}
+
+func TestReactor_SyncNoStateProvider(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.cfg.UseP2P = false
+	rts.reactor.cfg.RPCServers = nil
+
+	// the sync fails without waiting for peers, nor starting
 	_, err := rts.reactor.Sync(context.Background())
+	require.ErrorIs(t, err, errNoStateProvider)
+
+	_, err = rts.reactor.Snapshots()
+	require.Error(t, err)
+}
+
+func TestReactor_SyncMaxAttempts(t *testing.T) {
+	testcases := map[string]struct {
+		maxAttempts int
+		requests    int
+	}{
+		"single attempt": {1, 0},
+		"three attempts": {3, 2},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rts := setup(t, nil, nil, nil, 2)
+			rts.reactor.syncer = rts.syncer
+			rts.reactor.cfg.DiscoveryTime = 0
+			rts.reactor.cfg.MaxSyncAttempts = tc.maxAttempts
+			rts.reactor.syncRetryDelay = 10 * time.Millisecond
+
+			// no peer ever has a snapshot
+			_, _, err := rts.reactor.syncAny(context.Background())
+			require.Equal(t, errNoSnapshots, err)
+
+			// snapshots are requested again before every retry
+			require.Len(t, rts.snapshotOutCh, tc.requests)
+			for i := 0; i < tc.requests; i++ {
+				envelope := <-rts.snapshotOutCh
+				require.True(t, envelope.Broadcast)
+				require.IsType(t, &ssproto.SnapshotsRequest{}, envelope.Message)
+			}
+		})
+	}
+}
+
+func TestReactor_SyncOrWait(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	// pretend a sync is in progress
+	run := &syncRun{doneCh: make(chan struct{})}
+	rts.reactor.mtx.Lock()
+	rts.reactor.run = run
+	rts.reactor.mtx.Unlock()
+
+	type outcome struct {
+		state sm.State
+		err   error
+	}
+	const numWaiters = 3
+	outcomeCh := make(chan outcome, numWaiters)
+	for i := 0; i < numWaiters; i++ {
+		go func() {
+			state, err := rts.reactor.SyncOrWait(context.Background())
+			outcomeCh <- outcome{state, err}
+		}()
+	}
+
+	// waiters give up when their context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := rts.reactor.SyncOrWait(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// all waiters receive the outcome of the sync in progress
+	require.Never(t, func() bool { return len(outcomeCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	run.state, run.err = sm.State{LastBlockHeight: 5}, errors.New("sync failed")
+	close(run.doneCh)
+	for i := 0; i < numWaiters; i++ {
+		o := <-outcomeCh
+		require.EqualValues(t, 5, o.state.LastBlockHeight)
+		require.EqualError(t, o.err, "sync failed")
+	}
+}
+
+func TestReactor_Restart(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("LoadSnapshotChunkSync", context.Background(), abci.RequestLoadSnapshotChunk{
+		Height: 1, Format: 1, Chunk: 1,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil)
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("aa"), Status: p2p.PeerStatusUp}
+	require.Eventually(t, func() bool { return rts.reactor.peers.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	// invalid configs are rejected
+	cfg := *config.DefaultStateSyncConfig()
+	cfg.Fetchers = 0
+	cfg.Enable = true
+	cfg.UseP2P = true
+	cfg.TrustPeriod = time.Hour
+	cfg.TrustHeight = 1
+	cfg.TrustHash = strings.Repeat("ab", 32)
+	cfg.ChunkChecksumAlgorithm = ""
+	require.Error(t, rts.reactor.Restart(cfg))
+
+	// the new config is applied while retaining peers and processing envelopes
+	cfg.Fetchers = 8
+	cfg.ChunkRequestTimeout = 30 * time.Second
+	cfg.LightBlockRequestTimeout = 5 * time.Second
+	require.NoError(t, rts.reactor.Restart(cfg))
+	require.Equal(t, cfg, rts.reactor.cfg)
+	require.Equal(t, 1, rts.reactor.peers.Len())
+	require.Equal(t, cfg.LightBlockRequestTimeout, rts.reactor.dispatcher.timeout)
+
+	rts.chunkInCh <- p2p.Envelope{From: types.NodeID("aa"), Message: &ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1}}
+	response := <-rts.chunkOutCh
+	require.Equal(t, &ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{1}}, response.Message)
+
+	// the config can't change under a state sync in progress
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = rts.syncer
+	rts.reactor.mtx.Unlock()
+	cfg.Fetchers = 2
+	require.ErrorIs(t, rts.reactor.Restart(cfg), errSyncInProgress)
+	require.EqualValues(t, 8, rts.reactor.cfg.Fetchers)
+
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = nil
+	rts.reactor.mtx.Unlock()
+
+	// nor under a backfill or any other operation reading it
+	done := rts.reactor.beginOperation()
+	require.ErrorIs(t, rts.reactor.Restart(cfg), errOperationInProgress)
+	require.EqualValues(t, 8, rts.reactor.cfg.Fetchers)
+	done()
+	require.NoError(t, rts.reactor.Restart(cfg))
+	require.EqualValues(t, 2, rts.reactor.cfg.Fetchers)
+}
+
+func TestReactor_RejectedFormats(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = rts.syncer
+	rts.reactor.run = &syncRun{doneCh: make(chan struct{})}
+	rts.reactor.mtx.Unlock()
+
+	// formats rejected by the app outlive the syncer
+	rts.syncer.snapshots.RejectFormat(2)
+	rts.reactor.stopSyncer(sm.State{}, errors.New("sync failed"))
+
+	rts.reactor.mtx.RLock()
+	require.Nil(t, rts.reactor.syncer)
+	rts.reactor.mtx.RUnlock()
+	require.True(t, rts.reactor.formatRejected(2))
+	require.False(t, rts.reactor.formatRejected(1))
+
+	// and snapshots of those formats are ignored as soon as they're received
+	syncer := newSyncer(*config.DefaultStateSyncConfig(), log.NewNopLogger(), nopTracer{}, rts.conn,
+		rts.connQuery, rts.stateProvider, rts.snapshotOutCh, rts.chunkOutCh, "")
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = syncer
+	rts.reactor.mtx.Unlock()
+
+	for format := uint32(2); format > 0; format-- {
+		rts.snapshotInCh <- p2p.Envelope{
+			From:    types.NodeID("aa"),
+			Message: &ssproto.SnapshotsResponse{Height: 1, Format: format, Chunks: 1, Hash: []byte{1}},
+		}
+	}
+	retryUntil(t, func() bool { return syncer.snapshots.Best() != nil }, time.Second)
+	require.EqualValues(t, 1, syncer.snapshots.Best().Format)
+	require.Len(t, syncer.snapshots.Ranked(), 1)
+}
+
+func TestReactor_AppFormats(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: []*abci.Snapshot{{Height: 2, Format: 1, Chunks: 1}},
+	}, nil)
+	conn.On("LoadSnapshotChunkSync", mock.Anything, abci.RequestLoadSnapshotChunk{
+		Height: 2, Format: 1, Chunk: 0,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil)
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.reactor.cfg.ChunkChecksumAlgorithm = ""
+
+	// any format is assumed to be taken by the app until it lists snapshots
+	require.True(t, rts.reactor.appTakesFormat(2))
+
+	_, err := rts.reactor.recentSnapshots(recentSnapshots)
 	require.NoError(t, err)
+	require.True(t, rts.reactor.appTakesFormat(1))
+	require.False(t, rts.reactor.appTakesFormat(2))
+
+	// the chunks of formats the app doesn't take are reported missing without
+	// asking the app
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ChunkRequest{Height: 2, Format: 2, Index: 0},
+	}
+	response := <-rts.chunkOutCh
+	require.Equal(t, &ssproto.ChunkResponse{Height: 2, Format: 2, Index: 0, Missing: true}, response.Message)
+
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ChunkRequest{Height: 2, Format: 1, Index: 0},
+	}
+	response = <-rts.chunkOutCh
+	require.Equal(t, &ssproto.ChunkResponse{Height: 2, Format: 1, Index: 0, Chunk: []byte{1}}, response.Message)
+
+	conn.AssertNumberOfCalls(t, "LoadSnapshotChunkSync", 1)
+}
+
+func TestReactor_SetStores(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	stateStore := &smmocks.Store{}
+	blockStore := store.NewBlockStore(dbm.NewMemDB())
+
+	// the stores can't be replaced once the reactor is running
+	require.Error(t, rts.reactor.SetStores(stateStore, blockStore))
+	require.Equal(t, rts.stateStore, rts.reactor.stateStore)
+	require.Equal(t, rts.blockStore, rts.reactor.blockStore)
+
+	r := NewReactor(
+		factory.DefaultTestChainID,
+		1,
+		types.NodeID("00ff"),
+		*config.DefaultStateSyncConfig(),
+		log.TestingLogger(),
+		rts.conn,
+		rts.connQuery,
+		rts.snapshotChannel,
+		rts.chunkChannel,
+		rts.blockChannel,
+		rts.paramsChannel,
+		rts.peerUpdates,
+		nil,
+		nil,
+		"",
+		NopMetrics(),
+	)
+	require.NoError(t, r.SetStores(stateStore, blockStore))
+	require.Equal(t, stateStore, r.stateStore)
+	require.Equal(t, blockStore, r.blockStore)
+}
+
+func TestGetChannelShims(t *testing.T) {
+	cfg := config.DefaultStateSyncConfig()
+	cfg.LightBlockChannelPriority = 10
+	cfg.ChunkChannelMaxSendBytes = 4000
+
+	shims := GetChannelShims(cfg)
+	require.Equal(t, 6, shims[SnapshotChannel].Descriptor.Priority)
+	require.Equal(t, 3, shims[ChunkChannel].Descriptor.Priority)
+	require.Equal(t, 10, shims[LightBlockChannel].Descriptor.Priority)
+	require.Equal(t, 2, shims[ParamsChannel].Descriptor.Priority)
+	require.EqualValues(t, 400, shims[SnapshotChannel].Descriptor.MaxSendBytes)
+	require.EqualValues(t, 4000, shims[ChunkChannel].Descriptor.MaxSendBytes)
 }
 
 func TestReactor_ChunkRequest_InvalidRequest(t *testing.T) {
@@ -247,7 +616,226 @@ func TestReactor_ChunkRequest_InvalidRequest(t *testing.T) {
 	require.Equal(t, types.NodeID("aa"), response.NodeID)
 }
 
+func TestReactor_PeerPanics(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.cfg.MaxPeerPanics = 2
+
+	// a nil request panics when handled
+	panicking := p2p.Envelope{From: types.NodeID("aa"), Message: (*ssproto.ChunkRequest)(nil)}
+
+	// the first panic is tolerated, the second one reports the peer
+	rts.chunkInCh <- panicking
+	require.Never(t, func() bool { return len(rts.chunkPeerErrCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	rts.chunkInCh <- panicking
+	response := <-rts.chunkPeerErrCh
+	require.Equal(t, types.NodeID("aa"), response.NodeID)
+	require.Contains(t, response.Err.Error(), "panic in processing message")
+
+	// the count starts over once the peer disconnects
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("aa"), Status: p2p.PeerStatusDown}
+	require.Eventually(t, func() bool {
+		rts.reactor.peerPanicsMtx.Lock()
+		defer rts.reactor.peerPanicsMtx.Unlock()
+		return len(rts.reactor.peerPanics) == 0
+	}, time.Second, 10*time.Millisecond)
+	rts.chunkInCh <- panicking
+	require.Never(t, func() bool { return len(rts.chunkPeerErrCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestReactor_ResetPeers(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("aa"), Status: p2p.PeerStatusUp}
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("bb"), Status: p2p.PeerStatusUp}
+	require.Eventually(t, func() bool { return rts.reactor.peers.Len() == 2 }, time.Second, 10*time.Millisecond)
+	rts.reactor.countPeerPanic("aa")
+
+	// aa never got a peer-down event, and cc never got a peer-up one
+	rts.reactor.ResetPeers([]types.NodeID{"bb", "cc"})
+	require.ElementsMatch(t, []types.NodeID{"bb", "cc"}, rts.reactor.peers.All())
+
+	// the state of removed peers is cleared
+	rts.reactor.peerPanicsMtx.Lock()
+	require.Empty(t, rts.reactor.peerPanics)
+	rts.reactor.peerPanicsMtx.Unlock()
+
+	// resetting to the same set is a no-op
+	rts.reactor.ResetPeers([]types.NodeID{"cc", "bb"})
+	require.ElementsMatch(t, []types.NodeID{"bb", "cc"}, rts.reactor.peers.All())
+
+	rts.reactor.ResetPeers(nil)
+	require.Empty(t, rts.reactor.peers.All())
+
+	// updates received before a reset are never applied after it
+	rts.reactor.queuedPeerUpdates.push(p2p.PeerUpdate{NodeID: types.NodeID("dd"), Status: p2p.PeerStatusUp})
+	rts.reactor.ResetPeers([]types.NodeID{"bb"})
+	require.Zero(t, rts.reactor.queuedPeerUpdates.len())
+	require.Never(t, func() bool {
+		return len(rts.reactor.peers.All()) != 1
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	require.Equal(t, []types.NodeID{"bb"}, rts.reactor.peers.All())
+}
+
+func TestReactor_Capabilities(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.advertisedVersion = protocolVersion
+
+	// our version is advertised on every channel when a peer connects
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("aa"), Status: p2p.PeerStatusUp}
+	for _, outCh := range []chan p2p.Envelope{rts.snapshotOutCh, rts.chunkOutCh, rts.blockOutCh, rts.paramsOutCh} {
+		envelope := <-outCh
+		require.Equal(t, types.NodeID("aa"), envelope.To)
+		require.Equal(t, &ssproto.Capabilities{Version: protocolVersion}, envelope.Message)
+	}
+
+	// a peer that hasn't advertised a version is assumed to speak the legacy one
+	require.False(t, rts.reactor.peerSupports("aa", ChunkChannel, &ssproto.Capabilities{}))
+	require.True(t, rts.reactor.peerSupports("aa", ChunkChannel, &ssproto.ChunkRequest{}))
+
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.Capabilities{Version: protocolVersion + 1},
+	}
+	require.Eventually(t, func() bool {
+		return rts.reactor.peerSupports("aa", ChunkChannel, &ssproto.Capabilities{})
+	}, time.Second, 10*time.Millisecond)
+	require.False(t, rts.reactor.peerSupports("aa", SnapshotChannel, &ssproto.Capabilities{}))
+
+	// messages we don't handle are ignored from peers of a later version
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.SnapshotsRequest{},
+	}
+	require.Never(t, func() bool { return len(rts.chunkPeerErrCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// and versions are forgotten once the peer disconnects
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("aa"), Status: p2p.PeerStatusDown}
+	require.Eventually(t, func() bool {
+		return !rts.reactor.peerSupports("aa", ChunkChannel, &ssproto.Capabilities{})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReactor_LegacyPeerMessages(t *testing.T) {
+	metadata := bytes.Repeat([]byte("metadata"), 1000)
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: []*abci.Snapshot{
+			{Height: 9, Format: 1, Chunks: 1, Hash: []byte{1}, BaseHeight: 5},
+			{Height: 8, Format: 1, Chunks: 1, Hash: []byte{2}, Metadata: metadata},
+			{Height: 7, Format: 1, Chunks: 1, Hash: []byte{3}},
+		},
+	}, nil)
+	conn.On("LoadSnapshotChunkSync", mock.Anything, mock.AnythingOfType("types.RequestLoadSnapshotChunk")).
+		Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil)
+
+	rts := setup(t, conn, nil, nil, 100)
+	rts.reactor.cfg.CompressSnapshotMetadata = true
+
+	request := func(peer types.NodeID) {
+		rts.snapshotInCh <- p2p.Envelope{
+			From:    peer,
+			Message: &ssproto.SnapshotsRequest{AcceptCompressedMetadata: true, BaseHeight: 5},
+		}
+		rts.chunkInCh <- p2p.Envelope{
+			From:    peer,
+			Message: &ssproto.ChunkRequest{Height: 9, Format: 1, Index: 0, BaseHeight: 5},
+		}
+		rts.chunkInCh <- p2p.Envelope{
+			From:    peer,
+			Message: &ssproto.ChunkRequest{Height: 7, Format: 1, Index: 0},
+		}
+	}
+
+	// a peer that didn't advertise a version only receives baseline messages,
+	// even when asking for deltas and compressed metadata
+	request(types.NodeID("aa"))
+	response := <-rts.snapshotOutCh
+	require.Equal(t, &ssproto.SnapshotsResponse{Height: 7, Format: 1, Chunks: 1, Hash: []byte{3}}, response.Message)
+	response = <-rts.chunkOutCh
+	require.Equal(t, uint64(7), response.Message.(*ssproto.ChunkResponse).Height)
+	require.Never(t, func() bool { return len(rts.snapshotOutCh) > 0 || len(rts.chunkOutCh) > 0 },
+		100*time.Millisecond, 10*time.Millisecond)
+
+	// whereas a peer of our version receives them all
+	advertiseVersion(rts, types.NodeID("bb"), protocolVersion)
+	request(types.NodeID("bb"))
+	retryUntil(t, func() bool { return len(rts.snapshotOutCh) == 3 && len(rts.chunkOutCh) == 2 }, time.Second)
+	for i := 0; i < 3; i++ {
+		response = <-rts.snapshotOutCh
+		require.Equal(t, types.NodeID("bb"), response.To)
+	}
+	require.Equal(t, uint64(5), (<-rts.chunkOutCh).Message.(*ssproto.ChunkResponse).BaseHeight)
+	require.Equal(t, uint64(7), (<-rts.chunkOutCh).Message.(*ssproto.ChunkResponse).Height)
+	require.Empty(t, rts.snapshotPeerErrCh)
+	require.Empty(t, rts.chunkPeerErrCh)
+}
+
+func TestReactor_ChunkResponse_Oversized(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.syncer = rts.syncer
+
+	rts.chunkInCh <- p2p.Envelope{
+		From: types.NodeID("aa"),
+		Message: &ssproto.ChunkResponse{
+			Height: 1,
+			Format: 1,
+			Index:  0,
+			Chunk:  make([]byte, chunkMsgSize+1),
+		},
+	}
+
+	response := <-rts.chunkPeerErrCh
+	require.Error(t, response.Err)
+	require.Contains(t, response.Err.Error(), "exceeding the maximum")
+	require.Equal(t, types.NodeID("aa"), response.NodeID)
+}
+
+func TestReactor_ChunkResponse_Checksum(t *testing.T) {
+	chunk := []byte{1, 2, 3}
+	blake2bSum, err := chunkChecksum(config.ChunkChecksumBlake2b, chunk)
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		checksum  []byte
+		algorithm string
+		expectErr string
+	}{
+		"unknown algorithm": {blake2bSum, "md5", "unsupported checksum algorithm"},
+		"mismatch":          {blake2bSum, config.ChunkChecksumSHA256, "checksum mismatch"},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rts := setup(t, nil, nil, nil, 2)
+			rts.reactor.syncer = rts.syncer
+
+			rts.chunkInCh <- p2p.Envelope{
+				From: types.NodeID("aa"),
+				Message: &ssproto.ChunkResponse{
+					Height:            1,
+					Format:            1,
+					Index:             0,
+					Chunk:             chunk,
+					Checksum:          tc.checksum,
+					ChecksumAlgorithm: tc.algorithm,
+				},
+			}
+
+			response := <-rts.chunkPeerErrCh
+			require.Error(t, response.Err)
+			require.Contains(t, response.Err.Error(), tc.expectErr)
+			require.Equal(t, types.NodeID("aa"), response.NodeID)
+		})
+	}
+}
+
 func TestReactor_ChunkRequest(t *testing.T) {
+	checksum := func(chunk []byte) []byte {
+		sum, err := chunkChecksum(config.ChunkChecksumSHA256, chunk)
+		require.NoError(t, err)
+		return sum
+	}
+
 	testcases := map[string]struct {
 		request        *ssproto.ChunkRequest
 		chunk          []byte
@@ -256,12 +844,14 @@ func TestReactor_ChunkRequest(t *testing.T) {
 		"chunk is returned": {
 			&ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1},
 			[]byte{1, 2, 3},
-			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{1, 2, 3}},
+			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{1, 2, 3},
+				Checksum: checksum([]byte{1, 2, 3}), ChecksumAlgorithm: config.ChunkChecksumSHA256},
 		},
 		"empty chunk is returned, as empty": {
 			&ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1},
 			[]byte{},
-			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{}},
+			&ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{},
+				Checksum: checksum([]byte{}), ChecksumAlgorithm: config.ChunkChecksumSHA256},
 		},
 		"nil (missing) chunk is returned as missing": {
 			&ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1},
@@ -303,6 +893,133 @@ func TestReactor_ChunkRequest(t *testing.T) {
 	}
 }
 
+func TestReactor_ServeConn(t *testing.T) {
+	request := &ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1}
+	serveConn := &proxymocks.AppConnSnapshot{}
+	serveConn.On("LoadSnapshotChunkSync", context.Background(), abci.RequestLoadSnapshotChunk{
+		Height: 1, Format: 1, Chunk: 1,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil)
+
+	// the sync connection has no expectations, so using it fails the test
+	rts := setup(t, nil, nil, nil, 2)
+	require.Error(t, rts.reactor.SetServeConn(serveConn))
+	rts.reactor.serveConn = serveConn
+
+	rts.chunkInCh <- p2p.Envelope{From: types.NodeID("aa"), Message: request}
+	response := <-rts.chunkOutCh
+	require.Equal(t, []byte{1}, response.Message.(*ssproto.ChunkResponse).Chunk)
+
+	serveConn.AssertExpectations(t)
+	rts.conn.AssertExpectations(t)
+}
+
+func TestReactor_ServeSnapshotsDisabled(t *testing.T) {
+	// the connection has no expectations, so serving anything fails the test
+	rts := setup(t, nil, nil, nil, 2)
+	require.Error(t, rts.reactor.SetServeSnapshots(false))
+	rts.reactor.serveDisabled = true
+
+	rts.snapshotInCh <- p2p.Envelope{From: types.NodeID("aa"), Message: &ssproto.SnapshotsRequest{}}
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1},
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, rts.snapshotOutCh)
+	require.Empty(t, rts.chunkOutCh)
+	rts.conn.AssertExpectations(t)
+}
+
+func TestReactor_ChunkRequest_ReadAhead(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("LoadSnapshotChunkSync", context.Background(), abci.RequestLoadSnapshotChunk{
+		Height: 1, Format: 1, Chunk: 1,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil).Once()
+	readCh := make(chan uint32, 3)
+	for index := uint32(2); index <= 3; index++ {
+		index := index
+		conn.On("LoadSnapshotChunkSync", mock.Anything, abci.RequestLoadSnapshotChunk{
+			Height: 1, Format: 1, Chunk: index,
+		}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{byte(index)}}, nil).Once().Run(func(mock.Arguments) {
+			readCh <- index
+		})
+	}
+	conn.On("LoadSnapshotChunkSync", mock.Anything, abci.RequestLoadSnapshotChunk{
+		Height: 1, Format: 1, Chunk: 4,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: nil}, nil).Once().Run(func(mock.Arguments) {
+		readCh <- 4
+	})
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.reactor.cfg.ChunkChecksumAlgorithm = ""
+	rts.reactor.cfg.ChunkReadAhead = 2
+
+	waitRead := func(index uint32) {
+		select {
+		case read := <-readCh:
+			require.Equal(t, index, read)
+		case <-time.After(time.Second):
+			require.Failf(t, "timed out waiting for chunk to be read ahead", "chunk %d", index)
+		}
+	}
+
+	// the chunks following a requested chunk are read ahead in the background,
+	// and loaded from the app only once
+	for index := uint32(1); index <= 2; index++ {
+		rts.chunkInCh <- p2p.Envelope{
+			From:    types.NodeID("aa"),
+			Message: &ssproto.ChunkRequest{Height: 1, Format: 1, Index: index},
+		}
+		response := <-rts.chunkOutCh
+		require.Equal(t, []byte{byte(index)}, response.Message.(*ssproto.ChunkResponse).Chunk)
+		if index == 1 {
+			waitRead(2)
+			waitRead(3)
+			require.Eventually(t, func() bool {
+				rts.reactor.readAhead.mtx.Lock()
+				defer rts.reactor.readAhead.mtx.Unlock()
+				return len(rts.reactor.readAhead.filling) == 0
+			}, time.Second, 10*time.Millisecond)
+		}
+	}
+	waitRead(4)
+	conn.AssertExpectations(t)
+}
+
+func TestReactor_ChunkRequest_SyncingServeRate(t *testing.T) {
+	request := &ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1}
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("LoadSnapshotChunkSync", context.Background(), abci.RequestLoadSnapshotChunk{
+		Height: 1, Format: 1, Chunk: 1,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1, 2, 3}}, nil)
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.reactor.cfg.ChunkChecksumAlgorithm = ""
+	rts.reactor.cfg.SyncingServeRate = 100
+	rts.reactor.serveMonitor.Update(1000)
+	require.Eventually(t, func() bool {
+		return rts.reactor.serveMonitor.Status().CurRate > 100
+	}, time.Second, 10*time.Millisecond)
+
+	// while syncing, requests are ignored once the serve rate is exceeded
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = rts.syncer
+	rts.reactor.mtx.Unlock()
+
+	rts.chunkInCh <- p2p.Envelope{From: types.NodeID("aa"), Message: request}
+	require.Never(t, func() bool { return len(rts.chunkOutCh) > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+
+	// the limit is lifted once the sync completes
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = nil
+	rts.reactor.mtx.Unlock()
+
+	rts.chunkInCh <- p2p.Envelope{From: types.NodeID("aa"), Message: request}
+	response := <-rts.chunkOutCh
+	require.Equal(t, &ssproto.ChunkResponse{Height: 1, Format: 1, Index: 1, Chunk: []byte{1, 2, 3}}, response.Message)
+}
+
 func TestReactor_SnapshotsRequest_InvalidRequest(t *testing.T) {
 	rts := setup(t, nil, nil, nil, 2)
 
@@ -360,7 +1077,7 @@ func TestReactor_SnapshotsRequest(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			// mock ABCI connection to return local snapshots
 			conn := &proxymocks.AppConnSnapshot{}
-			conn.On("ListSnapshotsSync", context.Background(), abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+			conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
 				Snapshots: tc.snapshots,
 			}, nil)
 
@@ -387,31 +1104,250 @@ func TestReactor_SnapshotsRequest(t *testing.T) {
 	}
 }
 
-func TestReactor_LightBlockResponse(t *testing.T) {
-	rts := setup(t, nil, nil, nil, 2)
+func TestReactor_SnapshotsRequest_Delta(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: []*abci.Snapshot{
+			{Height: 9, Format: 1, Chunks: 1, Hash: []byte{1}, BaseHeight: 6},
+			{Height: 9, Format: 1, Chunks: 1, Hash: []byte{2}, BaseHeight: 5},
+			{Height: 8, Format: 1, Chunks: 3, Hash: []byte{3}},
+		},
+	}, nil)
+	conn.On("LoadSnapshotChunkSync", mock.Anything, abci.RequestLoadSnapshotChunk{
+		Height: 9, Format: 1, Chunk: 0, BaseHeight: 5,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{1}}, nil)
 
-	var height int64 = 10
-	h := factory.MakeRandomHeader()
-	h.Height = height
-	blockID := factory.MakeBlockIDWithHash(h.Hash())
-	vals, pv := factory.RandValidatorSet(1, 10)
-	vote, err := factory.MakeVote(pv[0], h.ChainID, 0, h.Height, 0, 2,
-		blockID, factory.DefaultTestTime)
-	require.NoError(t, err)
+	rts := setup(t, conn, nil, nil, 100)
+	advertiseVersion(rts, types.NodeID("aa"), protocolVersion)
 
-	sh := &types.SignedHeader{
-		Header: h,
-		Commit: &types.Commit{
-			Height:  h.Height,
-			BlockID: blockID,
-			Signatures: []types.CommitSig{
-				vote.CommitSig(),
-			},
-		},
+	// only the deltas applying on top of the requested base height are served,
+	// along with the full snapshots
+	rts.snapshotInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.SnapshotsRequest{BaseHeight: 5},
 	}
+	retryUntil(t, func() bool { return len(rts.snapshotOutCh) == 2 }, time.Second)
+	require.Equal(t, &ssproto.SnapshotsResponse{Height: 9, Format: 1, Chunks: 1, Hash: []byte{2}, BaseHeight: 5},
+		(<-rts.snapshotOutCh).Message)
+	require.Equal(t, &ssproto.SnapshotsResponse{Height: 8, Format: 1, Chunks: 3, Hash: []byte{3}},
+		(<-rts.snapshotOutCh).Message)
 
-	lb := &types.LightBlock{
-		SignedHeader: sh,
+	// peers without a base height only get full snapshots
+	rts.snapshotInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.SnapshotsRequest{},
+	}
+	require.Equal(t, &ssproto.SnapshotsResponse{Height: 8, Format: 1, Chunks: 3, Hash: []byte{3}},
+		(<-rts.snapshotOutCh).Message)
+	require.Never(t, func() bool { return len(rts.snapshotOutCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// delta chunks are loaded from the app by base height
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ChunkRequest{Height: 9, Format: 1, Index: 0, BaseHeight: 5},
+	}
+	response := <-rts.chunkOutCh
+	require.Equal(t, uint64(5), response.Message.(*ssproto.ChunkResponse).BaseHeight)
+	require.Equal(t, []byte{1}, response.Message.(*ssproto.ChunkResponse).Chunk)
+}
+
+func TestReactor_RecentSnapshotsTimeout(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Once().Return(
+		&abci.ResponseListSnapshots{Snapshots: []*abci.Snapshot{{Height: 1, Format: 1}}}, nil)
+	// the app then becomes slow to respond
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Once().After(500*time.Millisecond).Return(
+		&abci.ResponseListSnapshots{Snapshots: []*abci.Snapshot{{Height: 2, Format: 1}}}, nil)
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.reactor.cfg.ListSnapshotsTimeout = 50 * time.Millisecond
+
+	snapshots, err := rts.reactor.recentSnapshots(recentSnapshots)
+	require.NoError(t, err)
+	require.Equal(t, []*snapshot{{Height: 1, Format: 1}}, snapshots)
+
+	// the previous snapshots are served while the app is slow, without
+	// issuing further calls
+	for i := 0; i < 2; i++ {
+		snapshots, err = rts.reactor.recentSnapshots(recentSnapshots)
+		require.NoError(t, err)
+		require.Equal(t, []*snapshot{{Height: 1, Format: 1}}, snapshots)
+	}
+
+	// the late response is used once it arrives
+	time.Sleep(500 * time.Millisecond)
+	snapshots, err = rts.reactor.recentSnapshots(recentSnapshots)
+	require.NoError(t, err)
+	require.Equal(t, []*snapshot{{Height: 2, Format: 1}}, snapshots)
+
+	conn.AssertNumberOfCalls(t, "ListSnapshotsSync", 2)
+}
+
+func TestReactor_ServeFormats(t *testing.T) {
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(
+		&abci.ResponseListSnapshots{Snapshots: []*abci.Snapshot{
+			{Height: 2, Format: 1},
+			{Height: 2, Format: 2},
+			{Height: 1, Format: 2},
+			{Height: 1, Format: 3},
+		}}, nil)
+
+	rts := setup(t, conn, nil, nil, 2)
+	rts.reactor.cfg.ChunkChecksumAlgorithm = ""
+	rts.reactor.cfg.ServeFormats = []uint32{2}
+
+	// only the snapshots of served formats are advertised
+	snapshots, err := rts.reactor.recentSnapshots(recentSnapshots)
+	require.NoError(t, err)
+	require.Equal(t, []*snapshot{{Height: 2, Format: 2}, {Height: 1, Format: 2}}, snapshots)
+
+	// the chunks of other formats are reported missing without asking the app
+	rts.chunkInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ChunkRequest{Height: 2, Format: 1, Index: 0},
+	}
+	response := <-rts.chunkOutCh
+	require.Equal(t, &ssproto.ChunkResponse{Height: 2, Format: 1, Index: 0, Missing: true}, response.Message)
+
+	conn.AssertNotCalled(t, "LoadSnapshotChunkSync", mock.Anything, mock.Anything)
+}
+
+func TestReactor_SnapshotsCompressedMetadata(t *testing.T) {
+	metadata := bytes.Repeat([]byte("metadata"), 1000)
+	snapshots := []*abci.Snapshot{{Height: 1, Format: 1, Chunks: 7, Hash: []byte{1}, Metadata: metadata}}
+
+	conn := &proxymocks.AppConnSnapshot{}
+	conn.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: snapshots,
+	}, nil)
+
+	rts := setup(t, conn, nil, nil, 100)
+	rts.reactor.cfg.CompressSnapshotMetadata = true
+
+	// peers that don't support compressed metadata receive it raw
+	rts.snapshotInCh <- p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.SnapshotsRequest{},
+	}
+	e := <-rts.snapshotOutCh
+	resp := e.Message.(*ssproto.SnapshotsResponse)
+	require.False(t, resp.MetadataCompressed)
+	require.Equal(t, metadata, resp.Metadata)
+
+	advertiseVersion(rts, types.NodeID("bb"), protocolVersion)
+	rts.snapshotInCh <- p2p.Envelope{
+		From:    types.NodeID("bb"),
+		Message: &ssproto.SnapshotsRequest{AcceptCompressedMetadata: true},
+	}
+	e = <-rts.snapshotOutCh
+	resp = e.Message.(*ssproto.SnapshotsResponse)
+	require.True(t, resp.MetadataCompressed)
+	require.Less(t, len(resp.Metadata), len(metadata))
+
+	// the metadata is decompressed before being added to the pool
+	rts.reactor.syncer = rts.syncer
+	rts.snapshotInCh <- p2p.Envelope{From: types.NodeID("bb"), Message: resp}
+	retryUntil(t, func() bool { return rts.syncer.snapshots.Best() != nil }, time.Second)
+	require.Equal(t, metadata, rts.syncer.snapshots.Best().Metadata)
+
+	// invalid compressed metadata is a peer error
+	rts.snapshotInCh <- p2p.Envelope{
+		From: types.NodeID("cc"),
+		Message: &ssproto.SnapshotsResponse{
+			Height: 2, Format: 1, Chunks: 7, Hash: []byte{2}, Metadata: metadata, MetadataCompressed: true,
+		},
+	}
+	peerErr := <-rts.snapshotPeerErrCh
+	require.Equal(t, types.NodeID("cc"), peerErr.NodeID)
+	require.Contains(t, peerErr.Err.Error(), "invalid compressed snapshot metadata")
+}
+
+func TestReactor_SnapshotsResponse_NoChunks(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.reactor.syncer = rts.syncer
+
+	rts.snapshotInCh <- p2p.Envelope{
+		From: types.NodeID("aa"),
+		Message: &ssproto.SnapshotsResponse{
+			Height: 1,
+			Format: 1,
+			Chunks: 0,
+			Hash:   []byte{1},
+		},
+	}
+
+	peerErr := <-rts.snapshotPeerErrCh
+	require.Equal(t, types.NodeID("aa"), peerErr.NodeID)
+	require.Contains(t, peerErr.Err.Error(), "has no chunks")
+	require.Empty(t, rts.syncer.snapshots.Ranked())
+}
+
+func TestReactor_SnapshotRequester(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 10)
+
+	requested := func(n int) []types.NodeID {
+		peers := make([]types.NodeID, 0, n)
+		for i := 0; i < n; i++ {
+			envelope := <-rts.snapshotOutCh
+			require.IsType(t, &ssproto.SnapshotsRequest{}, envelope.Message)
+			require.False(t, envelope.Broadcast)
+			peers = append(peers, envelope.To)
+		}
+		require.Empty(t, rts.snapshotOutCh)
+		return peers
+	}
+
+	// the default strategy broadcasts the request
+	cfg := config.DefaultStateSyncConfig()
+	rts.reactor.snapshotRequester(*cfg, 0)()
+	envelope := <-rts.snapshotOutCh
+	require.True(t, envelope.Broadcast)
+
+	for _, peer := range []string{"a", "b", "c", "d", "e"} {
+		rts.reactor.peers.Append(types.NodeID(peer))
+	}
+	cfg.DiscoveryStrategy = config.DiscoveryStrategySample
+	cfg.DiscoverySampleSize = 2
+	requestSnapshots := rts.reactor.snapshotRequester(*cfg, 0)
+
+	// the sample is expanded with peers that weren't asked yet
+	requestSnapshots()
+	first := requested(2)
+	requestSnapshots()
+	second := requested(3)
+	require.ElementsMatch(t, []types.NodeID{"a", "b", "c", "d", "e"}, append(first, second...))
+
+	// once all peers have been asked, sampling starts over
+	requestSnapshots()
+	requested(2)
+}
+
+func TestReactor_LightBlockResponse(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	var height int64 = 10
+	h := factory.MakeRandomHeader()
+	h.Height = height
+	blockID := factory.MakeBlockIDWithHash(h.Hash())
+	vals, pv := factory.RandValidatorSet(1, 10)
+	vote, err := factory.MakeVote(pv[0], h.ChainID, 0, h.Height, 0, 2,
+		blockID, factory.DefaultTestTime)
+	require.NoError(t, err)
+
+	sh := &types.SignedHeader{
+		Header: h,
+		Commit: &types.Commit{
+			Height:  h.Height,
+			BlockID: blockID,
+			Signatures: []types.CommitSig{
+				vote.CommitSig(),
+			},
+		},
+	}
+
+	lb := &types.LightBlock{
+		SignedHeader: sh,
 		ValidatorSet: vals,
 	}
 
@@ -440,6 +1376,109 @@ func TestReactor_LightBlockResponse(t *testing.T) {
 	}
 }
 
+func TestReactor_LightBlockResponse_WrongChain(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	var height int64 = 10
+	chain := buildLightBlockChain(t, height, height+1, factory.DefaultTestTime)
+	lb, err := chain[height].ToProto()
+	require.NoError(t, err)
+	lb.SignedHeader.Header.ChainID = "other-chain"
+
+	peer := types.NodeID("aa")
+	blockProvider := NewBlockProvider(peer, factory.DefaultTestChainID, rts.reactor.dispatcher)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := blockProvider.LightBlock(context.Background(), height)
+		errCh <- err
+	}()
+
+	request := <-rts.blockOutCh
+	require.Equal(t, peer, request.To)
+	rts.blockInCh <- p2p.Envelope{
+		From:    peer,
+		Message: &ssproto.LightBlockResponse{LightBlock: lb},
+	}
+
+	// the peer is reported and the light block never reaches the provider
+	peerErr := <-rts.blockPeerErrCh
+	require.Equal(t, peer, peerErr.NodeID)
+	require.Contains(t, peerErr.Err.Error(), "chain ID")
+	require.Equal(t, provider.ErrLightBlockNotFound, <-errCh)
+	require.Nil(t, rts.blockStore.LoadBlockMeta(height))
+}
+
+func TestReactor_LightBlockServeRateLimit(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 10)
+	rts.reactor.cfg.LightBlockServeRateLimit = 2
+
+	peerA, peerB := types.NodeID("aa"), types.NodeID("bb")
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: peerA, Status: p2p.PeerStatusUp}
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: peerB, Status: p2p.PeerStatusUp}
+
+	// requests beyond the limit are dropped, without a peer error
+	for i := 0; i < 5; i++ {
+		rts.blockInCh <- p2p.Envelope{From: peerA, Message: &ssproto.LightBlockRequest{Height: 10}}
+	}
+	for i := 0; i < 2; i++ {
+		response := <-rts.blockOutCh
+		require.Equal(t, peerA, response.To)
+	}
+	require.Never(t, func() bool { return len(rts.blockOutCh) > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+	require.Empty(t, rts.blockPeerErrCh)
+
+	// the limit applies to each peer separately
+	rts.blockInCh <- p2p.Envelope{From: peerB, Message: &ssproto.LightBlockRequest{Height: 10}}
+	response := <-rts.blockOutCh
+	require.Equal(t, peerB, response.To)
+
+	// the state of disconnected peers is cleaned up
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: peerA, Status: p2p.PeerStatusDown}
+	require.Eventually(t, func() bool {
+		rts.reactor.lightBlockMtx.Lock()
+		defer rts.reactor.lightBlockMtx.Unlock()
+		_, ok := rts.reactor.lightBlockMonitors[peerA]
+		return !ok && len(rts.reactor.lightBlockMonitors) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReactor_LightBlockResponseSeenCommit(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	var height int64 = 10
+	vals, pv := factory.RandValidatorSet(1, 10)
+	block := types.MakeBlock(height, nil, &types.Commit{Height: height - 1}, nil)
+	block.ValidatorsHash = vals.Hash()
+	block.ProposerAddress = vals.Validators[0].Address
+	partSet := block.MakePartSet(types.BlockPartSizeBytes)
+	blockID := types.BlockID{Hash: block.Hash(), PartSetHeader: partSet.Header()}
+	vote, err := factory.MakeVote(pv[0], block.ChainID, 0, height, 0, 2,
+		blockID, factory.DefaultTestTime)
+	require.NoError(t, err)
+	seenCommit := &types.Commit{
+		Height:     height,
+		BlockID:    blockID,
+		Signatures: []types.CommitSig{vote.CommitSig()},
+	}
+
+	// the latest block has no canonical commit yet, only a seen commit
+	rts.blockStore.SaveBlock(block, partSet, seenCommit)
+	require.Nil(t, rts.blockStore.LoadBlockCommit(height))
+
+	rts.stateStore.On("LoadValidators", height).Return(vals, nil)
+
+	lb, err := rts.reactor.fetchLightBlock(uint64(height))
+	require.NoError(t, err)
+	require.NotNil(t, lb)
+	require.Equal(t, block.Hash(), lb.Hash())
+	require.Equal(t, seenCommit.BlockID, lb.Commit.BlockID)
+
+	// the seen commit doesn't cover the previous height
+	lb, err = rts.reactor.fetchLightBlock(uint64(height - 1))
+	require.NoError(t, err)
+	require.Nil(t, lb)
+}
+
 func TestReactor_BlockProviders(t *testing.T) {
 	rts := setup(t, nil, nil, nil, 2)
 	rts.peerUpdateCh <- p2p.PeerUpdate{
@@ -519,10 +1558,16 @@ func TestReactor_StateProviderP2P(t *testing.T) {
 	rts.reactor.cfg.TrustHeight = 1
 	rts.reactor.cfg.TrustHash = fmt.Sprintf("%X", chain[1].Hash())
 	ctx := context.Background()
+	require.Equal(t, light.TrustOptions{}, rts.reactor.TrustOptions())
 	rts.reactor.mtx.Lock()
 	err := rts.reactor.initStateProvider(ctx, factory.DefaultTestChainID, 1)
 	rts.reactor.mtx.Unlock()
 	require.NoError(t, err)
+	require.Equal(t, light.TrustOptions{
+		Period: rts.reactor.cfg.TrustPeriod,
+		Height: 1,
+		Hash:   chain[1].Hash(),
+	}, rts.reactor.TrustOptions())
 	rts.reactor.syncer.stateProvider = rts.reactor.stateProvider
 
 	appHash, err := rts.reactor.stateProvider.AppHash(ctx, 5)
@@ -543,6 +1588,180 @@ func TestReactor_StateProviderP2P(t *testing.T) {
 	})
 	require.NoError(t, err)
 	require.True(t, added)
+
+	// params matching the consensus hash are still rejected if the verifier
+	// disapproves of them
+	var verifiedHeight int64
+	rts.reactor.verifyParams = func(height int64, cp types.ConsensusParams) error {
+		verifiedHeight = height
+		return errors.New("unknown params")
+	}
+	rts.reactor.mtx.Lock()
+	err = rts.reactor.initStateProvider(ctx, factory.DefaultTestChainID, 1)
+	rts.reactor.mtx.Unlock()
+	require.NoError(t, err)
+
+	_, err = rts.reactor.stateProvider.State(ctx, 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown params")
+	require.EqualValues(t, 6, verifiedHeight)
+}
+
+func TestReactor_StateProviderP2P_MaxProviders(t *testing.T) {
+	chain := buildLightBlockChain(t, 1, 2, time.Now())
+	trustedStore := lightdb.New(dbm.NewMemDB())
+	require.NoError(t, trustedStore.SaveLightBlock(chain[1]))
+
+	newProvider := func(c string) *BlockProvider {
+		return NewBlockProvider(types.NodeID(strings.Repeat(c, 2*types.NodeIDByteLength)), factory.DefaultTestChainID, nil)
+	}
+	lc, err := light.NewClientFromTrustedStore(factory.DefaultTestChainID, time.Hour,
+		newProvider("a"), []provider.Provider{newProvider("b")}, trustedStore)
+	require.NoError(t, err)
+
+	sp := &stateProviderP2P{lc: lc, maxProviders: 3}
+
+	// providers are added until the limit is reached, and kept as spares beyond it
+	sp.addProvider(newProvider("c"))
+	sp.addProvider(newProvider("d"))
+	sp.addProvider(newProvider("e"))
+	require.Len(t, lc.Witnesses(), 2)
+	require.Equal(t, []provider.Provider{newProvider("d"), newProvider("e")}, sp.spares)
+
+	// spares whose peer went down are dropped
+	sp.removeProvider(newProvider("d").peer)
+	require.Equal(t, []provider.Provider{newProvider("e")}, sp.spares)
+
+	// spares are only rotated in once an active provider is gone
+	sp.rotateProviders()
+	require.Len(t, lc.Witnesses(), 2)
+
+	sp.lc, err = light.NewClientFromTrustedStore(factory.DefaultTestChainID, time.Hour,
+		newProvider("a"), []provider.Provider{newProvider("b")}, trustedStore)
+	require.NoError(t, err)
+	sp.rotateProviders()
+	require.Equal(t, []provider.Provider{newProvider("b"), newProvider("e")}, sp.lc.Witnesses())
+	require.Empty(t, sp.spares)
+}
+
+func TestReactor_StateProviderP2P_Quorum(t *testing.T) {
+	chain := buildLightBlockChain(t, 1, 3, time.Now())
+	trustedStore := lightdb.New(dbm.NewMemDB())
+	require.NoError(t, trustedStore.SaveLightBlock(chain[1]))
+
+	// one of the providers serves a conflicting light block
+	vals, pv := factory.RandValidatorSet(3, 10)
+	_, _, conflicting := mockLB(t, 2, factory.DefaultTestTime, factory.MakeBlockID(), vals, pv)
+	newProvider := func(lb *types.LightBlock) *lightmocks.Provider {
+		p := &lightmocks.Provider{}
+		p.On("LightBlock", mock.Anything, int64(2)).Return(lb, nil)
+		return p
+	}
+	lc, err := light.NewClientFromTrustedStore(factory.DefaultTestChainID, time.Hour,
+		newProvider(chain[2]), []provider.Provider{newProvider(chain[2]), newProvider(conflicting)}, trustedStore)
+	require.NoError(t, err)
+	sp := &stateProviderP2P{lc: lc, maxProviders: 3}
+
+	// no quorum is required by default
+	require.NoError(t, sp.crossReference(ctx, chain[2]))
+
+	sp.quorum = 2
+	require.NoError(t, sp.crossReference(ctx, chain[2]))
+
+	sp.quorum = 3
+	err = sp.crossReference(ctx, chain[2])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "short of a quorum of 3")
+}
+
+func TestReactor_ProviderUpdates(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 10)
+	chain := buildLightBlockChain(t, 1, 2, time.Now())
+	trustedStore := lightdb.New(dbm.NewMemDB())
+	require.NoError(t, trustedStore.SaveLightBlock(chain[1]))
+
+	peer := func(c string) types.NodeID {
+		return types.NodeID(strings.Repeat(c, 2*types.NodeIDByteLength))
+	}
+	lc, err := light.NewClientFromTrustedStore(factory.DefaultTestChainID, time.Hour,
+		NewBlockProvider(peer("a"), factory.DefaultTestChainID, nil),
+		[]provider.Provider{NewBlockProvider(peer("b"), factory.DefaultTestChainID, nil)}, trustedStore)
+	require.NoError(t, err)
+	sp := &stateProviderP2P{lc: lc, maxProviders: 3}
+
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = rts.syncer
+	rts.reactor.stateProvider = sp
+	rts.reactor.mtx.Unlock()
+
+	// providers of new peers are added by the queue, beyond the limit as spares
+	for _, c := range []string{"c", "d"} {
+		rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: peer(c), Status: p2p.PeerStatusUp}
+	}
+	require.Eventually(t, func() bool {
+		sp.Lock()
+		defer sp.Unlock()
+		return len(sp.lc.Witnesses()) == 2 && len(sp.spares) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// and removed once their peer goes down
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: peer("d"), Status: p2p.PeerStatusDown}
+	require.Eventually(t, func() bool {
+		sp.Lock()
+		defer sp.Unlock()
+		return len(sp.spares) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Zero(t, rts.reactor.providerUpdates.len())
+
+	rts.reactor.mtx.Lock()
+	rts.reactor.syncer = nil
+	rts.reactor.stateProvider = nil
+	rts.reactor.mtx.Unlock()
+}
+
+func TestReactor_ParamsResponse_Stale(t *testing.T) {
+	r := &Reactor{}
+	r.BaseService = *service.NewBaseService(log.TestingLogger(), "StateSync", r)
+
+	params := types.DefaultConsensusParams()
+	response := func(height uint64) p2p.Envelope {
+		return p2p.Envelope{
+			From:    types.NodeID("aa"),
+			Message: &ssproto.ParamsResponse{Height: height, ConsensusParams: params.ToProto()},
+		}
+	}
+
+	// no state provider, e.g. once state sync has completed
+	require.NoError(t, r.handleParamsMessage(response(5)))
+
+	// a P2P state provider with no outstanding request
+	sp := &stateProviderP2P{paramsRecvCh: make(chan types.ConsensusParams)}
+	r.stateProvider = sp
+	require.NoError(t, r.handleParamsMessage(response(5)))
+
+	// only responses for the outstanding request are delivered
+	sp.setParamsHeight(5)
+	recvCh := make(chan types.ConsensusParams, 1)
+	go func() { recvCh <- <-sp.paramsRecvCh }()
+	require.Eventually(t, func() bool { return sp.deliverParams(5, *params) }, time.Second, 10*time.Millisecond)
+	require.Equal(t, *params, <-recvCh)
+
+	go func() { recvCh <- <-sp.paramsRecvCh }()
+	require.NoError(t, r.handleParamsMessage(response(4)))
+	require.Never(t, func() bool { return len(recvCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	require.NoError(t, r.handleParamsMessage(response(5)))
+	require.Equal(t, *params, <-recvCh)
+
+	// invalid params are rejected without being delivered
+	invalid := *params
+	invalid.Block.MaxBytes = 0
+	go func() { recvCh <- <-sp.paramsRecvCh }()
+	err := r.handleParamsMessage(p2p.Envelope{
+		From:    types.NodeID("aa"),
+		Message: &ssproto.ParamsResponse{Height: 5, ConsensusParams: invalid.ToProto()},
+	})
+	require.Error(t, err)
+	require.Never(t, func() bool { return len(recvCh) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
 }
 
 func TestReactor_Backfill(t *testing.T) {
@@ -606,22 +1825,1104 @@ func TestReactor_Backfill(t *testing.T) {
 
 				require.Nil(t, rts.blockStore.LoadBlockMeta(stopHeight-1))
 				require.Nil(t, rts.blockStore.LoadBlockMeta(startHeight+1))
+				require.Equal(t, int(startHeight-stopHeight+1), rts.reactor.BackfilledBlocks())
 			}
 		})
 	}
 }
 
-// retryUntil will continue to evaluate fn and will return successfully when true
-// or fail when the timeout is reached.
-func retryUntil(t *testing.T, fn func() bool, timeout time.Duration) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+func TestReactor_BackfillSingleHeight(t *testing.T) {
+	var (
+		height   int64 = 10
+		stopTime       = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+	rts := setup(t, nil, nil, nil, 21)
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("a"), Status: p2p.PeerStatusUp}
 
-	for {
-		if fn() {
-			return
+	chain := buildLightBlockChain(t, height-1, height+1, stopTime)
+	rts.stateStore.On("SaveValidatorSets", height, height, chain[height].ValidatorSet).Return(nil).Once()
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+	// the only block of the range is both the first verified and the terminal one
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		height,
+		height,
+		1,
+		factory.MakeBlockIDWithHash(chain[height].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, rts.blockStore.LoadBlockMeta(height))
+	rts.stateStore.AssertExpectations(t)
+}
+
+func TestReactor_BackfillClosedBeforeVerifying(t *testing.T) {
+	var (
+		height   int64 = 10
+		stopTime       = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+	rts := setup(t, nil, nil, nil, 21)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rts.reactor.backfill(
+			context.Background(),
+			factory.DefaultTestChainID,
+			height,
+			height,
+			1,
+			factory.MakeBlockID(),
+			stopTime,
+		)
+	}()
+
+	// no peer ever serves a light block, and the queue is closed under the
+	// verifier
+	require.Eventually(t, func() bool {
+		rts.reactor.mtx.RLock()
+		defer rts.reactor.mtx.RUnlock()
+		return rts.reactor.backfillQueue != nil
+	}, time.Second, 10*time.Millisecond)
+	rts.reactor.mtx.RLock()
+	rts.reactor.backfillQueue.close()
+	rts.reactor.mtx.RUnlock()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("backfill did not stop after its queue was closed")
+	}
+	rts.stateStore.AssertNotCalled(t, "SaveValidatorSets", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReactor_BackfillEmptyRange(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 21)
+
+	// the start height is below the stop height, so there is nothing to fetch
+	// or save
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		0,
+		1,
+		1,
+		factory.MakeBlockID(),
+		time.Now(),
+	)
+	require.NoError(t, err)
+	require.Nil(t, rts.reactor.backfillQueue)
+	require.Empty(t, rts.blockOutCh)
+	rts.stateStore.AssertNotCalled(t, "SaveValidatorSets", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReactor_VerifyStoreConsistency(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	chain := buildLightBlockChain(t, 1, 11, time.Now())
+	for _, height := range []int64{9, 10} {
+		lb := chain[height]
+		require.NoError(t, rts.blockStore.SaveSignedHeader(lb.SignedHeader, lb.Commit.BlockID))
+	}
+
+	// the block store may be a block ahead of the state
+	for _, stateHeight := range []int64{9, 10} {
+		rts.stateStore.On("Load").Return(sm.State{LastBlockHeight: stateHeight}, nil).Once()
+		require.NoError(t, rts.reactor.VerifyStoreConsistency())
+	}
+
+	for _, stateHeight := range []int64{0, 8, 11} {
+		rts.stateStore.On("Load").Return(sm.State{LastBlockHeight: stateHeight}, nil).Once()
+		require.ErrorIs(t, rts.reactor.VerifyStoreConsistency(), errInconsistentStores)
+	}
+
+	rts.stateStore.On("Load").Return(sm.State{}, errors.New("failed")).Once()
+	err := rts.reactor.VerifyStoreConsistency()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errInconsistentStores)
+}
+
+func TestReactor_FetchHeights(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 21)
+	chain := buildLightBlockChain(t, 1, 11, time.Now())
+
+	// only the headers at heights 5 and 8 are stored
+	for _, height := range []int64{5, 8} {
+		lb := chain[height]
+		require.NoError(t, rts.blockStore.SaveSignedHeader(lb.SignedHeader, lb.Commit.BlockID))
+	}
+
+	for _, peer := range []string{"a", "b"} {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
 		}
-		require.NoError(t, ctx.Err())
+	}
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+	results := rts.reactor.FetchHeights(context.Background(), []int64{3, 6, 7, 9, 5})
+	require.Len(t, results, 5)
+
+	// 9 links to the header below it, 7 to the one above it, and 6 to 7 once it
+	// is recovered. 5 was already stored.
+	for _, height := range []int64{5, 6, 7, 9} {
+		require.NoError(t, results[height], "height %d", height)
+		meta := rts.blockStore.LoadBlockMeta(height)
+		require.NotNil(t, meta)
+		require.Equal(t, chain[height].Hash(), meta.Header.Hash())
+	}
+
+	// 3 has no adjacent header to be verified against
+	require.Error(t, results[3])
+	require.Nil(t, rts.blockStore.LoadBlockMeta(3))
+}
+
+func TestReactor_BackfillStopHeight(t *testing.T) {
+	r := &Reactor{}
+	state := sm.State{
+		InitialHeight:   1,
+		LastBlockHeight: 100,
+		LastBlockTime:   time.Now(),
+		ConsensusParams: types.ConsensusParams{
+			Evidence: types.EvidenceParams{MaxAgeNumBlocks: 40, MaxAgeDuration: time.Hour},
+		},
+	}
+	require.EqualValues(t, 60, r.BackfillStopHeight(state))
+
+	// the stop height doesn't go below the initial height
+	state.InitialHeight = 80
+	require.EqualValues(t, 80, r.BackfillStopHeight(state))
+
+	state.InitialHeight = 1
+	state.ConsensusParams.Evidence.MaxAgeNumBlocks = 200
+	require.EqualValues(t, 1, r.BackfillStopHeight(state))
+}
+
+func TestReactor_BackfillFailurePolicy(t *testing.T) {
+	testcases := map[string]struct {
+		policy   string
+		attempts int
+	}{
+		"proceed": {config.BackfillFailureProceed, 1},
+		"retry":   {config.BackfillFailureRetry, 2},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+			rts := setup(t, nil, nil, nil, 21)
+			rts.reactor.cfg.BackfillFailurePolicy = tc.policy
+			rts.reactor.backfillRetryDelay = 10 * time.Millisecond
+
+			var (
+				startHeight int64 = 20
+				stopHeight  int64 = 10
+				stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+			)
+
+			for _, peer := range []string{"a", "b", "c", "d"} {
+				rts.peerUpdateCh <- p2p.PeerUpdate{
+					NodeID: types.NodeID(peer),
+					Status: p2p.PeerStatusUp,
+				}
+			}
+
+			// the first attempt fails to save the validators
+			rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+				mock.AnythingOfType("*types.ValidatorSet")).Return(errors.New("boom")).Once()
+			rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+				mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+			chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+			closeCh := make(chan struct{})
+			defer close(closeCh)
+			go handleLightBlockRequests(t, chain, rts.blockOutCh,
+				rts.blockInCh, closeCh, 0)
+
+			state := sm.State{
+				ChainID:         factory.DefaultTestChainID,
+				InitialHeight:   1,
+				LastBlockHeight: startHeight,
+				LastBlockID:     factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+				LastBlockTime:   stopTime,
+				ConsensusParams: types.ConsensusParams{
+					Evidence: types.EvidenceParams{MaxAgeNumBlocks: startHeight - stopHeight},
+				},
+			}
+			attempts, err := rts.reactor.runBackfill(context.Background(), state)
+			require.Equal(t, tc.attempts, attempts)
+			if tc.policy == config.BackfillFailureRetry {
+				require.NoError(t, err)
+				for height := stopHeight; height <= startHeight; height++ {
+					require.NotNil(t, rts.blockStore.LoadBlockMeta(height))
+				}
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReactor_BackfillMaxTime(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight   int64 = 20
+		stopHeight    int64 = 10
+		reachedHeight int64 = 15
+		stopTime            = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	peers := []string{"a", "b", "c", "d"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+
+	trackingHeight := startHeight
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(func(lh, uh int64, vals *types.ValidatorSet) error {
+		require.Equal(t, trackingHeight, lh)
+		require.Equal(t, lh, uh)
+		require.GreaterOrEqual(t, lh, reachedHeight)
+		trackingHeight--
+		return nil
+	})
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	// only serve light blocks down to the reached height so that backfill can
+	// never complete within the time limit
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go func() {
+		for {
+			select {
+			case envelope := <-rts.blockOutCh:
+				msg, ok := envelope.Message.(*ssproto.LightBlockRequest)
+				if !ok || int64(msg.Height) < reachedHeight {
+					continue
+				}
+				lb, err := chain[int64(msg.Height)].ToProto()
+				require.NoError(t, err)
+				rts.blockInCh <- p2p.Envelope{
+					From:    envelope.To,
+					Message: &ssproto.LightBlockResponse{LightBlock: lb},
+				}
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+
+	rts.reactor.cfg.MaxBackfillTime = 2 * time.Second
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.ErrorIs(t, err, errBackfillTimeExceeded)
+
+	// the validators of all verified heights should have been saved
+	require.Equal(t, reachedHeight-1, trackingHeight)
+	for height := reachedHeight; height <= startHeight; height++ {
+		require.NotNil(t, rts.blockStore.LoadBlockMeta(height))
+	}
+	require.Nil(t, rts.blockStore.LoadBlockMeta(reachedHeight-1))
+}
+
+func TestReactor_BackfillCheckpoints(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	peers := []string{"a", "b", "c", "d"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+	rts.reactor.cfg.BackfillCheckpointInterval = 5
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+
+	checkpoints, err := rts.blockStore.LoadBackfillCheckpoints()
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	for i, height := range []int64{10, 15, 20} {
+		require.Equal(t, height, checkpoints[i].Height)
+		require.Equal(t, chain[height].Hash(), checkpoints[i].Hash())
+	}
+	require.NoError(t, rts.reactor.VerifyBackfillCheckpoints())
+
+	// a checkpoint that does not belong to the stored chain fails verification
+	vals, pv := factory.RandValidatorSet(3, 10)
+	_, _, forged := mockLB(t, 12, stopTime, factory.MakeBlockID(), vals, pv)
+	require.NoError(t, rts.blockStore.SaveBackfillCheckpoint(forged))
+	require.Error(t, rts.reactor.VerifyBackfillCheckpoints())
+}
+
+func TestReactor_BackfillVerifiedFunc(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	peers := []string{"a", "b", "c", "d"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+	// the func can't be set once the reactor is running
+	require.Error(t, rts.reactor.SetBackfillVerifiedFunc(nil))
+
+	var heights []int64
+	rts.reactor.backfillVerified = func(height int64, validators *types.ValidatorSet) {
+		require.Equal(t, chain[height].ValidatorSet.Hash(), validators.Hash())
+		heights = append(heights, height)
+	}
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+
+	// blocks are verified from the top down, down to the block terminating
+	// backfill
+	require.Len(t, heights, int(startHeight-stopHeight+1))
+	for i, height := range heights {
+		require.Equal(t, startHeight-int64(i), height)
+	}
+}
+
+func TestReactor_BackfillVerifyCommits(t *testing.T) {
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		breakHeight int64 = 15
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	// build a chain with a valid hash chain, in which the commit of the block
+	// at breakHeight carries forged signatures, which the hash chain doesn't
+	// cover
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+	commit := *chain[breakHeight].Commit
+	commit.Signatures = append([]types.CommitSig(nil), commit.Signatures...)
+	for i := range commit.Signatures {
+		commit.Signatures[i].Signature = []byte("forged signature")
+	}
+	chain[breakHeight].Commit = &commit
+
+	testcases := []struct {
+		name   string
+		verify bool
+	}{
+		{"verification enabled", true},
+		{"verification disabled", false},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+			rts := setup(t, nil, nil, nil, 21)
+
+			peers := []string{"a", "b", "c", "d"}
+			for _, peer := range peers {
+				rts.peerUpdateCh <- p2p.PeerUpdate{
+					NodeID: types.NodeID(peer),
+					Status: p2p.PeerStatusUp,
+				}
+			}
+
+			rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+				mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+			closeCh := make(chan struct{})
+			defer close(closeCh)
+			go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+			peerErrCount := 0
+			peerErrCloseCh := make(chan struct{})
+			peerErrDoneCh := make(chan struct{})
+			go func() {
+				defer close(peerErrDoneCh)
+				for {
+					select {
+					case <-rts.blockPeerErrCh:
+						peerErrCount++
+					case <-peerErrCloseCh:
+						return
+					}
+				}
+			}()
+
+			rts.reactor.cfg.BackfillVerifyCommits = tc.verify
+			err := rts.reactor.backfill(
+				context.Background(),
+				factory.DefaultTestChainID,
+				startHeight,
+				stopHeight,
+				1,
+				factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+				stopTime,
+			)
+
+			close(peerErrCloseCh)
+			<-peerErrDoneCh
+
+			if !tc.verify {
+				require.NoError(t, err)
+				require.Zero(t, peerErrCount)
+				for height := stopHeight; height <= startHeight; height++ {
+					require.NotNil(t, rts.blockStore.LoadBlockMeta(height))
+				}
+				return
+			}
+
+			require.Error(t, err)
+			for height := breakHeight + 1; height <= startHeight; height++ {
+				require.NotNil(t, rts.blockStore.LoadBlockMeta(height))
+			}
+			require.Nil(t, rts.blockStore.LoadBlockMeta(breakHeight))
+			require.Greater(t, peerErrCount, 0)
+		})
+	}
+}
+
+// batchCountingDB counts the batches created on the underlying database, which
+// the block store uses to save signed headers.
+type batchCountingDB struct {
+	dbm.DB
+	batches int
+}
+
+func (db *batchCountingDB) NewBatch() dbm.Batch {
+	db.batches++
+	return db.DB.NewBatch()
+}
+
+func TestReactor_BackfillDuplicateResponses(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	peers := []string{"a", "b", "c", "d"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	// one of the heights has already been stored, e.g. by an earlier backfill
+	db := &batchCountingDB{DB: dbm.NewMemDB()}
+	rts.reactor.blockStore = store.NewBlockStore(db)
+	require.NoError(t, rts.reactor.blockStore.SaveSignedHeader(chain[15].SignedHeader, chain[16].LastBlockID))
+	db.batches = 0
+
+	// respond twice to every request
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go func() {
+		for {
+			select {
+			case envelope := <-rts.blockOutCh:
+				msg, ok := envelope.Message.(*ssproto.LightBlockRequest)
+				require.True(t, ok)
+				lb, err := chain[int64(msg.Height)].ToProto()
+				require.NoError(t, err)
+				for i := 0; i < 2; i++ {
+					rts.blockInCh <- p2p.Envelope{
+						From:    envelope.To,
+						Message: &ssproto.LightBlockResponse{LightBlock: lb},
+					}
+				}
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+
+	for height := startHeight; height >= stopHeight; height-- {
+		require.NotNil(t, rts.reactor.blockStore.LoadBlockMeta(height))
+	}
+	// a single write per height, none for the one already stored
+	require.Equal(t, int(startHeight-stopHeight), db.batches)
+
+	// a conflicting header at a stored height is rejected
+	vals, pv := factory.RandValidatorSet(3, 10)
+	_, _, conflicting := mockLB(t, 15, stopTime, factory.MakeBlockID(), vals, pv)
+	require.Error(t, rts.reactor.saveSignedHeader(conflicting.SignedHeader, factory.MakeBlockID()))
+}
+
+func TestReactor_BackfillCrossCheck(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+		badPeer           = types.NodeID("a")
+	)
+
+	peers := []string{"a", "b", "c"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+	require.Eventually(t, func() bool {
+		return rts.reactor.numConnectedPeers() == len(peers)
+	}, time.Second, 10*time.Millisecond)
+
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	// the bad peer serves blocks with an altered commit signature, which
+	// passes all the other checks of backfill but is caught by the cross-check
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go func() {
+		for {
+			select {
+			case envelope := <-rts.blockOutCh:
+				msg, ok := envelope.Message.(*ssproto.LightBlockRequest)
+				if !ok {
+					continue
+				}
+				lb, err := chain[int64(msg.Height)].ToProto()
+				require.NoError(t, err)
+				if envelope.To == badPeer {
+					commit := *lb.SignedHeader.Commit
+					commit.Signatures = append([]tmproto.CommitSig(nil), commit.Signatures...)
+					commit.Signatures[0].Signature = []byte("bad signature")
+					lb.SignedHeader.Commit = &commit
+				}
+				rts.blockInCh <- p2p.Envelope{
+					From:    envelope.To,
+					Message: &ssproto.LightBlockResponse{LightBlock: lb},
+				}
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+
+	var peerErrs []p2p.PeerError
+	peerErrCloseCh := make(chan struct{})
+	peerErrDoneCh := make(chan struct{})
+	go func() {
+		defer close(peerErrDoneCh)
+		for {
+			select {
+			case peerErr := <-rts.blockPeerErrCh:
+				peerErrs = append(peerErrs, peerErr)
+			case <-peerErrCloseCh:
+				return
+			}
+		}
+	}()
+
+	rts.reactor.cfg.BackfillCrossCheck = true
+	rts.reactor.cfg.BackfillVerifyCommits = false
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+
+	close(peerErrCloseCh)
+	<-peerErrDoneCh
+
+	// only the bad peer is reported, and only the blocks agreed on by the
+	// majority are stored
+	require.NotEmpty(t, peerErrs)
+	for _, peerErr := range peerErrs {
+		require.Equal(t, badPeer, peerErr.NodeID)
+	}
+	for height := stopHeight; height <= startHeight; height++ {
+		commit := rts.blockStore.LoadBlockCommit(height)
+		require.NotNil(t, commit)
+		require.Equal(t, chain[height].Commit.Signatures[0].Signature, commit.Signatures[0].Signature)
+	}
+}
+
+func TestReactor_BackfillCrossCheckCancel(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		height     int64 = 10
+		stopTime         = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+		silentPeer       = types.NodeID("c")
+	)
+
+	peers := []string{"a", "b", "c"}
+	for _, peer := range peers {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+	require.Eventually(t, func() bool {
+		return rts.reactor.numConnectedPeers() == len(peers)
+	}, time.Second, 10*time.Millisecond)
+
+	chain := buildLightBlockChain(t, height, height+1, stopTime)
+
+	// the silent peer never responds, but once the other peer agrees with the
+	// light block, its request is canceled instead of waited for
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go func() {
+		for {
+			select {
+			case envelope := <-rts.blockOutCh:
+				if _, ok := envelope.Message.(*ssproto.LightBlockRequest); !ok || envelope.To == silentPeer {
+					continue
+				}
+				lb, err := chain[height].ToProto()
+				require.NoError(t, err)
+				rts.blockInCh <- p2p.Envelope{
+					From:    envelope.To,
+					Message: &ssproto.LightBlockResponse{LightBlock: lb},
+				}
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+
+	rts.reactor.cfg.BackfillRequestTimeout = time.Minute
+	start := time.Now()
+	confirmed, err := rts.reactor.crossCheckLightBlock(context.Background(), lightBlockResponse{
+		block: chain[height],
+		peer:  types.NodeID("a"),
+	})
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	// the silent peer is free for other requests right away
+	require.False(t, rts.reactor.dispatcher.Cancel(height, silentPeer))
+}
+
+func TestReactor_BackfillCrossCheckNotEnoughPeers(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 21)
+
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+	)
+
+	rts.peerUpdateCh <- p2p.PeerUpdate{NodeID: types.NodeID("a"), Status: p2p.PeerStatusUp}
+	require.Eventually(t, func() bool {
+		return rts.reactor.numConnectedPeers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go handleLightBlockRequests(t, chain, rts.blockOutCh, rts.blockInCh, closeCh, 0)
+
+	// a single peer can never be confirmed by another one
+	rts.reactor.cfg.BackfillCrossCheck = true
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.ErrorIs(t, err, errCrossCheckPeers)
+}
+
+func TestReactor_BackfillMismatchPeers(t *testing.T) {
+	var (
+		startHeight int64 = 20
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+		badPeer           = types.NodeID("a")
+	)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+
+	// the bad peer serves valid light blocks of another chain history, whose
+	// hashes don't match the trusted block IDs
+	badChain := make(map[int64]*types.LightBlock, len(chain))
+	for height := range chain {
+		vals, pv := factory.RandValidatorSet(3, 10)
+		_, _, badChain[height] = mockLB(t, height, factory.DefaultTestTime, factory.MakeBlockID(), vals, pv)
+	}
+
+	testcases := []struct {
+		name          string
+		mismatchPeers int
+		expectErrs    bool
+	}{
+		{"report right away", 1, true},
+		{"report once two peers disagree", 2, false},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+			rts := setup(t, nil, nil, nil, 21)
+
+			for _, peer := range []string{"a", "b", "c"} {
+				rts.peerUpdateCh <- p2p.PeerUpdate{
+					NodeID: types.NodeID(peer),
+					Status: p2p.PeerStatusUp,
+				}
+			}
+
+			rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+				mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+			closeCh := make(chan struct{})
+			defer close(closeCh)
+			go func() {
+				for {
+					select {
+					case envelope := <-rts.blockOutCh:
+						msg, ok := envelope.Message.(*ssproto.LightBlockRequest)
+						if !ok {
+							continue
+						}
+						lb := chain[int64(msg.Height)]
+						if envelope.To == badPeer {
+							lb = badChain[int64(msg.Height)]
+						}
+						pb, err := lb.ToProto()
+						require.NoError(t, err)
+						rts.blockInCh <- p2p.Envelope{
+							From:    envelope.To,
+							Message: &ssproto.LightBlockResponse{LightBlock: pb},
+						}
+					case <-closeCh:
+						return
+					}
+				}
+			}()
+
+			var peerErrs []p2p.PeerError
+			peerErrCloseCh := make(chan struct{})
+			peerErrDoneCh := make(chan struct{})
+			go func() {
+				defer close(peerErrDoneCh)
+				for {
+					select {
+					case peerErr := <-rts.blockPeerErrCh:
+						peerErrs = append(peerErrs, peerErr)
+					case <-peerErrCloseCh:
+						return
+					}
+				}
+			}()
+
+			rts.reactor.cfg.BackfillMismatchPeers = tc.mismatchPeers
+			err := rts.reactor.backfill(
+				context.Background(),
+				factory.DefaultTestChainID,
+				startHeight,
+				stopHeight,
+				1,
+				factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+				stopTime,
+			)
+			require.NoError(t, err)
+
+			close(peerErrCloseCh)
+			<-peerErrDoneCh
+
+			// only the bad peer ever disagrees with the trusted block IDs, so it
+			// is only reported if a single peer is enough
+			if !tc.expectErrs {
+				require.Empty(t, peerErrs)
+				return
+			}
+			require.NotEmpty(t, peerErrs)
+			for _, peerErr := range peerErrs {
+				require.Equal(t, badPeer, peerErr.NodeID)
+			}
+		})
+	}
+}
+
+func TestReactor_BackfillWrongHeight(t *testing.T) {
+	t.Cleanup(leaktest.CheckTimeout(t, 1*time.Minute))
+	var (
+		startHeight int64 = 30
+		stopHeight  int64 = 10
+		stopTime          = time.Date(2020, 1, 1, 0, 100, 0, 0, time.UTC)
+		badPeer           = types.NodeID("a")
+	)
+
+	chain := buildLightBlockChain(t, stopHeight-1, startHeight+1, stopTime)
+	rts := setup(t, nil, nil, nil, 21)
+
+	for _, peer := range []string{"a", "b"} {
+		rts.peerUpdateCh <- p2p.PeerUpdate{
+			NodeID: types.NodeID(peer),
+			Status: p2p.PeerStatusUp,
+		}
+	}
+
+	rts.stateStore.On("SaveValidatorSets", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"),
+		mock.AnythingOfType("*types.ValidatorSet")).Return(nil)
+
+	// the bad peer always returns the valid light block below the requested one
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	go func() {
+		for {
+			select {
+			case envelope := <-rts.blockOutCh:
+				msg, ok := envelope.Message.(*ssproto.LightBlockRequest)
+				if !ok {
+					continue
+				}
+				height := int64(msg.Height)
+				if envelope.To == badPeer {
+					height--
+				}
+				pb, err := chain[height].ToProto()
+				require.NoError(t, err)
+				rts.blockInCh <- p2p.Envelope{
+					From:    envelope.To,
+					Message: &ssproto.LightBlockResponse{LightBlock: pb},
+				}
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+
+	var peerErrs []p2p.PeerError
+	peerErrCloseCh := make(chan struct{})
+	peerErrDoneCh := make(chan struct{})
+	go func() {
+		defer close(peerErrDoneCh)
+		for {
+			select {
+			case peerErr := <-rts.blockPeerErrCh:
+				peerErrs = append(peerErrs, peerErr)
+			case <-peerErrCloseCh:
+				return
+			}
+		}
+	}()
+
+	err := rts.reactor.backfill(
+		context.Background(),
+		factory.DefaultTestChainID,
+		startHeight,
+		stopHeight,
+		1,
+		factory.MakeBlockIDWithHash(chain[startHeight].Header.Hash()),
+		stopTime,
+	)
+	require.NoError(t, err)
+
+	close(peerErrCloseCh)
+	<-peerErrDoneCh
+
+	// the bad peer is reported once it repeatedly returned wrong heights
+	require.NotEmpty(t, peerErrs)
+	for _, peerErr := range peerErrs {
+		require.Equal(t, badPeer, peerErr.NodeID)
+		require.Contains(t, peerErr.Err.Error(), "wrong height")
+	}
+}
+
+func TestVerifierIdle(t *testing.T) {
+	start := time.Now()
+	idle := newVerifierIdle(start, time.Minute)
+
+	// nothing is reported until the window is over
+	_, ok := idle.add(start.Add(30*time.Second), 20*time.Second)
+	require.False(t, ok)
+
+	percent, ok := idle.add(start.Add(time.Minute), 25*time.Second)
+	require.True(t, ok)
+	require.Equal(t, 75, percent)
+
+	// and the next window starts afresh
+	_, ok = idle.add(start.Add(90*time.Second), 0)
+	require.False(t, ok)
+	percent, ok = idle.add(start.Add(2*time.Minute), 6*time.Second)
+	require.True(t, ok)
+	require.Equal(t, 10, percent)
+}
+
+func TestCheckStopTime(t *testing.T) {
+	stopTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	lightBlock := func(height int64, blockTime time.Time) *types.LightBlock {
+		return &types.LightBlock{SignedHeader: &types.SignedHeader{
+			Header: &types.Header{Height: height, Time: blockTime},
+		}}
+	}
+
+	testcases := map[string]struct {
+		terminal  *types.LightBlock
+		expectErr bool
+	}{
+		"stopped at stop height":           {lightBlock(10, stopTime.Add(-48*time.Hour)), false},
+		"stopped at initial height":        {lightBlock(1, stopTime.Add(-48*time.Hour)), false},
+		"stopped on time within tolerance": {lightBlock(5, stopTime.Add(-time.Minute)), false},
+		"stopped on time past tolerance":   {lightBlock(5, stopTime.Add(-48*time.Hour)), true},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := checkStopTime(tc.terminal, 10, 1, stopTime)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReactor_TempDirNamespace(t *testing.T) {
+	dir := t.TempDir()
+	r := &Reactor{tempDir: namespacedTempDir(dir, "tm-statesync", factory.DefaultTestChainID, types.NodeID("aa"))}
+	require.Equal(t, filepath.Join(dir, "tm-statesync-"+factory.DefaultTestChainID+"-aa"), r.tempDir)
+
+	// another node sharing the same parent directory
+	otherDir := namespacedTempDir(dir, "tm-statesync", factory.DefaultTestChainID, types.NodeID("bb"))
+	require.NoError(t, os.MkdirAll(otherDir, 0700))
+	otherFile := filepath.Join(otherDir, "chunk")
+	require.NoError(t, ioutil.WriteFile(otherFile, []byte{1}, 0600))
+
+	// stale files from a previous sync are removed when preparing the dir
+	require.NoError(t, os.MkdirAll(r.tempDir, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(r.tempDir, "chunk"), []byte{1}, 0600))
+	require.NoError(t, r.resetTempDir())
+	files, err := ioutil.ReadDir(r.tempDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	require.NoError(t, r.cleanupTempDir())
+	require.NoDirExists(t, r.tempDir)
+	require.FileExists(t, otherFile)
+}
+
+// retryUntil will continue to evaluate fn and will return successfully when true
+// or fail when the timeout is reached.
+func retryUntil(t *testing.T, fn func() bool, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		if fn() {
+			return
+		}
+		require.NoError(t, ctx.Err())
+	}
+}
+
+// advertiseVersion has peer advertise a protocol version on every channel of
+// rts, ahead of the messages it sends on them afterwards.
+func advertiseVersion(rts *reactorTestSuite, peer types.NodeID, version uint32) {
+	for _, inCh := range []chan p2p.Envelope{rts.snapshotInCh, rts.chunkInCh, rts.blockInCh, rts.paramsInCh} {
+		inCh <- p2p.Envelope{From: peer, Message: &ssproto.Capabilities{Version: version}}
 	}
 }
 
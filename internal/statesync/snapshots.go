@@ -1,8 +1,12 @@
 package statesync
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"sort"
 	"strings"
@@ -14,13 +18,16 @@ import (
 // snapshotKey is a snapshot key used for lookups.
 type snapshotKey [sha256.Size]byte
 
-// snapshot contains data about a snapshot.
+// snapshot contains data about a snapshot. A snapshot with a non-zero
+// BaseHeight is a delta, which only applies on top of the app state at that
+// height.
 type snapshot struct {
-	Height   uint64
-	Format   uint32
-	Chunks   uint32
-	Hash     []byte
-	Metadata []byte
+	Height     uint64
+	Format     uint32
+	Chunks     uint32
+	Hash       []byte
+	Metadata   []byte
+	BaseHeight uint64
 
 	trustedAppHash []byte // populated by light client
 }
@@ -31,7 +38,7 @@ type snapshot struct {
 func (s *snapshot) Key() snapshotKey {
 	// Hash.Write() never returns an error.
 	hasher := sha256.New()
-	hasher.Write([]byte(fmt.Sprintf("%v:%v:%v", s.Height, s.Format, s.Chunks)))
+	hasher.Write([]byte(fmt.Sprintf("%v:%v:%v:%v", s.Height, s.Format, s.Chunks, s.BaseHeight)))
 	hasher.Write(s.Hash)
 	hasher.Write(s.Metadata)
 	var key snapshotKey
@@ -39,6 +46,97 @@ func (s *snapshot) Key() snapshotKey {
 	return key
 }
 
+// maxRejectedSnapshots is the maximum number of rejected snapshots kept around
+// for status reporting. Peers can advertise any number of snapshots, so only
+// the latest rejections are kept.
+const maxRejectedSnapshots = 100
+
+// maxSnapshotMetadataSize is the maximum size of decompressed snapshot
+// metadata, which bounds the memory used by a malicious compressed payload.
+const maxSnapshotMetadataSize = 16 * 1024 * 1024 // 16MB
+
+// compressMetadata gzips snapshot metadata for transmission to peers.
+func compressMetadata(metadata []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(metadata); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressMetadata reverses compressMetadata, erroring if the decompressed
+// metadata exceeds maxSnapshotMetadataSize.
+func decompressMetadata(compressed []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	metadata, err := ioutil.ReadAll(io.LimitReader(r, maxSnapshotMetadataSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) > maxSnapshotMetadataSize {
+		return nil, fmt.Errorf("decompressed metadata exceeds %d bytes", maxSnapshotMetadataSize)
+	}
+	return metadata, nil
+}
+
+// SnapshotStatus describes the acceptance status of a snapshot discovered
+// during state sync.
+type SnapshotStatus string
+
+const (
+	// SnapshotStatusPending is the status of a snapshot that is a candidate for
+	// restoration.
+	SnapshotStatusPending SnapshotStatus = "pending"
+	// SnapshotStatusApplying is the status of the snapshot currently being
+	// restored.
+	SnapshotStatusApplying SnapshotStatus = "applying"
+	// SnapshotStatusRejected is the status of a snapshot that was rejected and
+	// will never be used again.
+	SnapshotStatusRejected SnapshotStatus = "rejected"
+)
+
+// SnapshotInfo describes a snapshot discovered during state sync. The chunks
+// and hash of rejected snapshots aren't reported.
+type SnapshotInfo struct {
+	Height uint64
+	Format uint32
+	Chunks uint32
+	Hash   []byte
+	Peers  int
+	Status SnapshotStatus
+
+	// RejectReason is the reason the snapshot was rejected, if it was.
+	RejectReason string
+}
+
+// rejectedSnapshot records why a snapshot was rejected. The hash and metadata
+// of the snapshot aren't kept, since their size is up to the peers.
+type rejectedSnapshot struct {
+	key    snapshotKey
+	Height uint64
+	Format uint32
+	Reason string
+}
+
+// SelectedSnapshot describes the snapshot restored by a state sync, along with
+// the peers that advertised it.
+type SelectedSnapshot struct {
+	Height     uint64
+	Format     uint32
+	Chunks     uint32
+	Hash       []byte
+	BaseHeight uint64
+	Peers      []types.NodeID
+}
+
 // snapshotPool discovers and aggregates snapshots across peers.
 type snapshotPool struct {
 	tmsync.Mutex
@@ -54,6 +152,10 @@ type snapshotPool struct {
 	formatBlacklist   map[uint32]bool
 	peerBlacklist     map[types.NodeID]bool
 	snapshotBlacklist map[snapshotKey]bool
+
+	// the last maxRejectedSnapshots rejected snapshots, oldest first, kept
+	// around for status reporting
+	rejectedSnapshots []*rejectedSnapshot
 }
 
 // newSnapshotPool creates a new empty snapshot pool.
@@ -159,7 +261,11 @@ func (p *snapshotPool) GetPeers(snapshot *snapshot) []types.NodeID {
 
 // Ranked returns a list of snapshots ranked by preference. The current heuristic is very naïve,
 // preferring the snapshot with the greatest height, then greatest format, then greatest number of
-// peers. This can be improved quite a lot.
+// peers. This can be improved quite a lot. Delta snapshots are always preferred over full ones,
+// since the pool only holds deltas that apply to the local app state.
+//
+// Breaking ties by the number of distinct peers advertising a snapshot biases the selection
+// towards widely available snapshots, whose chunks are more likely to be fetched to completion.
 func (p *snapshotPool) Ranked() []*snapshot {
 	p.Lock()
 	defer p.Unlock()
@@ -194,7 +300,11 @@ func (p *snapshotPool) Ranked() []*snapshot {
 	sort.Slice(commonCandidates, p.sorterFactory(commonCandidates))
 	sort.Slice(uncommonCandidates, p.sorterFactory(uncommonCandidates))
 
-	return append(commonCandidates, uncommonCandidates...)
+	ranked := append(commonCandidates, uncommonCandidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].BaseHeight > 0 && ranked[j].BaseHeight == 0
+	})
+	return ranked
 }
 
 func (p *snapshotPool) sorterFactory(candidates []*snapshot) func(int, int) bool {
@@ -207,25 +317,25 @@ func (p *snapshotPool) sorterFactory(candidates []*snapshot) func(int, int) bool
 			return true
 		case a.Height < b.Height:
 			return false
-		case len(p.snapshotPeers[a.Key()]) > len(p.snapshotPeers[b.Key()]):
-			return true
 		case a.Format > b.Format:
 			return true
 		case a.Format < b.Format:
 			return false
 		default:
-			return false
+			return len(p.snapshotPeers[a.Key()]) > len(p.snapshotPeers[b.Key()])
 		}
 	}
 }
 
-// Reject rejects a snapshot. Rejected snapshots will never be used again.
-func (p *snapshotPool) Reject(snapshot *snapshot) {
+// Reject rejects a snapshot for the given reason. Rejected snapshots will
+// never be used again.
+func (p *snapshotPool) Reject(snapshot *snapshot, reason string) {
 	key := snapshot.Key()
 	p.Lock()
 	defer p.Unlock()
 
 	p.snapshotBlacklist[key] = true
+	p.addRejected(key, snapshot, reason)
 	p.removeSnapshot(key)
 }
 
@@ -236,10 +346,81 @@ func (p *snapshotPool) RejectFormat(format uint32) {
 
 	p.formatBlacklist[format] = true
 	for key := range p.formatIndex[format] {
+		p.addRejected(key, p.snapshots[key], fmt.Sprintf("format %d rejected", format))
 		p.removeSnapshot(key)
 	}
 }
 
+// addRejected records the rejection of a snapshot, forgetting the oldest one
+// once maxRejectedSnapshots are recorded. The caller must hold the mutex lock.
+func (p *snapshotPool) addRejected(key snapshotKey, snapshot *snapshot, reason string) {
+	for i, rejected := range p.rejectedSnapshots {
+		if rejected.key == key {
+			p.rejectedSnapshots = append(p.rejectedSnapshots[:i], p.rejectedSnapshots[i+1:]...)
+			break
+		}
+	}
+	if len(p.rejectedSnapshots) >= maxRejectedSnapshots {
+		p.rejectedSnapshots = p.rejectedSnapshots[1:]
+	}
+	p.rejectedSnapshots = append(p.rejectedSnapshots, &rejectedSnapshot{
+		key:    key,
+		Height: snapshot.Height,
+		Format: snapshot.Format,
+		Reason: reason,
+	})
+}
+
+// Rejected returns the last maxRejectedSnapshots snapshots that have been
+// rejected, either directly or by format, sorted by descending height and
+// format.
+func (p *snapshotPool) Rejected() []*rejectedSnapshot {
+	p.Lock()
+	defer p.Unlock()
+
+	rejected := make([]*rejectedSnapshot, len(p.rejectedSnapshots))
+	copy(rejected, p.rejectedSnapshots)
+	sort.Slice(rejected, func(i, j int) bool {
+		a, b := rejected[i], rejected[j]
+		if a.Height != b.Height {
+			return a.Height > b.Height
+		}
+		return a.Format > b.Format
+	})
+
+	return rejected
+}
+
+// RejectReason returns the reason a snapshot was rejected, or an empty string
+// if it wasn't or its rejection is no longer recorded.
+func (p *snapshotPool) RejectReason(snapshot *snapshot) string {
+	key := snapshot.Key()
+	p.Lock()
+	defer p.Unlock()
+
+	for _, rejected := range p.rejectedSnapshots {
+		if rejected.key == key {
+			return rejected.Reason
+		}
+	}
+	return ""
+}
+
+// RejectedFormats returns the snapshot formats that have been rejected, in
+// ascending order.
+func (p *snapshotPool) RejectedFormats() []uint32 {
+	p.Lock()
+	defer p.Unlock()
+
+	formats := make([]uint32, 0, len(p.formatBlacklist))
+	for format := range p.formatBlacklist {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i] < formats[j] })
+
+	return formats
+}
+
 // RejectPeer rejects a peer. It will never be used again.
 func (p *snapshotPool) RejectPeer(peerID types.NodeID) {
 	if len(peerID) == 0 {
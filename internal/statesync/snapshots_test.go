@@ -1,6 +1,7 @@
 package statesync
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -17,6 +18,7 @@ func TestSnapshot_Key(t *testing.T) {
 		"new chunk count": {func(s *snapshot) { s.Chunks = 9 }},
 		"new hash":        {func(s *snapshot) { s.Hash = []byte{9} }},
 		"no metadata":     {func(s *snapshot) { s.Metadata = nil }},
+		"new base height": {func(s *snapshot) { s.BaseHeight = 2 }},
 	}
 	for name, tc := range testcases {
 		tc := tc
@@ -160,12 +162,54 @@ func TestSnapshotPool_Ranked_Best(t *testing.T) {
 	for i := range expectSnapshots {
 		snapshot := expectSnapshots[i].snapshot
 		require.Equal(t, snapshot, pool.Best())
-		pool.Reject(snapshot)
+		pool.Reject(snapshot, "test")
 	}
 
 	require.Nil(t, pool.Best())
 }
 
+func TestSnapshotPool_Ranked_PeersTieBreaker(t *testing.T) {
+	pool := newSnapshotPool()
+
+	// a and b are both served by more peers than the median of 3, and differ
+	// only in their number of peers, which alone decides their order. c and d
+	// are below the median, where the format ranks c first despite its fewer
+	// peers.
+	a := &snapshot{Height: 5, Format: 1, Chunks: 1, Hash: []byte{1}}
+	b := &snapshot{Height: 5, Format: 1, Chunks: 1, Hash: []byte{2}}
+	c := &snapshot{Height: 5, Format: 2, Chunks: 1, Hash: []byte{3}}
+	d := &snapshot{Height: 5, Format: 1, Chunks: 1, Hash: []byte{4}}
+	for s, peers := range map[*snapshot][]types.NodeID{
+		a: {"AA", "BB", "CC", "DD", "EE", "FF"},
+		b: {"AA", "BB", "CC", "DD", "EE"},
+		c: {"AA"},
+		d: {"AA", "BB"},
+	} {
+		for _, peer := range peers {
+			_, err := pool.Add(peer, s)
+			require.NoError(t, err)
+		}
+	}
+
+	require.Equal(t, []*snapshot{a, b, c, d}, pool.Ranked())
+}
+
+func TestSnapshotPool_Ranked_Delta(t *testing.T) {
+	pool := newSnapshotPool()
+
+	// delta snapshots are preferred over full ones, even lower and less common
+	full := &snapshot{Height: 10, Format: 1, Chunks: 4, Hash: []byte{1}}
+	delta := &snapshot{Height: 8, Format: 1, Chunks: 1, Hash: []byte{2}, BaseHeight: 5}
+	for _, peerID := range []types.NodeID{"AA", "BB", "CC"} {
+		_, err := pool.Add(peerID, full)
+		require.NoError(t, err)
+	}
+	_, err := pool.Add("AA", delta)
+	require.NoError(t, err)
+
+	require.Equal(t, []*snapshot{delta, full}, pool.Ranked())
+}
+
 func TestSnapshotPool_Reject(t *testing.T) {
 	pool := newSnapshotPool()
 
@@ -182,8 +226,10 @@ func TestSnapshotPool_Reject(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	pool.Reject(snapshots[0])
+	pool.Reject(snapshots[0], "test")
 	require.Equal(t, snapshots[1:], pool.Ranked())
+	require.Equal(t, "test", pool.RejectReason(snapshots[0]))
+	require.Empty(t, pool.RejectReason(snapshots[1]))
 
 	added, err := pool.Add(peerID, snapshots[0])
 	require.NoError(t, err)
@@ -194,6 +240,29 @@ func TestSnapshotPool_Reject(t *testing.T) {
 	require.True(t, added)
 }
 
+func TestSnapshotPool_RejectedLimit(t *testing.T) {
+	pool := newSnapshotPool()
+
+	snapshots := make([]*snapshot, maxRejectedSnapshots+1)
+	for i := range snapshots {
+		snapshots[i] = &snapshot{Height: uint64(i + 1), Format: 1, Chunks: 1, Hash: []byte{1}}
+		pool.Reject(snapshots[i], fmt.Sprintf("reason %d", i))
+	}
+
+	// only the latest rejections are kept around, without the snapshots'
+	// hashes or metadata
+	rejected := pool.Rejected()
+	require.Len(t, rejected, maxRejectedSnapshots)
+	require.EqualValues(t, maxRejectedSnapshots+1, rejected[0].Height)
+	require.Equal(t, fmt.Sprintf("reason %d", maxRejectedSnapshots), rejected[0].Reason)
+	require.Empty(t, pool.RejectReason(snapshots[0]))
+
+	// but forgotten rejections are still never used again
+	added, err := pool.Add(types.NodeID("aa"), snapshots[0])
+	require.NoError(t, err)
+	require.False(t, added)
+}
+
 func TestSnapshotPool_RejectFormat(t *testing.T) {
 	pool := newSnapshotPool()
 
@@ -212,6 +281,8 @@ func TestSnapshotPool_RejectFormat(t *testing.T) {
 
 	pool.RejectFormat(1)
 	require.Equal(t, []*snapshot{snapshots[0], snapshots[2]}, pool.Ranked())
+	require.Equal(t, []uint32{1}, pool.RejectedFormats())
+	require.Equal(t, "format 1 rejected", pool.RejectReason(snapshots[1]))
 
 	added, err := pool.Add(peerID, &snapshot{Height: 3, Format: 1, Chunks: 1, Hash: []byte{1}})
 	require.NoError(t, err)
@@ -299,3 +370,21 @@ func TestSnapshotPool_RemovePeer(t *testing.T) {
 	require.Equal(t, peerAID, peers1[0])
 	require.Equal(t, peerBID, peers1[1])
 }
+
+func TestCompressMetadata(t *testing.T) {
+	metadata := []byte("metadata")
+	compressed, err := compressMetadata(metadata)
+	require.NoError(t, err)
+	decompressed, err := decompressMetadata(compressed)
+	require.NoError(t, err)
+	require.Equal(t, metadata, decompressed)
+
+	_, err = decompressMetadata(metadata)
+	require.Error(t, err)
+
+	// payloads that decompress beyond the limit are rejected
+	compressed, err = compressMetadata(make([]byte, maxSnapshotMetadataSize+1))
+	require.NoError(t, err)
+	_, err = decompressMetadata(compressed)
+	require.Error(t, err)
+}
@@ -196,28 +196,66 @@ func rpcClient(server string) (*rpchttp.HTTP, error) {
 	return rpchttp.New(server)
 }
 
+// ParamsVerifier is called by the P2P state provider with the consensus params
+// received from peers for a height, once they have been checked against the
+// consensus hash of the verified header. The params are only accepted if it
+// returns nil, which allows chains changing their params through governance to
+// check them against a known history.
+type ParamsVerifier func(height int64, cp types.ConsensusParams) error
+
 type stateProviderP2P struct {
 	tmsync.Mutex  // light.Client is not concurrency-safe
 	lc            *light.Client
 	initialHeight int64
 	paramsSendCh  chan<- p2p.Envelope
 	paramsRecvCh  chan types.ConsensusParams
+	verifyParams  ParamsVerifier // optional, may be nil
+
+	// the height of the outstanding consensus params request, or 0 if none.
+	// Guarded by its own mutex as responses are delivered while a request holds
+	// the main lock.
+	paramsMtx    tmsync.Mutex
+	paramsHeight int64
+
+	maxProviders int                      // max number of providers used by the light client
+	spares       []lightprovider.Provider // providers rotated in when active ones fail
+	quorum       int                      // number of providers that must agree on a light block, or 0
 }
 
 // NewP2PStateProvider creates a light client state
-// provider but uses a dispatcher connected to the P2P layer
+// provider but uses a dispatcher connected to the P2P layer. At most
+// maxProviders of the given providers are used by the light client, the
+// remaining ones are kept as spares. If quorum is positive, that many providers
+// must serve the light block verified at the height holding the app hash. If
+// verifyParams is not nil, it must approve the consensus params received from
+// peers before they are accepted.
 func NewP2PStateProvider(
 	ctx context.Context,
 	chainID string,
 	initialHeight int64,
 	providers []lightprovider.Provider,
+	maxProviders int,
+	quorum int,
 	trustOptions light.TrustOptions,
 	paramsSendCh chan<- p2p.Envelope,
+	verifyParams ParamsVerifier,
 	logger log.Logger,
 ) (StateProvider, error) {
 	if len(providers) < 2 {
 		return nil, fmt.Errorf("at least 2 peers are required, got %d", len(providers))
 	}
+	if maxProviders < 2 {
+		return nil, fmt.Errorf("at least 2 providers must be allowed, got %d", maxProviders)
+	}
+	if quorum > maxProviders {
+		return nil, fmt.Errorf("a quorum of %d can't be reached with at most %d providers", quorum, maxProviders)
+	}
+
+	var spares []lightprovider.Provider
+	if len(providers) > maxProviders {
+		spares = append(spares, providers[maxProviders:]...)
+		providers = providers[:maxProviders]
+	}
 
 	lc, err := light.NewClient(ctx, chainID, trustOptions, providers[0], providers[1:],
 		lightdb.New(dbm.NewMemDB()), light.Logger(logger))
@@ -230,6 +268,10 @@ func NewP2PStateProvider(
 		initialHeight: initialHeight,
 		paramsSendCh:  paramsSendCh,
 		paramsRecvCh:  make(chan types.ConsensusParams),
+		verifyParams:  verifyParams,
+		maxProviders:  maxProviders,
+		spares:        spares,
+		quorum:        quorum,
 	}, nil
 }
 
@@ -237,12 +279,16 @@ func NewP2PStateProvider(
 func (s *stateProviderP2P) AppHash(ctx context.Context, height uint64) ([]byte, error) {
 	s.Lock()
 	defer s.Unlock()
+	defer s.rotateProviders()
 
 	// We have to fetch the next height, which contains the app hash for the previous height.
 	header, err := s.lc.VerifyLightBlockAtHeight(ctx, int64(height+1), time.Now())
 	if err != nil {
 		return nil, err
 	}
+	if err := s.crossReference(ctx, header); err != nil {
+		return nil, err
+	}
 
 	// We also try to fetch the blocks at H+2, since we need these
 	// when building the state while restoring the snapshot. This avoids the race
@@ -258,6 +304,7 @@ func (s *stateProviderP2P) AppHash(ctx context.Context, height uint64) ([]byte,
 func (s *stateProviderP2P) Commit(ctx context.Context, height uint64) (*types.Commit, error) {
 	s.Lock()
 	defer s.Unlock()
+	defer s.rotateProviders()
 	header, err := s.lc.VerifyLightBlockAtHeight(ctx, int64(height), time.Now())
 	if err != nil {
 		return nil, err
@@ -269,6 +316,7 @@ func (s *stateProviderP2P) Commit(ctx context.Context, height uint64) (*types.Co
 func (s *stateProviderP2P) State(ctx context.Context, height uint64) (sm.State, error) {
 	s.Lock()
 	defer s.Unlock()
+	defer s.rotateProviders()
 
 	state := sm.State{
 		ChainID:       s.lc.ChainID(),
@@ -323,23 +371,109 @@ func (s *stateProviderP2P) State(ctx context.Context, height uint64) (sm.State,
 		return sm.State{}, fmt.Errorf("consensus params hash mismatch at height %d. Expected %v, got %v",
 			currentLightBlock.Height, nextLightBlock.ConsensusHash, state.ConsensusParams.HashConsensusParams())
 	}
+	if s.verifyParams != nil {
+		if err := s.verifyParams(currentLightBlock.Height, state.ConsensusParams); err != nil {
+			return sm.State{}, fmt.Errorf("consensus params at height %d rejected: %w", currentLightBlock.Height, err)
+		}
+	}
 	// set the last height changed to the current height
 	state.LastHeightConsensusParamsChanged = currentLightBlock.Height
 
 	return state, nil
 }
 
-// addProvider dynamically adds a peer as a new witness. A limit of 6 providers is kept as a
+// crossReference requests the light block at the height of the verified one
+// from all the providers of the light client concurrently, returning an error
+// unless at least quorum of them serve a block with the same hash. It returns
+// as soon as the quorum is reached or can no longer be. The caller must hold
+// the lock.
+func (s *stateProviderP2P) crossReference(ctx context.Context, verified *types.LightBlock) error {
+	if s.quorum <= 0 {
+		return nil
+	}
+	providers := append([]lightprovider.Provider{s.lc.Primary()}, s.lc.Witnesses()...)
+	if len(providers) < s.quorum {
+		return fmt.Errorf("only %d providers available for a quorum of %d", len(providers), s.quorum)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	agreeCh := make(chan bool, len(providers))
+	for _, p := range providers {
+		go func(p lightprovider.Provider) {
+			lb, err := p.LightBlock(ctx, verified.Height)
+			agreeCh <- err == nil && bytes.Equal(lb.Hash(), verified.Hash())
+		}(p)
+	}
+
+	agreed, pending := 0, len(providers)
+	for agreed < s.quorum {
+		if agreed+pending < s.quorum {
+			return fmt.Errorf("only %d of %d providers agree on the light block at height %d, short of a quorum of %d",
+				agreed, len(providers), verified.Height, s.quorum)
+		}
+		select {
+		case agree := <-agreeCh:
+			pending--
+			if agree {
+				agreed++
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// addProvider dynamically adds a peer as a new witness. At most maxProviders are kept as a
 // heuristic. Too many overburdens the network and too little compromises the second layer of security.
+// Peers beyond the limit are kept as spares.
 func (s *stateProviderP2P) addProvider(p lightprovider.Provider) {
-	if len(s.lc.Witnesses()) < 6 {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.numProviders() < s.maxProviders {
 		s.lc.AddProvider(p)
+		return
+	}
+	s.spares = append(s.spares, p)
+}
+
+// removeProvider drops the spare provider for the given peer. Active providers
+// are removed by the light client itself when they fail.
+func (s *stateProviderP2P) removeProvider(peer types.NodeID) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i, p := range s.spares {
+		if bp, ok := p.(*BlockProvider); ok && bp.peer == peer {
+			s.spares = append(s.spares[:i], s.spares[i+1:]...)
+			return
+		}
 	}
 }
 
+// rotateProviders replaces the providers the light client dropped with spares.
+// The caller must hold the lock.
+func (s *stateProviderP2P) rotateProviders() {
+	for s.numProviders() < s.maxProviders && len(s.spares) > 0 {
+		s.lc.AddProvider(s.spares[0])
+		s.spares = s.spares[1:]
+	}
+}
+
+// numProviders returns the number of providers used by the light client,
+// including the primary.
+func (s *stateProviderP2P) numProviders() int {
+	return len(s.lc.Witnesses()) + 1
+}
+
 // consensusParams sends out a request for consensus params blocking until one is returned.
 // If it fails to get a valid set of consensus params from any of the providers it returns an error.
 func (s *stateProviderP2P) consensusParams(ctx context.Context, height int64) (types.ConsensusParams, error) {
+	s.setParamsHeight(height)
+	defer s.setParamsHeight(0)
+
 	for _, provider := range s.lc.Witnesses() {
 		p, ok := provider.(*BlockProvider)
 		if !ok {
@@ -378,3 +512,28 @@ func (s *stateProviderP2P) consensusParams(ctx context.Context, height int64) (t
 	}
 	return types.ConsensusParams{}, errors.New("unable to fetch consensus params from connected providers")
 }
+
+// setParamsHeight sets the height of the outstanding consensus params request.
+func (s *stateProviderP2P) setParamsHeight(height int64) {
+	s.paramsMtx.Lock()
+	defer s.paramsMtx.Unlock()
+	s.paramsHeight = height
+}
+
+// deliverParams hands the consensus params received from a peer to the
+// outstanding request for the given height. It returns false if there is no
+// such request, e.g. because the response arrived after the request timed out.
+func (s *stateProviderP2P) deliverParams(height uint64, params types.ConsensusParams) bool {
+	s.paramsMtx.Lock()
+	defer s.paramsMtx.Unlock()
+
+	if s.paramsHeight == 0 || s.paramsHeight != int64(height) {
+		return false
+	}
+	select {
+	case s.paramsRecvCh <- params:
+		return true
+	default:
+		return false
+	}
+}
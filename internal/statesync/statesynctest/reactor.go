@@ -0,0 +1,203 @@
+package statesynctest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/internal/p2p"
+	"github.com/tendermint/tendermint/internal/statesync"
+	"github.com/tendermint/tendermint/internal/test/factory"
+	"github.com/tendermint/tendermint/libs/log"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	"github.com/tendermint/tendermint/proxy"
+	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/store"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Reactor is a state sync reactor wired to in-memory p2p channels and stores,
+// so that an app's snapshot integration can be tested against the real
+// reactor. Peers are simulated by sending peer updates and messages on the
+// inbound channels, and by reading the messages the reactor sends them from
+// the outbound channels.
+type Reactor struct {
+	*statesync.Reactor
+
+	App        proxy.AppConnSnapshot
+	Query      proxy.AppConnQuery
+	StateStore sm.Store
+	BlockStore *store.BlockStore
+
+	// The messages sent by the reactor to peers on each channel.
+	SnapshotOut   <-chan p2p.Envelope
+	ChunkOut      <-chan p2p.Envelope
+	LightBlockOut <-chan p2p.Envelope
+	ParamsOut     <-chan p2p.Envelope
+
+	// The errors reported by the reactor about peers on any channel.
+	PeerErrors <-chan p2p.PeerError
+
+	snapshotIn   chan p2p.Envelope
+	chunkIn      chan p2p.Envelope
+	lightBlockIn chan p2p.Envelope
+	paramsIn     chan p2p.Envelope
+	peerUpdateCh chan p2p.PeerUpdate
+}
+
+// ReactorOptions is an argument structure to parameterize the MakeReactor
+// function.
+type ReactorOptions struct {
+	// The state sync config, or the default one if nil.
+	Config *config.StateSyncConfig
+	// The chain ID, or factory.DefaultTestChainID if empty.
+	ChainID string
+	// The app connections, or mocks without any expectations if nil.
+	App   proxy.AppConnSnapshot
+	Query proxy.AppConnQuery
+	// The buffer size of the p2p channels, or 10 if 0.
+	BufferSize int
+}
+
+func (opts *ReactorOptions) setDefaults() {
+	if opts.Config == nil {
+		opts.Config = config.DefaultStateSyncConfig()
+	}
+	if opts.ChainID == "" {
+		opts.ChainID = factory.DefaultTestChainID
+	}
+	if opts.App == nil {
+		opts.App = &proxymocks.AppConnSnapshot{}
+	}
+	if opts.Query == nil {
+		opts.Query = &proxymocks.AppConnQuery{}
+	}
+	if opts.BufferSize == 0 {
+		opts.BufferSize = 10
+	}
+}
+
+// MakeReactor creates and starts a state sync reactor wired to in-memory
+// channels and stores, stopping it once the test completes.
+func MakeReactor(t *testing.T, opts ReactorOptions) *Reactor {
+	t.Helper()
+	opts.setDefaults()
+
+	var (
+		peerErrCh    = make(chan p2p.PeerError, opts.BufferSize)
+		snapshotOut  = make(chan p2p.Envelope, opts.BufferSize)
+		chunkOut     = make(chan p2p.Envelope, opts.BufferSize)
+		lightOut     = make(chan p2p.Envelope, opts.BufferSize)
+		paramsOut    = make(chan p2p.Envelope, opts.BufferSize)
+		peerUpdateCh = make(chan p2p.PeerUpdate, opts.BufferSize)
+	)
+	r := &Reactor{
+		App:           opts.App,
+		Query:         opts.Query,
+		StateStore:    sm.NewStore(dbm.NewMemDB()),
+		BlockStore:    store.NewBlockStore(dbm.NewMemDB()),
+		SnapshotOut:   snapshotOut,
+		ChunkOut:      chunkOut,
+		LightBlockOut: lightOut,
+		ParamsOut:     paramsOut,
+		PeerErrors:    peerErrCh,
+		snapshotIn:    make(chan p2p.Envelope, opts.BufferSize),
+		chunkIn:       make(chan p2p.Envelope, opts.BufferSize),
+		lightBlockIn:  make(chan p2p.Envelope, opts.BufferSize),
+		paramsIn:      make(chan p2p.Envelope, opts.BufferSize),
+		peerUpdateCh:  peerUpdateCh,
+	}
+
+	r.Reactor = statesync.NewReactor(
+		opts.ChainID,
+		1,
+		types.NodeID("00ff"),
+		*opts.Config,
+		log.TestingLogger(),
+		opts.App,
+		opts.Query,
+		p2p.NewChannel(statesync.SnapshotChannel, new(ssproto.Message), r.snapshotIn, snapshotOut, peerErrCh),
+		p2p.NewChannel(statesync.ChunkChannel, new(ssproto.Message), r.chunkIn, chunkOut, peerErrCh),
+		p2p.NewChannel(statesync.LightBlockChannel, new(ssproto.Message), r.lightBlockIn, lightOut, peerErrCh),
+		p2p.NewChannel(statesync.ParamsChannel, new(ssproto.Message), r.paramsIn, paramsOut, peerErrCh),
+		p2p.NewPeerUpdates(peerUpdateCh, opts.BufferSize),
+		r.StateStore,
+		r.BlockStore,
+		t.TempDir(),
+		statesync.NopMetrics(),
+	)
+
+	require.NoError(t, r.Start())
+	t.Cleanup(func() {
+		require.NoError(t, r.Stop())
+	})
+
+	return r
+}
+
+// AddPeer connects a peer to the reactor.
+func (r *Reactor) AddPeer(peerID types.NodeID) {
+	r.peerUpdateCh <- p2p.PeerUpdate{NodeID: peerID, Status: p2p.PeerStatusUp}
+}
+
+// RemovePeer disconnects a peer from the reactor.
+func (r *Reactor) RemovePeer(peerID types.NodeID) {
+	r.peerUpdateCh <- p2p.PeerUpdate{NodeID: peerID, Status: p2p.PeerStatusDown}
+}
+
+// AdvertiseSnapshot has a peer advertise a snapshot to the reactor, as if in
+// response to a snapshots request.
+func (r *Reactor) AdvertiseSnapshot(peerID types.NodeID, snapshot abci.Snapshot) {
+	r.snapshotIn <- p2p.Envelope{
+		From: peerID,
+		Message: &ssproto.SnapshotsResponse{
+			Height:   snapshot.Height,
+			Format:   snapshot.Format,
+			Chunks:   snapshot.Chunks,
+			Hash:     snapshot.Hash,
+			Metadata: snapshot.Metadata,
+		},
+	}
+}
+
+// SendChunk has a peer send a snapshot chunk to the reactor, as if in response
+// to a chunk request. A nil chunk is sent as missing.
+func (r *Reactor) SendChunk(peerID types.NodeID, height uint64, format, index uint32, chunk []byte) {
+	r.chunkIn <- p2p.Envelope{
+		From: peerID,
+		Message: &ssproto.ChunkResponse{
+			Height:  height,
+			Format:  format,
+			Index:   index,
+			Chunk:   chunk,
+			Missing: chunk == nil,
+		},
+	}
+}
+
+// RequestSnapshots has a peer request the snapshots served by the reactor's app.
+func (r *Reactor) RequestSnapshots(peerID types.NodeID) {
+	r.snapshotIn <- p2p.Envelope{From: peerID, Message: &ssproto.SnapshotsRequest{}}
+}
+
+// RequestChunk has a peer request a snapshot chunk served by the reactor's app.
+func (r *Reactor) RequestChunk(peerID types.NodeID, height uint64, format, index uint32) {
+	r.chunkIn <- p2p.Envelope{
+		From:    peerID,
+		Message: &ssproto.ChunkRequest{Height: height, Format: format, Index: index},
+	}
+}
+
+// SendLightBlock has a peer send a message on the light block channel.
+func (r *Reactor) SendLightBlock(peerID types.NodeID, msg *ssproto.LightBlockResponse) {
+	r.lightBlockIn <- p2p.Envelope{From: peerID, Message: msg}
+}
+
+// SendParams has a peer send a message on the params channel.
+func (r *Reactor) SendParams(peerID types.NodeID, msg *ssproto.ParamsResponse) {
+	r.paramsIn <- p2p.Envelope{From: peerID, Message: msg}
+}
@@ -0,0 +1,51 @@
+package statesynctest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/internal/statesync/statesynctest"
+	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
+	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestReactor_ServesAppSnapshots(t *testing.T) {
+	app := &proxymocks.AppConnSnapshot{}
+	app.On("ListSnapshotsSync", mock.Anything, abci.RequestListSnapshots{}).Return(&abci.ResponseListSnapshots{
+		Snapshots: []*abci.Snapshot{{Height: 3, Format: 1, Chunks: 2, Hash: []byte{3}}},
+	}, nil)
+	app.On("LoadSnapshotChunkSync", mock.Anything, abci.RequestLoadSnapshotChunk{
+		Height: 3, Format: 1, Chunk: 1,
+	}).Return(&abci.ResponseLoadSnapshotChunk{Chunk: []byte{3, 1, 1}}, nil)
+
+	r := statesynctest.MakeReactor(t, statesynctest.ReactorOptions{App: app})
+	peerID := types.NodeID("aa")
+
+	r.RequestSnapshots(peerID)
+	select {
+	case envelope := <-r.SnapshotOut:
+		require.Equal(t, peerID, envelope.To)
+		msg, ok := envelope.Message.(*ssproto.SnapshotsResponse)
+		require.True(t, ok)
+		require.EqualValues(t, 3, msg.Height)
+		require.EqualValues(t, 2, msg.Chunks)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for snapshots")
+	}
+
+	r.RequestChunk(peerID, 3, 1, 1)
+	select {
+	case envelope := <-r.ChunkOut:
+		require.Equal(t, peerID, envelope.To)
+		msg, ok := envelope.Message.(*ssproto.ChunkResponse)
+		require.True(t, ok)
+		require.Equal(t, []byte{3, 1, 1}, msg.Chunk)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for chunk")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -23,6 +24,10 @@ const (
 	// chunkTimeout is the timeout while waiting for the next chunk from the chunk queue.
 	chunkTimeout = 2 * time.Minute
 
+	// availabilityCheckInterval is how often the peers advertising the snapshot
+	// being restored are checked for.
+	availabilityCheckInterval = 10 * time.Second
+
 	// minimumDiscoveryTime is the lowest allowable time for a
 	// SyncAny discovery time.
 	minimumDiscoveryTime = 5 * time.Second
@@ -46,6 +51,12 @@ var (
 	errTimeout = errors.New("timed out waiting for chunk")
 	// errNoSnapshots is returned by SyncAny() if no snapshots are found and discovery is disabled.
 	errNoSnapshots = errors.New("no suitable snapshots found")
+	// errInsufficientChunkPeers is returned by Sync() when fewer peers than required serve a
+	// sample chunk of the snapshot.
+	errInsufficientChunkPeers = errors.New("insufficient peers serving snapshot chunks")
+	// errSnapshotUnavailable is returned by Sync() when no peer has advertised the snapshot for
+	// longer than the availability grace period.
+	errSnapshotUnavailable = errors.New("no peers serve the snapshot")
 )
 
 // syncer runs a state sync against an ABCI app. Use either SyncAny() to automatically attempt to
@@ -53,6 +64,7 @@ var (
 // snapshot. Snapshots and chunks are fed via AddSnapshot() and AddChunk() as appropriate.
 type syncer struct {
 	logger        log.Logger
+	tracer        Tracer
 	stateProvider StateProvider
 	conn          proxy.AppConnSnapshot
 	connQuery     proxy.AppConnQuery
@@ -63,22 +75,202 @@ type syncer struct {
 	fetchers      int32
 	retryTimeout  time.Duration
 
-	mtx    tmsync.RWMutex
-	chunks *chunkQueue
+	// whether to verify the snapshot's light blocks before fetching chunks
+	verifyBeforeDownload bool
+
+	// the minimum number of distinct peers that must serve a sample chunk of
+	// a snapshot before it is restored, or 0 to restore it right away
+	minChunkPeers int
+
+	// how long no peer may advertise the snapshot being restored before it's
+	// abandoned, or 0 to never abandon it, and how often peers are checked
+	availabilityGrace         time.Duration
+	availabilityCheckInterval time.Duration
+
+	// the height of the last breaking upgrade, below which snapshots are
+	// rejected, or 0 to accept snapshots of any height
+	minUpgradeHeight uint64
+
+	// the height of the local app state, on top of which delta snapshots can
+	// be applied, or 0 if the app has no state to apply deltas to
+	baseHeight uint64
+
+	// transfers logs chunk requests and responses, or is nil if they aren't
+	// logged
+	transfers *chunkTransferLog
+
+	// whether to keep the files of applied chunks until the snapshot is
+	// restored, so that a restarted sync doesn't download them again
+	retainChunks bool
+	// the prefix of the name of the directory retaining a snapshot's chunks
+	tempDirPrefix string
+
+	mtx      tmsync.RWMutex
+	chunks   *chunkQueue
+	prefetch *headerPrefetch
+	probe    *chunkProbe
+	selected *SelectedSnapshot // the snapshot restored by SyncAny, if any
+}
+
+// chunkProbePeerMargin is the number of peers probed for a snapshot chunk on
+// top of the number of peers required to serve it, so that a slow peer or two
+// don't fail the probe.
+const chunkProbePeerMargin = 2
+
+// chunkProbe collects the peers that serve the sample chunk requested from a
+// sample of the peers advertising a snapshot, before committing to restore it.
+type chunkProbe struct {
+	height     uint64
+	format     uint32
+	baseHeight uint64
+	index      uint32
+	needed     int
+
+	mtx    tmsync.Mutex
+	probed map[types.NodeID]bool // the peers the chunk was requested from
+	peers  map[types.NodeID]bool // the peers that served the chunk
+	doneCh chan struct{}         // closed once needed peers served the chunk
+}
+
+// add records that the sender of the chunk served it, returning false if the
+// chunk isn't the sample chunk of the probe. Chunks from peers that weren't
+// probed, or received once needed peers served the chunk, are ignored.
+func (p *chunkProbe) add(chunk *chunk) bool {
+	if chunk.Height != p.height || chunk.Format != p.format || chunk.BaseHeight != p.baseHeight ||
+		chunk.Index != p.index {
+		return false
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if chunk.Chunk == nil || !p.probed[chunk.Sender] || p.peers[chunk.Sender] || len(p.peers) >= p.needed {
+		return true
+	}
+	p.peers[chunk.Sender] = true
+	if len(p.peers) == p.needed {
+		close(p.doneCh)
+	}
+	return true
+}
+
+// served returns the number of distinct peers that served the sample chunk.
+func (p *chunkProbe) served() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return len(p.peers)
+}
+
+// markProbed records that the chunk was requested from the peer.
+func (p *chunkProbe) markProbed(peer types.NodeID) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.probed[peer] = true
+}
+
+// chunkRequest identifies a chunk requested from a peer.
+type chunkRequest struct {
+	height     uint64
+	format     uint32
+	baseHeight uint64
+	index      uint32
+	peer       types.NodeID
+}
+
+// chunkTransferLog logs the chunk requests sent during a sync and the
+// responses received for them, timing how long each peer took to respond. All
+// its methods are no-ops on a nil log.
+type chunkTransferLog struct {
+	logger log.Logger
+
+	mtx  tmsync.Mutex
+	sent map[chunkRequest]time.Time // outstanding requests, by the time they were sent
+}
+
+func newChunkTransferLog(logger log.Logger) *chunkTransferLog {
+	return &chunkTransferLog{
+		logger: logger,
+		sent:   make(map[chunkRequest]time.Time),
+	}
+}
+
+// request logs the request of a chunk of the snapshot from a peer.
+func (l *chunkTransferLog) request(snapshot *snapshot, index uint32, peer types.NodeID) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	l.sent[chunkRequest{snapshot.Height, snapshot.Format, snapshot.BaseHeight, index, peer}] = time.Now()
+	l.mtx.Unlock()
+
+	l.logger.Info("Sent chunk request", "height", snapshot.Height, "format", snapshot.Format,
+		"chunk", index, "peer", peer)
+}
+
+// response logs the response to a chunk request, along with the time since
+// the request was sent. Responses to requests that weren't logged are logged
+// as unsolicited.
+func (l *chunkTransferLog) response(chunk *chunk) {
+	if l == nil {
+		return
+	}
+	key := chunkRequest{chunk.Height, chunk.Format, chunk.BaseHeight, chunk.Index, chunk.Sender}
+	l.mtx.Lock()
+	sentAt, ok := l.sent[key]
+	delete(l.sent, key)
+	l.mtx.Unlock()
+
+	if !ok {
+		l.logger.Info("Received unsolicited chunk response", "height", chunk.Height, "format", chunk.Format,
+			"chunk", chunk.Index, "peer", chunk.Sender, "size", len(chunk.Chunk), "missing", chunk.Chunk == nil)
+		return
+	}
+	l.logger.Info("Received chunk response", "height", chunk.Height, "format", chunk.Format,
+		"chunk", chunk.Index, "peer", chunk.Sender, "size", len(chunk.Chunk), "missing", chunk.Chunk == nil,
+		"latency", time.Since(sentAt))
+}
+
+// reset forgets the outstanding requests, once the sync they belong to is over.
+func (l *chunkTransferLog) reset() {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.sent = make(map[chunkRequest]time.Time)
+}
+
+// headerPrefetch is a speculative fetch of the trusted app hash at the height
+// of the next-best snapshot, run while the current snapshot is being restored
+// so that falling back to it on failure doesn't have to start cold.
+type headerPrefetch struct {
+	height uint64
+	cancel context.CancelFunc
+	doneCh chan struct{}
+
+	// only safe to read once doneCh is closed
+	appHash []byte
+	err     error
 }
 
 // newSyncer creates a new syncer.
 func newSyncer(
 	cfg config.StateSyncConfig,
 	logger log.Logger,
+	tracer Tracer,
 	conn proxy.AppConnSnapshot,
 	connQuery proxy.AppConnQuery,
 	stateProvider StateProvider,
 	snapshotCh, chunkCh chan<- p2p.Envelope,
 	tempDir string,
 ) *syncer {
+	var transfers *chunkTransferLog
+	if cfg.LogChunkTransfers {
+		transfers = newChunkTransferLog(logger)
+	}
+
 	return &syncer{
 		logger:        logger,
+		tracer:        tracer,
 		stateProvider: stateProvider,
 		conn:          conn,
 		connQuery:     connQuery,
@@ -88,14 +280,31 @@ func newSyncer(
 		tempDir:       tempDir,
 		fetchers:      cfg.Fetchers,
 		retryTimeout:  cfg.ChunkRequestTimeout,
+
+		verifyBeforeDownload: cfg.VerifySnapshotBeforeDownload,
+		minChunkPeers:        cfg.MinChunkServingPeers,
+		availabilityGrace:    cfg.SnapshotAvailabilityGracePeriod,
+		minUpgradeHeight:     uint64(cfg.MinUpgradeHeight),
+		transfers:            transfers,
+		retainChunks:         cfg.RetainAppliedChunks,
+		tempDirPrefix:        cfg.TempDirPrefix,
+
+		availabilityCheckInterval: availabilityCheckInterval,
 	}
 }
 
 // AddChunk adds a chunk to the chunk queue, if any. It returns false if the chunk has already
 // been added to the queue, or an error if there's no sync in progress.
 func (s *syncer) AddChunk(chunk *chunk) (bool, error) {
+	s.transfers.response(chunk)
+
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
+	if s.probe != nil && s.probe.add(chunk) {
+		s.logger.Debug("Received probed chunk", "height", chunk.Height, "format", chunk.Format,
+			"chunk", chunk.Index, "peer", chunk.Sender)
+		return false, nil
+	}
 	if s.chunks == nil {
 		return false, errors.New("no state sync in progress")
 	}
@@ -114,15 +323,28 @@ func (s *syncer) AddChunk(chunk *chunk) (bool, error) {
 }
 
 // AddSnapshot adds a snapshot to the snapshot pool. It returns true if a new, previously unseen
-// snapshot was accepted and added.
+// snapshot was accepted and added. Delta snapshots that don't apply to the local app state are
+// refused with an error, and snapshots taken before the upgrade height are rejected without being
+// offered.
 func (s *syncer) AddSnapshot(peerID types.NodeID, snapshot *snapshot) (bool, error) {
+	if snapshot.BaseHeight != 0 && (snapshot.BaseHeight != s.baseHeight || snapshot.BaseHeight >= snapshot.Height) {
+		return false, fmt.Errorf("delta snapshot from height %d to %d does not apply to app state at height %d",
+			snapshot.BaseHeight, snapshot.Height, s.baseHeight)
+	}
+	if snapshot.Height < s.minUpgradeHeight {
+		reason := fmt.Sprintf("taken before upgrade height %d", s.minUpgradeHeight)
+		s.snapshots.Reject(snapshot, reason)
+		s.logger.Info("Snapshot rejected as taken before the upgrade height", "height", snapshot.Height,
+			"format", snapshot.Format, "hash", snapshot.Hash, "peer", peerID, "upgradeHeight", s.minUpgradeHeight)
+		return false, nil
+	}
 	added, err := s.snapshots.Add(peerID, snapshot)
 	if err != nil {
 		return false, err
 	}
 	if added {
 		s.logger.Info("Discovered new snapshot", "height", snapshot.Height, "format", snapshot.Format,
-			"hash", snapshot.Hash)
+			"baseHeight", snapshot.BaseHeight, "hash", snapshot.Hash)
 	}
 	return added, nil
 }
@@ -133,7 +355,17 @@ func (s *syncer) AddPeer(peerID types.NodeID) {
 	s.logger.Debug("Requesting snapshots from peer", "peer", peerID)
 	s.snapshotCh <- p2p.Envelope{
 		To:      peerID,
-		Message: &ssproto.SnapshotsRequest{},
+		Message: newSnapshotsRequest(s.baseHeight),
+	}
+}
+
+// newSnapshotsRequest returns a request for the snapshots of a peer, including
+// the delta snapshots that apply on top of the app state at baseHeight, if
+// non-zero.
+func newSnapshotsRequest(baseHeight uint64) *ssproto.SnapshotsRequest {
+	return &ssproto.SnapshotsRequest{
+		AcceptCompressedMetadata: true,
+		BaseHeight:               baseHeight,
 	}
 }
 
@@ -143,8 +375,54 @@ func (s *syncer) RemovePeer(peerID types.NodeID) {
 	s.snapshots.RemovePeer(peerID)
 }
 
-// SyncAny tries to sync any of the snapshots in the snapshot pool, waiting to discover further
-// snapshots if none were found and discoveryTime > 0. It returns the latest state and block commit
+// Snapshots returns the snapshots known to the syncer along with their
+// acceptance status. Pending snapshots are ordered by preference and followed
+// by the rejected ones.
+func (s *syncer) Snapshots() []SnapshotInfo {
+	var applying *snapshot
+	s.mtx.RLock()
+	if s.chunks != nil {
+		s.chunks.Lock()
+		applying = s.chunks.snapshot
+		s.chunks.Unlock()
+	}
+	s.mtx.RUnlock()
+
+	ranked := s.snapshots.Ranked()
+	rejected := s.snapshots.Rejected()
+	infos := make([]SnapshotInfo, 0, len(ranked)+len(rejected))
+	for _, snapshot := range ranked {
+		status := SnapshotStatusPending
+		if applying != nil && snapshot.Key() == applying.Key() {
+			status = SnapshotStatusApplying
+		}
+		infos = append(infos, s.snapshotInfo(snapshot, status))
+	}
+	for _, r := range rejected {
+		infos = append(infos, SnapshotInfo{
+			Height:       r.Height,
+			Format:       r.Format,
+			Status:       SnapshotStatusRejected,
+			RejectReason: r.Reason,
+		})
+	}
+
+	return infos
+}
+
+func (s *syncer) snapshotInfo(snapshot *snapshot, status SnapshotStatus) SnapshotInfo {
+	return SnapshotInfo{
+		Height: snapshot.Height,
+		Format: snapshot.Format,
+		Chunks: snapshot.Chunks,
+		Hash:   snapshot.Hash,
+		Peers:  len(s.snapshots.GetPeers(snapshot)),
+		Status: status,
+	}
+}
+
+// SyncAny tries to sync any of the snapshots in the snapshot pool, requesting and waiting to
+// discover further snapshots if none were found and discoveryTime > 0. It returns the latest state and block commit
 // which the caller must use to bootstrap the node.
 func (s *syncer) SyncAny(
 	ctx context.Context,
@@ -156,11 +434,12 @@ func (s *syncer) SyncAny(
 	}
 
 	if discoveryTime > 0 {
-		requestSnapshots()
-		s.logger.Info(fmt.Sprintf("Discovering snapshots for %v", discoveryTime))
-		time.Sleep(discoveryTime)
+		s.discover(ctx, discoveryTime, requestSnapshots)
 	}
 
+	// stop any speculative header fetch once we're done with the pool
+	defer s.cancelPrefetch()
+
 	// The app may ask us to retry a snapshot restoration, in which case we need to reuse
 	// the snapshot and chunk queue from the previous loop iteration.
 	var (
@@ -178,12 +457,15 @@ func (s *syncer) SyncAny(
 			if discoveryTime == 0 {
 				return sm.State{}, nil, errNoSnapshots
 			}
-			s.logger.Info(fmt.Sprintf("Discovering snapshots for %v", discoveryTime))
-			time.Sleep(discoveryTime)
+			s.discover(ctx, discoveryTime, requestSnapshots)
 			continue
 		}
 		if chunks == nil {
-			chunks, err = newChunkQueue(snapshot, s.tempDir)
+			if s.retainChunks {
+				chunks, err = newRetainedChunkQueue(snapshot, s.tempDir, s.tempDirPrefix)
+			} else {
+				chunks, err = newChunkQueue(snapshot, s.tempDir)
+			}
 			if err != nil {
 				return sm.State{}, nil, fmt.Errorf("failed to create chunk queue: %w", err)
 			}
@@ -193,6 +475,20 @@ func (s *syncer) SyncAny(
 		newState, commit, err := s.Sync(ctx, snapshot, chunks)
 		switch {
 		case err == nil:
+			// the snapshot is restored, so retained chunks are no longer needed
+			if err := chunks.Remove(); err != nil {
+				s.logger.Error("Failed to clean up chunk queue", "err", err)
+			}
+			s.mtx.Lock()
+			s.selected = &SelectedSnapshot{
+				Height:     snapshot.Height,
+				Format:     snapshot.Format,
+				Chunks:     snapshot.Chunks,
+				Hash:       snapshot.Hash,
+				BaseHeight: snapshot.BaseHeight,
+				Peers:      s.snapshots.GetPeers(snapshot),
+			}
+			s.mtx.Unlock()
 			return newState, commit, nil
 
 		case errors.Is(err, errAbort):
@@ -205,14 +501,24 @@ func (s *syncer) SyncAny(
 			continue
 
 		case errors.Is(err, errTimeout):
-			s.snapshots.Reject(snapshot)
+			s.snapshots.Reject(snapshot, err.Error())
 			s.logger.Error("Timed out waiting for snapshot chunks, rejected snapshot",
 				"height", snapshot.Height, "format", snapshot.Format, "hash", snapshot.Hash)
 
+		case errors.Is(err, errSnapshotUnavailable):
+			s.snapshots.Reject(snapshot, err.Error())
+			s.logger.Info("No peers serve snapshot anymore, rejected snapshot", "height", snapshot.Height,
+				"format", snapshot.Format, "hash", snapshot.Hash)
+
+		case errors.Is(err, errInsufficientChunkPeers):
+			s.snapshots.Reject(snapshot, err.Error())
+			s.logger.Info("Not enough peers serve snapshot chunks, rejected snapshot", "height", snapshot.Height,
+				"format", snapshot.Format, "hash", snapshot.Hash, "err", err)
+
 		case errors.Is(err, errRejectSnapshot):
-			s.snapshots.Reject(snapshot)
+			s.snapshots.Reject(snapshot, err.Error())
 			s.logger.Info("Snapshot rejected", "height", snapshot.Height, "format", snapshot.Format,
-				"hash", snapshot.Hash)
+				"hash", snapshot.Hash, "reason", err)
 
 		case errors.Is(err, errRejectFormat):
 			s.snapshots.RejectFormat(snapshot.Format)
@@ -230,8 +536,8 @@ func (s *syncer) SyncAny(
 			return sm.State{}, nil, fmt.Errorf("snapshot restoration failed: %w", err)
 		}
 
-		// Discard snapshot and chunks for next iteration
-		err = chunks.Close()
+		// Discard snapshot and chunks for next iteration, including any retained ones
+		err = chunks.Remove()
 		if err != nil {
 			s.logger.Error("Failed to clean up chunk queue", "err", err)
 		}
@@ -240,9 +546,33 @@ func (s *syncer) SyncAny(
 	}
 }
 
+// discover requests snapshots from peers and waits for discoveryTime while
+// they are added to the pool.
+func (s *syncer) discover(ctx context.Context, discoveryTime time.Duration, requestSnapshots func()) {
+	_, span := s.tracer.Start(ctx, "statesync.discovery", "discoveryTime", discoveryTime)
+	defer span.End()
+
+	requestSnapshots()
+	s.logger.Info(fmt.Sprintf("Discovering snapshots for %v", discoveryTime))
+	time.Sleep(discoveryTime)
+}
+
 // Sync executes a sync for a specific snapshot, returning the latest state and block commit which
 // the caller must use to bootstrap the node.
-func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueue) (sm.State, *types.Commit, error) {
+func (s *syncer) Sync(
+	ctx context.Context,
+	snapshot *snapshot,
+	chunks *chunkQueue,
+) (state sm.State, commit *types.Commit, err error) {
+	ctx, span := s.tracer.Start(ctx, "statesync.snapshot", "height", snapshot.Height,
+		"format", snapshot.Format, "hash", snapshot.Hash)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
 	s.mtx.Lock()
 	if s.chunks != nil {
 		s.mtx.Unlock()
@@ -254,13 +584,15 @@ func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueu
 		s.mtx.Lock()
 		s.chunks = nil
 		s.mtx.Unlock()
+		s.transfers.reset()
 	}()
 
 	hctx, hcancel := context.WithTimeout(ctx, 30*time.Second)
 	defer hcancel()
 
-	// Fetch the app hash corresponding to the snapshot
-	appHash, err := s.stateProvider.AppHash(hctx, snapshot.Height)
+	// Fetch the app hash corresponding to the snapshot, unless it has already
+	// been fetched speculatively
+	appHash, err := s.appHash(hctx, snapshot.Height)
 	if err != nil {
 		// check if the main context was triggered
 		if ctx.Err() != nil {
@@ -277,24 +609,39 @@ func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueu
 	}
 	snapshot.trustedAppHash = appHash
 
-	// Offer snapshot to ABCI app.
+	// Offer snapshot to ABCI app, which can reject it, e.g. for a malformed
+	// hash, before any of its chunks are downloaded.
 	err = s.offerSnapshot(ctx, snapshot)
 	if err != nil {
 		return sm.State{}, nil, err
 	}
 
+	// Make sure that enough peers can actually serve the snapshot's chunks
+	// before committing to download them all
+	if s.minChunkPeers > 0 {
+		if err := s.probeChunkPeers(ctx, snapshot); err != nil {
+			return sm.State{}, nil, err
+		}
+	}
+
 	// Spawn chunk fetchers. They will terminate when the chunk queue is closed or context canceled.
+	// Unless the snapshot must be verified first, chunks are fetched while the state is built.
 	fetchCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	for i := int32(0); i < s.fetchers; i++ {
-		go s.fetchChunks(fetchCtx, snapshot, chunks)
+	startFetchers := func() {
+		for i := int32(0); i < s.fetchers; i++ {
+			go s.fetchChunks(fetchCtx, snapshot, chunks)
+		}
+	}
+	if !s.verifyBeforeDownload {
+		startFetchers()
 	}
 
 	pctx, pcancel := context.WithTimeout(ctx, 1*time.Minute)
 	defer pcancel()
 
 	// Optimistically build new state, so we don't discover any light client failures at the end.
-	state, err := s.stateProvider.State(pctx, snapshot.Height)
+	state, err = s.stateProvider.State(pctx, snapshot.Height)
 	if err != nil {
 		// check if the main context was triggered
 		if ctx.Err() != nil {
@@ -308,7 +655,7 @@ func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueu
 			"err", err, "height", snapshot.Height)
 		return sm.State{}, nil, errRejectSnapshot
 	}
-	commit, err := s.stateProvider.Commit(pctx, snapshot.Height)
+	commit, err = s.stateProvider.Commit(pctx, snapshot.Height)
 	if err != nil {
 		// check if the provider context exceeded the 10 second deadline
 		if ctx.Err() != nil {
@@ -323,8 +670,31 @@ func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueu
 		return sm.State{}, nil, errRejectSnapshot
 	}
 
+	// The light blocks at the snapshot height and above have been verified, so
+	// the snapshot is anchored and its chunks are worth downloading.
+	if s.verifyBeforeDownload {
+		s.logger.Debug("verified snapshot light blocks, fetching chunks", "height", snapshot.Height)
+		startFetchers()
+	}
+
+	// While the snapshot is being restored, speculatively fetch the header of
+	// the next candidate so that we can fall back to it immediately on failure.
+	s.prefetchNext(ctx, snapshot)
+
+	// Abandon the snapshot if its serving peers all leave while it's restored
+	var unavailableCh chan struct{}
+	if s.availabilityGrace > 0 {
+		unavailableCh = make(chan struct{})
+		go s.watchAvailability(fetchCtx, snapshot, chunks, unavailableCh)
+	}
+
 	// Restore snapshot
 	err = s.applyChunks(ctx, chunks)
+	select {
+	case <-unavailableCh:
+		return sm.State{}, nil, errSnapshotUnavailable
+	default:
+	}
 	if err != nil {
 		return sm.State{}, nil, err
 	}
@@ -337,12 +707,208 @@ func (s *syncer) Sync(ctx context.Context, snapshot *snapshot, chunks *chunkQueu
 	state.Version.Consensus.App = appVersion
 
 	// Done! 🎉
+	s.cancelPrefetch()
 	s.logger.Info("Snapshot restored", "height", snapshot.Height, "format", snapshot.Format,
 		"hash", snapshot.Hash)
 
 	return state, commit, nil
 }
 
+// probeChunkPeers requests a randomly chosen chunk of the snapshot from a
+// random sample of chunkProbePeerMargin more peers than required among those
+// advertising it, and waits for at least minChunkPeers distinct peers to serve
+// it. Each time the sampled peers don't do so within the chunk request timeout,
+// the sample is widened to as many other peers as are still missing plus the
+// margin. It returns errInsufficientChunkPeers once no peers are left to probe.
+func (s *syncer) probeChunkPeers(ctx context.Context, snapshot *snapshot) error {
+	peers := s.snapshots.GetPeers(snapshot)
+	if len(peers) < s.minChunkPeers {
+		return fmt.Errorf("%w: %d peers advertise the snapshot, %d required",
+			errInsufficientChunkPeers, len(peers), s.minChunkPeers)
+	}
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	probe := &chunkProbe{
+		height:     snapshot.Height,
+		format:     snapshot.Format,
+		baseHeight: snapshot.BaseHeight,
+		index:      uint32(rand.Intn(int(snapshot.Chunks))), // nolint:gosec // G404: Use of weak random number generator
+		needed:     s.minChunkPeers,
+		probed:     make(map[types.NodeID]bool),
+		peers:      make(map[types.NodeID]bool),
+		doneCh:     make(chan struct{}),
+	}
+	s.mtx.Lock()
+	s.probe = probe
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		s.probe = nil
+		s.mtx.Unlock()
+	}()
+
+	sample := s.minChunkPeers + chunkProbePeerMargin
+	for len(peers) > 0 {
+		if sample > len(peers) {
+			sample = len(peers)
+		}
+		s.logger.Info("Probing peers for snapshot chunk", "height", snapshot.Height, "format", snapshot.Format,
+			"chunk", probe.index, "peers", sample)
+		for _, peer := range peers[:sample] {
+			probe.markProbed(peer)
+			s.transfers.request(snapshot, probe.index, peer)
+			s.chunkCh <- p2p.Envelope{
+				To: peer,
+				Message: &ssproto.ChunkRequest{
+					Height:     snapshot.Height,
+					Format:     snapshot.Format,
+					Index:      probe.index,
+					BaseHeight: snapshot.BaseHeight,
+				},
+			}
+		}
+		peers = peers[sample:]
+
+		timer := time.NewTimer(s.retryTimeout)
+		select {
+		case <-probe.doneCh:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		sample = s.minChunkPeers - probe.served() + chunkProbePeerMargin
+	}
+
+	return fmt.Errorf("%w: %d of %d required peers served chunk %d",
+		errInsufficientChunkPeers, probe.served(), s.minChunkPeers, probe.index)
+}
+
+// watchAvailability checks periodically that at least one peer advertises the snapshot. Once
+// none has for the availability grace period, it closes unavailableCh and the chunk queue, so
+// that the restoration stops waiting for chunks no peer can serve.
+func (s *syncer) watchAvailability(
+	ctx context.Context,
+	snapshot *snapshot,
+	chunks *chunkQueue,
+	unavailableCh chan<- struct{},
+) {
+	ticker := time.NewTicker(s.availabilityCheckInterval)
+	defer ticker.Stop()
+
+	var since time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if len(s.snapshots.GetPeers(snapshot)) > 0 {
+			since = time.Time{}
+			continue
+		}
+		if since.IsZero() {
+			since = time.Now()
+			s.logger.Info("No peers serve snapshot, waiting for them", "height", snapshot.Height,
+				"format", snapshot.Format, "hash", snapshot.Hash, "grace", s.availabilityGrace)
+			continue
+		}
+		if time.Since(since) >= s.availabilityGrace {
+			close(unavailableCh)
+			if err := chunks.Close(); err != nil {
+				s.logger.Error("Failed to close chunk queue", "err", err)
+			}
+			return
+		}
+	}
+}
+
+// appHash returns the trusted app hash at the given height, using the result
+// of a speculative fetch for that height if there is one.
+func (s *syncer) appHash(ctx context.Context, height uint64) ([]byte, error) {
+	s.mtx.Lock()
+	prefetch := s.prefetch
+	if prefetch != nil && prefetch.height == height {
+		s.prefetch = nil
+	}
+	s.mtx.Unlock()
+
+	if prefetch != nil && prefetch.height == height {
+		select {
+		case <-prefetch.doneCh:
+			if prefetch.err == nil {
+				s.logger.Debug("Using prefetched app hash", "height", height)
+				return prefetch.appHash, nil
+			}
+		case <-ctx.Done():
+			prefetch.cancel()
+			return nil, ctx.Err()
+		}
+	}
+
+	return s.stateProvider.AppHash(ctx, height)
+}
+
+// prefetchNext speculatively fetches the trusted app hash of the best snapshot
+// in the pool at a different height than the current one. Only a single
+// snapshot is prefetched at any time: a previous prefetch for another height
+// is canceled.
+func (s *syncer) prefetchNext(ctx context.Context, current *snapshot) {
+	var next *snapshot
+	for _, candidate := range s.snapshots.Ranked() {
+		if candidate.Height != current.Height {
+			next = candidate
+			break
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.prefetch != nil {
+		if next != nil && s.prefetch.height == next.Height {
+			return
+		}
+		s.prefetch.cancel()
+		s.prefetch = nil
+	}
+	if next == nil {
+		return
+	}
+
+	pctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	prefetch := &headerPrefetch{
+		height: next.Height,
+		cancel: cancel,
+		doneCh: make(chan struct{}),
+	}
+	s.prefetch = prefetch
+
+	s.logger.Debug("Prefetching app hash of next snapshot", "height", next.Height)
+	go func() {
+		defer close(prefetch.doneCh)
+		defer cancel()
+		prefetch.appHash, prefetch.err = s.stateProvider.AppHash(pctx, prefetch.height)
+	}()
+}
+
+// cancelPrefetch cancels any speculative fetch in progress and waits for it to
+// return.
+func (s *syncer) cancelPrefetch() {
+	s.mtx.Lock()
+	prefetch := s.prefetch
+	s.prefetch = nil
+	s.mtx.Unlock()
+
+	if prefetch != nil {
+		prefetch.cancel()
+		<-prefetch.doneCh
+	}
+}
+
 // offerSnapshot offers a snapshot to the app. It returns various errors depending on the app's
 // response, or nil if the snapshot was accepted.
 func (s *syncer) offerSnapshot(ctx context.Context, snapshot *snapshot) error {
@@ -350,11 +916,12 @@ func (s *syncer) offerSnapshot(ctx context.Context, snapshot *snapshot) error {
 		"format", snapshot.Format, "hash", snapshot.Hash)
 	resp, err := s.conn.OfferSnapshotSync(ctx, abci.RequestOfferSnapshot{
 		Snapshot: &abci.Snapshot{
-			Height:   snapshot.Height,
-			Format:   snapshot.Format,
-			Chunks:   snapshot.Chunks,
-			Hash:     snapshot.Hash,
-			Metadata: snapshot.Metadata,
+			Height:     snapshot.Height,
+			Format:     snapshot.Format,
+			Chunks:     snapshot.Chunks,
+			Hash:       snapshot.Hash,
+			Metadata:   snapshot.Metadata,
+			BaseHeight: snapshot.BaseHeight,
 		},
 		AppHash: snapshot.trustedAppHash,
 	})
@@ -369,7 +936,7 @@ func (s *syncer) offerSnapshot(ctx context.Context, snapshot *snapshot) error {
 	case abci.ResponseOfferSnapshot_ABORT:
 		return errAbort
 	case abci.ResponseOfferSnapshot_REJECT:
-		return errRejectSnapshot
+		return fmt.Errorf("%w by the app", errRejectSnapshot)
 	case abci.ResponseOfferSnapshot_REJECT_FORMAT:
 		return errRejectFormat
 	case abci.ResponseOfferSnapshot_REJECT_SENDER:
@@ -390,14 +957,19 @@ func (s *syncer) applyChunks(ctx context.Context, chunks *chunkQueue) error {
 			return fmt.Errorf("failed to fetch chunk: %w", err)
 		}
 
-		resp, err := s.conn.ApplySnapshotChunkSync(ctx, abci.RequestApplySnapshotChunk{
+		actx, span := s.tracer.Start(ctx, "statesync.apply_chunk", "height", chunk.Height,
+			"format", chunk.Format, "chunk", chunk.Index)
+		resp, err := s.conn.ApplySnapshotChunkSync(actx, abci.RequestApplySnapshotChunk{
 			Index:  chunk.Index,
 			Chunk:  chunk.Chunk,
 			Sender: string(chunk.Sender),
 		})
 		if err != nil {
+			span.RecordError(err)
+			span.End()
 			return fmt.Errorf("failed to apply chunk %v: %w", chunk.Index, err)
 		}
+		span.End()
 		s.logger.Info("Applied snapshot chunk to ABCI app", "height", chunk.Height,
 			"format", chunk.Format, "chunk", chunk.Index, "total", chunks.Size())
 
@@ -423,6 +995,9 @@ func (s *syncer) applyChunks(ctx context.Context, chunks *chunkQueue) error {
 
 		switch resp.Result {
 		case abci.ResponseApplySnapshotChunk_ACCEPT:
+			if err := chunks.MarkApplied(chunk.Index); err != nil {
+				return err
+			}
 		case abci.ResponseApplySnapshotChunk_ABORT:
 			return errAbort
 		case abci.ResponseApplySnapshotChunk_RETRY:
@@ -470,6 +1045,8 @@ func (s *syncer) fetchChunks(ctx context.Context, snapshot *snapshot, chunks *ch
 		ticker := time.NewTicker(s.retryTimeout)
 		defer ticker.Stop()
 
+		_, span := s.tracer.Start(ctx, "statesync.fetch_chunk", "height", snapshot.Height,
+			"format", snapshot.Format, "chunk", index)
 		s.requestChunk(snapshot, index)
 
 		select {
@@ -477,12 +1054,15 @@ func (s *syncer) fetchChunks(ctx context.Context, snapshot *snapshot, chunks *ch
 			next = true
 
 		case <-ticker.C:
+			span.RecordError(errTimeout)
 			next = false
 
 		case <-ctx.Done():
+			span.End()
 			return
 		}
 
+		span.End()
 		ticker.Stop()
 	}
 }
@@ -504,12 +1084,14 @@ func (s *syncer) requestChunk(snapshot *snapshot, chunk uint32) {
 		"peer", peer,
 	)
 
+	s.transfers.request(snapshot, chunk, peer)
 	s.chunkCh <- p2p.Envelope{
 		To: peer,
 		Message: &ssproto.ChunkRequest{
-			Height: snapshot.Height,
-			Format: snapshot.Format,
-			Index:  chunk,
+			Height:     snapshot.Height,
+			Format:     snapshot.Format,
+			Index:      chunk,
+			BaseHeight: snapshot.BaseHeight,
 		},
 	}
 }
@@ -14,6 +14,7 @@ import (
 	abci "github.com/tendermint/tendermint/abci/types"
 	tmsync "github.com/tendermint/tendermint/internal/libs/sync"
 	"github.com/tendermint/tendermint/internal/statesync/mocks"
+	"github.com/tendermint/tendermint/libs/log"
 	ssproto "github.com/tendermint/tendermint/proto/tendermint/statesync"
 	"github.com/tendermint/tendermint/proxy"
 	proxymocks "github.com/tendermint/tendermint/proxy/mocks"
@@ -77,12 +78,12 @@ func TestSyncer_SyncAny(t *testing.T) {
 	// Adding a couple of peers should trigger snapshot discovery messages
 	rts.syncer.AddPeer(peerAID)
 	e := <-rts.snapshotOutCh
-	require.Equal(t, &ssproto.SnapshotsRequest{}, e.Message)
+	require.Equal(t, &ssproto.SnapshotsRequest{AcceptCompressedMetadata: true}, e.Message)
 	require.Equal(t, peerAID, e.To)
 
 	rts.syncer.AddPeer(peerBID)
 	e = <-rts.snapshotOutCh
-	require.Equal(t, &ssproto.SnapshotsRequest{}, e.Message)
+	require.Equal(t, &ssproto.SnapshotsRequest{AcceptCompressedMetadata: true}, e.Message)
 	require.Equal(t, peerBID, e.To)
 
 	// Both peers report back with snapshots. One of them also returns a snapshot we don't want, in
@@ -267,6 +268,7 @@ func TestSyncer_SyncAny_reject(t *testing.T) {
 	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
 	require.Equal(t, errNoSnapshots, err)
 	rts.conn.AssertExpectations(t)
+	require.Equal(t, "snapshot was rejected by the app", rts.syncer.snapshots.RejectReason(s22))
 }
 
 func TestSyncer_SyncAny_reject_format(t *testing.T) {
@@ -373,6 +375,396 @@ func TestSyncer_SyncAny_abciError(t *testing.T) {
 	rts.conn.AssertExpectations(t)
 }
 
+func TestSyncer_SyncAny_prefetch(t *testing.T) {
+	state := sm.State{
+		ChainID:         "chain",
+		LastBlockHeight: 1,
+		AppHash:         []byte("app_hash_1"),
+	}
+	commit := &types.Commit{BlockID: types.BlockID{Hash: []byte("blockhash")}}
+
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, uint64(2)).Once().Return([]byte("app_hash_2"), nil)
+	prefetchedCh := make(chan struct{})
+	stateProvider.On("AppHash", mock.Anything, uint64(1)).Once().
+		Run(func(args mock.Arguments) { close(prefetchedCh) }).Return([]byte("app_hash_1"), nil)
+	stateProvider.On("State", mock.Anything, mock.AnythingOfType("uint64")).Return(state, nil)
+	stateProvider.On("Commit", mock.Anything, mock.AnythingOfType("uint64")).Return(commit, nil)
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+
+	// s2 is tried first and rejected once its chunk is applied, then s1 is
+	// restored using the app hash prefetched while s2 was being applied
+	s2 := &snapshot{Height: 2, Format: 1, Chunks: 1, Hash: []byte{2}}
+	s1 := &snapshot{Height: 1, Format: 1, Chunks: 1, Hash: []byte{1}}
+
+	peerID := types.NodeID("aa")
+	for _, s := range []*snapshot{s2, s1} {
+		_, err := rts.syncer.AddSnapshot(peerID, s)
+		require.NoError(t, err)
+	}
+
+	go func() {
+		for e := range rts.chunkOutCh {
+			msg, ok := e.Message.(*ssproto.ChunkRequest)
+			assert.True(t, ok)
+			_, _ = rts.syncer.AddChunk(&chunk{
+				Height: msg.Height,
+				Format: msg.Format,
+				Index:  msg.Index,
+				Chunk:  []byte{byte(msg.Height)},
+			})
+		}
+	}()
+
+	rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+		Snapshot: toABCI(s2), AppHash: []byte("app_hash_2"),
+	}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+	rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+		Snapshot: toABCI(s1), AppHash: []byte("app_hash_1"),
+	}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+	rts.conn.On("ApplySnapshotChunkSync", ctx, abci.RequestApplySnapshotChunk{
+		Index: 0, Chunk: []byte{2},
+	}).Once().Run(func(args mock.Arguments) {
+		// the app hash of s1 is fetched while s2 is still being applied
+		select {
+		case <-prefetchedCh:
+		case <-time.After(time.Second):
+			t.Error("app hash of next snapshot was not prefetched")
+		}
+	}).Return(&abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_REJECT_SNAPSHOT}, nil)
+	rts.conn.On("ApplySnapshotChunkSync", ctx, abci.RequestApplySnapshotChunk{
+		Index: 0, Chunk: []byte{1},
+	}).Once().Return(&abci.ResponseApplySnapshotChunk{Result: abci.ResponseApplySnapshotChunk_ACCEPT}, nil)
+	rts.connQuery.On("InfoSync", ctx, proxy.RequestInfo).Return(&abci.ResponseInfo{
+		AppVersion:       9,
+		LastBlockHeight:  1,
+		LastBlockAppHash: []byte("app_hash_1"),
+	}, nil)
+
+	newState, lastCommit, err := rts.syncer.SyncAny(ctx, 0, func() {})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, newState.LastBlockHeight)
+	require.Equal(t, commit, lastCommit)
+
+	rts.conn.AssertExpectations(t)
+	stateProvider.AssertExpectations(t)
+	stateProvider.AssertNumberOfCalls(t, "AppHash", 2)
+}
+
+func TestSyncer_SyncAny_verifyBeforeDownload(t *testing.T) {
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, uint64(1)).Return([]byte("app_hash"), nil)
+	stateProvider.On("State", mock.Anything, uint64(1)).Return(sm.State{}, nil)
+	// give fetchers time to request chunks, if they were started
+	stateProvider.On("Commit", mock.Anything, uint64(1)).After(100*time.Millisecond).
+		Return(nil, errors.New("no commit"))
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+	rts.syncer.verifyBeforeDownload = true
+
+	s := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1}}
+	_, err := rts.syncer.AddSnapshot(types.NodeID("aa"), s)
+	require.NoError(t, err)
+
+	rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+		Snapshot: toABCI(s), AppHash: []byte("app_hash"),
+	}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+
+	// the snapshot can't be anchored, so it's rejected without fetching any chunks
+	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
+	require.Equal(t, errNoSnapshots, err)
+	require.Empty(t, rts.chunkOutCh)
+
+	rts.conn.AssertExpectations(t)
+	stateProvider.AssertExpectations(t)
+}
+
+func TestSyncer_SyncAny_minChunkPeers(t *testing.T) {
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, mock.AnythingOfType("uint64")).Return([]byte("app_hash"), nil)
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+	rts.syncer.minChunkPeers = 2
+	rts.syncer.retryTimeout = 200 * time.Millisecond
+
+	peerA, peerB, peerC := types.NodeID("aa"), types.NodeID("bb"), types.NodeID("cc")
+
+	// snapshots are probed once the app accepts them. s3 is advertised by a
+	// single peer, so it's rejected without probing
+	s3 := &snapshot{Height: 3, Format: 1, Chunks: 3, Hash: []byte{3}}
+	_, err := rts.syncer.AddSnapshot(peerA, s3)
+	require.NoError(t, err)
+
+	// s2 is advertised by two peers, but only one of them serves its chunks
+	s2 := &snapshot{Height: 2, Format: 1, Chunks: 3, Hash: []byte{2}}
+	for _, peer := range []types.NodeID{peerA, peerC} {
+		_, err = rts.syncer.AddSnapshot(peer, s2)
+		require.NoError(t, err)
+	}
+
+	// s1 is advertised and served by two peers, so it's restored, failing to
+	// build the state
+	s1 := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1}}
+	for _, peer := range []types.NodeID{peerA, peerB} {
+		_, err = rts.syncer.AddSnapshot(peer, s1)
+		require.NoError(t, err)
+	}
+
+	go func() {
+		for e := range rts.chunkOutCh {
+			if e.To == peerC {
+				continue
+			}
+			req := e.Message.(*ssproto.ChunkRequest)
+			// chunks arriving once s1 is abandoned are refused
+			_, _ = rts.syncer.AddChunk(&chunk{
+				Height: req.Height,
+				Format: req.Format,
+				Index:  req.Index,
+				Chunk:  []byte{1},
+				Sender: e.To,
+			})
+		}
+	}()
+
+	for _, s := range []*snapshot{s3, s2, s1} {
+		rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+			Snapshot: toABCI(s), AppHash: []byte("app_hash"),
+		}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+	}
+	stateProvider.On("State", mock.Anything, uint64(1)).Return(sm.State{}, errors.New("no state"))
+
+	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
+	require.Equal(t, errNoSnapshots, err)
+	require.Contains(t, rts.syncer.snapshots.RejectReason(s3), errInsufficientChunkPeers.Error())
+	require.Contains(t, rts.syncer.snapshots.RejectReason(s2), errInsufficientChunkPeers.Error())
+
+	rts.conn.AssertExpectations(t)
+	stateProvider.AssertExpectations(t)
+}
+
+func TestSyncer_probeChunkPeers_sample(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 10)
+	rts.syncer.minChunkPeers = 1
+	rts.syncer.retryTimeout = 200 * time.Millisecond
+
+	s := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1}}
+	for _, peer := range []types.NodeID{"aa", "bb", "cc", "dd", "ee", "ff"} {
+		_, err := rts.syncer.AddSnapshot(peer, s)
+		require.NoError(t, err)
+	}
+
+	// receive the probes sent, replying to them if serve is set
+	receive := func(n int, serve bool) map[types.NodeID]bool {
+		peers := make(map[types.NodeID]bool)
+		for i := 0; i < n; i++ {
+			e := <-rts.chunkOutCh
+			peers[e.To] = true
+			if serve {
+				req := e.Message.(*ssproto.ChunkRequest)
+				_, _ = rts.syncer.AddChunk(&chunk{
+					Height: req.Height,
+					Format: req.Format,
+					Index:  req.Index,
+					Chunk:  []byte{1},
+					Sender: e.To,
+				})
+			}
+		}
+		return peers
+	}
+
+	// only the required number of peers plus the margin are probed when they
+	// serve the chunk
+	errCh := make(chan error, 1)
+	go func() { errCh <- rts.syncer.probeChunkPeers(ctx, s) }()
+	require.Len(t, receive(1+chunkProbePeerMargin, true), 1+chunkProbePeerMargin)
+	require.NoError(t, <-errCh)
+	require.Empty(t, rts.chunkOutCh)
+
+	// the sample is widened to other peers when the sampled ones don't serve it
+	go func() { errCh <- rts.syncer.probeChunkPeers(ctx, s) }()
+	first := receive(1+chunkProbePeerMargin, false)
+	second := receive(1+chunkProbePeerMargin, true)
+	for peer := range second {
+		require.False(t, first[peer])
+	}
+	require.NoError(t, <-errCh)
+	require.Empty(t, rts.chunkOutCh)
+
+	// the probe fails once all peers were probed
+	go func() { errCh <- rts.syncer.probeChunkPeers(ctx, s) }()
+	receive(6, false)
+	require.ErrorIs(t, <-errCh, errInsufficientChunkPeers)
+}
+
+func TestSyncer_SyncAny_snapshotUnavailable(t *testing.T) {
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, mock.AnythingOfType("uint64")).Return([]byte("app_hash"), nil)
+	stateProvider.On("State", mock.Anything, mock.AnythingOfType("uint64")).Return(sm.State{}, nil)
+	stateProvider.On("Commit", mock.Anything, mock.AnythingOfType("uint64")).Return(&types.Commit{}, nil)
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+	rts.syncer.availabilityGrace = 200 * time.Millisecond
+	rts.syncer.availabilityCheckInterval = 50 * time.Millisecond
+
+	peerA, peerB := types.NodeID("aa"), types.NodeID("bb")
+
+	// s2 is the best snapshot, but its only peer leaves once its chunks are
+	// requested
+	s2 := &snapshot{Height: 2, Format: 1, Chunks: 3, Hash: []byte{2}}
+	_, err := rts.syncer.AddSnapshot(peerA, s2)
+	require.NoError(t, err)
+
+	// s1 is still served, so it's restored next
+	s1 := &snapshot{Height: 1, Format: 1, Chunks: 1, Hash: []byte{1}}
+	_, err = rts.syncer.AddSnapshot(peerB, s1)
+	require.NoError(t, err)
+
+	go func() {
+		for e := range rts.chunkOutCh {
+			if e.To == peerA {
+				rts.syncer.RemovePeer(peerA)
+				continue
+			}
+			req := e.Message.(*ssproto.ChunkRequest)
+			_, err := rts.syncer.AddChunk(&chunk{
+				Height: req.Height,
+				Format: req.Format,
+				Index:  req.Index,
+				Chunk:  []byte{1},
+				Sender: e.To,
+			})
+			assert.NoError(t, err)
+		}
+	}()
+
+	for _, s := range []*snapshot{s1, s2} {
+		rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+			Snapshot: toABCI(s), AppHash: []byte("app_hash"),
+		}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+	}
+	rts.conn.On("ApplySnapshotChunkSync", mock.Anything, abci.RequestApplySnapshotChunk{
+		Index: 0, Chunk: []byte{1}, Sender: string(peerB),
+	}).Once().Return(&abci.ResponseApplySnapshotChunk{
+		Result: abci.ResponseApplySnapshotChunk_REJECT_SNAPSHOT,
+	}, nil)
+
+	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
+	require.Equal(t, errNoSnapshots, err)
+	require.Equal(t, errSnapshotUnavailable.Error(), rts.syncer.snapshots.RejectReason(s2))
+
+	rts.conn.AssertExpectations(t)
+}
+
+func TestSyncer_AddSnapshot_delta(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.syncer.baseHeight = 5
+	peerID := types.NodeID("aa")
+
+	// snapshots are requested along with the deltas applying to the app state
+	rts.syncer.AddPeer(peerID)
+	e := <-rts.snapshotOutCh
+	require.Equal(t, &ssproto.SnapshotsRequest{AcceptCompressedMetadata: true, BaseHeight: 5}, e.Message)
+
+	// deltas from other heights are refused
+	_, err := rts.syncer.AddSnapshot(peerID, &snapshot{Height: 8, Format: 1, Chunks: 1, BaseHeight: 4})
+	require.Error(t, err)
+	_, err = rts.syncer.AddSnapshot(peerID, &snapshot{Height: 5, Format: 1, Chunks: 1, BaseHeight: 5})
+	require.Error(t, err)
+
+	added, err := rts.syncer.AddSnapshot(peerID, &snapshot{Height: 8, Format: 1, Chunks: 1, BaseHeight: 5})
+	require.NoError(t, err)
+	require.True(t, added)
+
+	// a node without app state can't use any delta
+	rts.syncer.baseHeight = 0
+	_, err = rts.syncer.AddSnapshot(peerID, &snapshot{Height: 9, Format: 1, Chunks: 1, BaseHeight: 5})
+	require.Error(t, err)
+}
+
+func TestSyncer_SyncAny_rejectedBeforeDownload(t *testing.T) {
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, uint64(2)).Return([]byte("app_hash"), nil)
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+	rts.syncer.minChunkPeers = 1
+
+	// the app rejects a snapshot with a malformed hash when it's offered,
+	// before any of its chunks is requested, even to probe peers
+	malformed := &snapshot{Height: 2, Format: 1, Chunks: 3, Hash: []byte{1, 2, 3}}
+	_, err := rts.syncer.AddSnapshot(types.NodeID("aa"), malformed)
+	require.NoError(t, err)
+	rts.conn.On("OfferSnapshotSync", ctx, abci.RequestOfferSnapshot{
+		Snapshot: toABCI(malformed), AppHash: []byte("app_hash"),
+	}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_REJECT}, nil)
+
+	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
+	require.Equal(t, errNoSnapshots, err)
+	require.Empty(t, rts.chunkOutCh)
+	require.Equal(t, "snapshot was rejected by the app", rts.syncer.snapshots.RejectReason(malformed))
+
+	// and it isn't added again when advertised by another peer
+	added, err := rts.syncer.AddSnapshot(types.NodeID("bb"), malformed)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	rts.conn.AssertExpectations(t)
+}
+
+func TestSyncer_AddSnapshot_minUpgradeHeight(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+	rts.syncer.minUpgradeHeight = 5
+	peerID := types.NodeID("aa")
+
+	// snapshots taken before the upgrade are rejected without being offered
+	old := &snapshot{Height: 4, Format: 1, Chunks: 1, Hash: []byte{1}}
+	added, err := rts.syncer.AddSnapshot(peerID, old)
+	require.NoError(t, err)
+	require.False(t, added)
+	require.Empty(t, rts.syncer.snapshots.Ranked())
+	require.Equal(t, "taken before upgrade height 5", rts.syncer.snapshots.RejectReason(old))
+
+	upgraded := &snapshot{Height: 5, Format: 1, Chunks: 1, Hash: []byte{1}}
+	added, err = rts.syncer.AddSnapshot(peerID, upgraded)
+	require.NoError(t, err)
+	require.True(t, added)
+	require.Equal(t, []*snapshot{upgraded}, rts.syncer.snapshots.Ranked())
+}
+
+func TestSyncer_Snapshots(t *testing.T) {
+	rts := setup(t, nil, nil, nil, 2)
+
+	s22 := &snapshot{Height: 2, Format: 2, Chunks: 3, Hash: []byte{1, 2, 3}}
+	s12 := &snapshot{Height: 1, Format: 2, Chunks: 3, Hash: []byte{1, 2, 3}}
+	s11 := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1, 2, 3}}
+
+	// no snapshots are reported while the reactor isn't syncing
+	_, err := rts.reactor.Snapshots()
+	require.Error(t, err)
+	rts.reactor.syncer = rts.syncer
+
+	for _, s := range []*snapshot{s22, s12, s11} {
+		_, err := rts.syncer.AddSnapshot(types.NodeID("aa"), s)
+		require.NoError(t, err)
+	}
+	rts.syncer.snapshots.Reject(s22, "test")
+
+	chunks, err := newChunkQueue(s12, "")
+	require.NoError(t, err)
+	defer chunks.Close()
+	rts.syncer.chunks = chunks
+
+	snapshots, err := rts.reactor.Snapshots()
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotInfo{
+		{Height: 1, Format: 2, Chunks: 3, Hash: []byte{1, 2, 3}, Peers: 1, Status: SnapshotStatusApplying},
+		{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1, 2, 3}, Peers: 1, Status: SnapshotStatusPending},
+		{Height: 2, Format: 2, Status: SnapshotStatusRejected, RejectReason: "test"},
+	}, snapshots)
+}
+
 func TestSyncer_offerSnapshot(t *testing.T) {
 	unknownErr := errors.New("unknown error")
 	boom := errors.New("boom")
@@ -700,3 +1092,142 @@ func toABCI(s *snapshot) *abci.Snapshot {
 		Metadata: s.Metadata,
 	}
 }
+
+type spanCtxKey struct{}
+
+// recordingTracer records the spans it creates, along with their parents.
+type recordingTracer struct {
+	mtx   sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	tracer  *recordingTracer
+	name    string
+	parent  *recordedSpan
+	keyvals []interface{}
+	err     error
+	ended   bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, keyvals ...interface{}) (context.Context, Span) {
+	parent, _ := ctx.Value(spanCtxKey{}).(*recordedSpan)
+	span := &recordedSpan{tracer: t, name: name, parent: parent, keyvals: keyvals}
+
+	t.mtx.Lock()
+	t.spans = append(t.spans, span)
+	t.mtx.Unlock()
+	return context.WithValue(ctx, spanCtxKey{}, span), span
+}
+
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var spans []*recordedSpan
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.tracer.mtx.Lock()
+	defer s.tracer.mtx.Unlock()
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.tracer.mtx.Lock()
+	defer s.tracer.mtx.Unlock()
+	s.ended = true
+}
+
+func TestSyncer_Tracing(t *testing.T) {
+	stateProvider := &mocks.StateProvider{}
+	stateProvider.On("AppHash", mock.Anything, uint64(1)).Return([]byte("app_hash"), nil)
+	stateProvider.On("State", mock.Anything, uint64(1)).Return(sm.State{}, nil)
+	// give fetchers time to request chunks
+	stateProvider.On("Commit", mock.Anything, uint64(1)).After(100*time.Millisecond).
+		Return(nil, errors.New("no commit"))
+
+	rts := setup(t, nil, nil, stateProvider, 2)
+	tracer := &recordingTracer{}
+	rts.syncer.tracer = tracer
+
+	// drop chunk requests, so that fetchers don't block
+	go func() {
+		for range rts.chunkOutCh {
+		}
+	}()
+
+	s := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1}}
+	_, err := rts.syncer.AddSnapshot(types.NodeID("aa"), s)
+	require.NoError(t, err)
+
+	rts.conn.On("OfferSnapshotSync", mock.Anything, abci.RequestOfferSnapshot{
+		Snapshot: toABCI(s), AppHash: []byte("app_hash"),
+	}).Once().Return(&abci.ResponseOfferSnapshot{Result: abci.ResponseOfferSnapshot_ACCEPT}, nil)
+
+	_, _, err = rts.syncer.SyncAny(ctx, 0, func() {})
+	require.Equal(t, errNoSnapshots, err)
+
+	// the failed restoration of the snapshot is traced
+	snapshotSpans := tracer.named("statesync.snapshot")
+	require.Len(t, snapshotSpans, 1)
+	require.Equal(t, errRejectSnapshot, snapshotSpans[0].err)
+	require.True(t, snapshotSpans[0].ended)
+
+	// along with the chunk fetches started for it
+	require.Eventually(t, func() bool {
+		spans := tracer.named("statesync.fetch_chunk")
+		if len(spans) == 0 {
+			return false
+		}
+		tracer.mtx.Lock()
+		defer tracer.mtx.Unlock()
+		for _, span := range spans {
+			if !span.ended || span.parent != snapshotSpans[0] {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// as well as discovery
+	requested := false
+	rts.syncer.discover(ctx, 0, func() { requested = true })
+	require.True(t, requested)
+	discoverySpans := tracer.named("statesync.discovery")
+	require.Len(t, discoverySpans, 1)
+	require.True(t, discoverySpans[0].ended)
+}
+
+func TestChunkTransferLog(t *testing.T) {
+	l := newChunkTransferLog(log.TestingLogger())
+	s := &snapshot{Height: 1, Format: 1, Chunks: 3, Hash: []byte{1}}
+
+	// responses are matched with the request of the chunk from their sender
+	l.request(s, 0, types.NodeID("aa"))
+	l.request(s, 1, types.NodeID("aa"))
+	l.request(s, 1, types.NodeID("bb"))
+	l.response(&chunk{Height: 1, Format: 1, Index: 1, Chunk: []byte{1}, Sender: types.NodeID("bb")})
+	require.Len(t, l.sent, 2)
+	_, ok := l.sent[chunkRequest{height: 1, format: 1, index: 1, peer: types.NodeID("aa")}]
+	require.True(t, ok)
+
+	// unsolicited responses are logged without affecting the requests
+	l.response(&chunk{Height: 1, Format: 1, Index: 2, Chunk: []byte{1}, Sender: types.NodeID("aa")})
+	require.Len(t, l.sent, 2)
+
+	// requests are forgotten once the sync is over
+	l.reset()
+	require.Empty(t, l.sent)
+
+	// a nil log doesn't log anything
+	var nilLog *chunkTransferLog
+	nilLog.request(s, 0, types.NodeID("aa"))
+	nilLog.response(&chunk{Height: 1, Format: 1, Index: 0, Sender: types.NodeID("aa")})
+	nilLog.reset()
+}
@@ -0,0 +1,37 @@
+package statesync
+
+import "context"
+
+// Tracer creates spans for the major state sync operations: discovery, the
+// restoration of each snapshot, the fetching and applying of each chunk, and
+// backfill. Spans are derived from the context passed to Sync, so that they
+// form a single trace of the node joining the network. The interface follows
+// the shape of the OpenTelemetry tracer, which can be plugged in through a thin
+// adapter.
+type Tracer interface {
+	// Start creates a span with the given name and attributes, as a child of
+	// the span carried by ctx if any. The returned context carries the new
+	// span.
+	Start(ctx context.Context, name string, keyvals ...interface{}) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// RecordError records that the operation failed with err.
+	RecordError(err error)
+
+	// End completes the operation.
+	End()
+}
+
+// nopTracer is the Tracer used when none is configured. Its spans do nothing.
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ ...interface{}) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) RecordError(error) {}
+func (nopSpan) End()              {}
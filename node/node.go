@@ -68,6 +68,7 @@ type nodeImpl struct {
 	mempool          mempool.Mempool
 	stateSync        bool               // whether the node should state sync on startup
 	stateSyncReactor *statesync.Reactor // for hosting and restoring state sync snapshots
+	stateSyncServer  *stateSyncServer   // for hosting snapshots over statesync.serve-laddr, if set
 	consensusReactor *cs.Reactor        // for participating in the consensus
 	pexReactor       service.Service    // for exchanging peer addresses
 	evidenceReactor  service.Service
@@ -239,7 +240,8 @@ func makeNode(config *cfg.Config,
 		return nil, fmt.Errorf("failed to create peer manager: %w", err)
 	}
 
-	csMetrics, p2pMetrics, memplMetrics, smMetrics := defaultMetricsProvider(config.Instrumentation)(genDoc.ChainID)
+	csMetrics, p2pMetrics, memplMetrics, smMetrics, ssMetrics :=
+		defaultMetricsProvider(config.Instrumentation)(genDoc.ChainID)
 
 	router, err := createRouter(p2pLogger, p2pMetrics, nodeInfo, nodeKey.PrivKey,
 		peerManager, transport, getRouterConfig(config, proxyApp))
@@ -316,19 +318,21 @@ func makeNode(config *cfg.Config,
 		peerUpdates *p2p.PeerUpdates
 	)
 
-	stateSyncReactorShim = p2p.NewReactorShim(logger.With("module", "statesync"), "StateSyncShim", statesync.ChannelShims)
+	stateSyncChannelShims := statesync.GetChannelShims(config.StateSync)
+	stateSyncReactorShim = p2p.NewReactorShim(logger.With("module", "statesync"), "StateSyncShim", stateSyncChannelShims)
 
 	if config.P2P.UseLegacy {
 		channels = getChannelsFromShim(stateSyncReactorShim)
 		peerUpdates = stateSyncReactorShim.PeerUpdates
 	} else {
-		channels = makeChannelsFromShims(router, statesync.ChannelShims)
+		channels = makeChannelsFromShims(router, stateSyncChannelShims)
 		peerUpdates = peerManager.Subscribe()
 	}
 
 	stateSyncReactor = statesync.NewReactor(
 		genDoc.ChainID,
 		genDoc.InitialHeight,
+		nodeKey.ID,
 		*config.StateSync,
 		stateSyncReactorShim.Logger,
 		proxyApp.Snapshot(),
@@ -341,8 +345,23 @@ func makeNode(config *cfg.Config,
 		stateStore,
 		blockStore,
 		config.StateSync.TempDir,
+		ssMetrics,
 	)
 
+	// Optionally, serve snapshots and chunks over a dedicated listener rather
+	// than the main p2p connections.
+	var stateSyncSrv *stateSyncServer
+	if config.StateSync.ServeListenAddress != "" {
+		stateSyncSrv, err = createStateSyncServer(config, logger, nodeInfo, proxyApp,
+			stateStore, blockStore, genDoc, p2pMetrics, ssMetrics)
+		if err != nil {
+			return nil, err
+		}
+		if err := stateSyncReactor.SetServeSnapshots(false); err != nil {
+			return nil, err
+		}
+	}
+
 	// add the channel descriptors to both the transports
 	// FIXME: This should be removed when the legacy p2p stack is removed and
 	// transports can either be agnostic to channel descriptors or can be
@@ -433,6 +452,7 @@ func makeNode(config *cfg.Config,
 		mempool:          mp,
 		consensusReactor: csReactor,
 		stateSyncReactor: stateSyncReactor,
+		stateSyncServer:  stateSyncSrv,
 		stateSync:        stateSync,
 		pexReactor:       pexReactor,
 		evidenceReactor:  evReactor,
@@ -450,6 +470,7 @@ func makeNode(config *cfg.Config,
 
 			ConsensusReactor: csReactor,
 			BlockSyncReactor: bcReactor.(cs.BlockSyncReactor),
+			StateSyncReactor: stateSyncReactor,
 
 			P2PPeers:    sw,
 			PeerManager: peerManager,
@@ -651,6 +672,12 @@ func (n *nodeImpl) OnStart() error {
 			return err
 		}
 
+		if n.stateSyncServer != nil {
+			if err := n.stateSyncServer.start(n.config.StateSync.ServeListenAddress); err != nil {
+				return err
+			}
+		}
+
 		// Start the real mempool reactor separately since the switch uses the shim.
 		if err := n.mempoolReactor.Start(); err != nil {
 			return err
@@ -774,6 +801,10 @@ func (n *nodeImpl) OnStop() {
 			n.Logger.Error("failed to stop the state sync reactor", "err", err)
 		}
 
+		if n.stateSyncServer != nil {
+			n.stateSyncServer.stop(n.Logger)
+		}
+
 		// Stop the real mempool reactor separately since the switch uses the shim.
 		if err := n.mempoolReactor.Stop(); err != nil {
 			n.Logger.Error("failed to stop the mempool reactor", "err", err)
@@ -848,6 +879,7 @@ func (n *nodeImpl) startRPC() ([]net.Listener, error) {
 
 	config := rpcserver.DefaultConfig()
 	config.MaxBodyBytes = n.config.RPC.MaxBodyBytes
+	config.MaxResponseBodyBytes = n.config.RPC.MaxResponseBodyBytes
 	config.MaxHeaderBytes = n.config.RPC.MaxHeaderBytes
 	config.MaxOpenConnections = n.config.RPC.MaxOpenConnections
 	// If necessary adjust global WriteTimeout to ensure it's greater than
@@ -1034,20 +1066,22 @@ func defaultGenesisDocProviderFunc(config *cfg.Config) genesisDocProvider {
 	}
 }
 
-// metricsProvider returns a consensus, p2p and mempool Metrics.
-type metricsProvider func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempool.Metrics, *sm.Metrics)
+// metricsProvider returns a consensus, p2p, mempool, state and statesync Metrics.
+type metricsProvider func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempool.Metrics, *sm.Metrics,
+	*statesync.Metrics)
 
 // defaultMetricsProvider returns Metrics build using Prometheus client library
 // if Prometheus is enabled. Otherwise, it returns no-op Metrics.
 func defaultMetricsProvider(config *cfg.InstrumentationConfig) metricsProvider {
-	return func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempool.Metrics, *sm.Metrics) {
+	return func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempool.Metrics, *sm.Metrics, *statesync.Metrics) {
 		if config.Prometheus {
 			return cs.PrometheusMetrics(config.Namespace, "chain_id", chainID),
 				p2p.PrometheusMetrics(config.Namespace, "chain_id", chainID),
 				mempool.PrometheusMetrics(config.Namespace, "chain_id", chainID),
-				sm.PrometheusMetrics(config.Namespace, "chain_id", chainID)
+				sm.PrometheusMetrics(config.Namespace, "chain_id", chainID),
+				statesync.PrometheusMetrics(config.Namespace, "chain_id", chainID)
 		}
-		return cs.NopMetrics(), p2p.NopMetrics(), mempool.NopMetrics(), sm.NopMetrics()
+		return cs.NopMetrics(), p2p.NopMetrics(), mempool.NopMetrics(), sm.NopMetrics(), statesync.NopMetrics()
 	}
 }
 
@@ -27,6 +27,7 @@ import (
 	mempoolv0 "github.com/tendermint/tendermint/internal/mempool/v0"
 	"github.com/tendermint/tendermint/internal/test/factory"
 	"github.com/tendermint/tendermint/libs/log"
+	tmnet "github.com/tendermint/tendermint/libs/net"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
 	tmtime "github.com/tendermint/tendermint/libs/time"
 	"github.com/tendermint/tendermint/privval"
@@ -107,6 +108,28 @@ func TestNodeDelayedStart(t *testing.T) {
 	assert.Equal(t, true, startTime.After(n.GenesisDoc().GenesisTime))
 }
 
+func TestNodeStateSyncServeListener(t *testing.T) {
+	config := cfg.ResetTestRoot("node_statesync_serve_test")
+	defer os.RemoveAll(config.RootDir)
+
+	port, err := tmnet.GetFreePort()
+	require.NoError(t, err)
+	config.StateSync.ServeListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", port)
+
+	// the listener has a node key of its own
+	n := getTestNode(t, config, log.TestingLogger())
+	require.NotNil(t, n.stateSyncServer)
+	require.FileExists(t, config.StateSync.ServeNodeKeyFile())
+	require.NotEqual(t, n.nodeKey.ID, n.stateSyncServer.nodeKey.ID)
+
+	require.NoError(t, n.Start())
+	defer n.Stop() //nolint:errcheck // ignore for tests
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
+
 func TestNodeSetAppVersion(t *testing.T) {
 	config := cfg.ResetTestRoot("node_app_version_test")
 	defer os.RemoveAll(config.RootDir)
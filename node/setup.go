@@ -26,6 +26,7 @@ import (
 	"github.com/tendermint/tendermint/internal/p2p/pex"
 	"github.com/tendermint/tendermint/internal/statesync"
 	"github.com/tendermint/tendermint/libs/log"
+	tmmath "github.com/tendermint/tendermint/libs/math"
 	"github.com/tendermint/tendermint/libs/service"
 	tmstrings "github.com/tendermint/tendermint/libs/strings"
 	protop2p "github.com/tendermint/tendermint/proto/tendermint/p2p"
@@ -509,6 +510,139 @@ func createRouter(
 	)
 }
 
+// stateSyncServer serves the app's snapshots and chunks to peers over a
+// dedicated transport and router, listening on statesync.serve-laddr with a
+// node key of its own, isolated from the main p2p connections.
+type stateSyncServer struct {
+	nodeKey   types.NodeKey
+	transport *p2p.MConnTransport
+	router    *p2p.Router
+	reactor   *statesync.Reactor
+}
+
+func createStateSyncServer(
+	config *cfg.Config,
+	logger log.Logger,
+	nodeInfo types.NodeInfo,
+	proxyApp proxy.AppConns,
+	stateStore sm.Store,
+	blockStore *store.BlockStore,
+	genDoc *types.GenesisDoc,
+	p2pMetrics *p2p.Metrics,
+	ssMetrics *statesync.Metrics,
+) (*stateSyncServer, error) {
+	if config.P2P.UseLegacy {
+		return nil, errors.New("statesync.serve-laddr requires the new p2p stack")
+	}
+
+	nodeKey, err := types.LoadOrGenNodeKey(config.StateSync.ServeNodeKeyFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load or gen state sync serve node key %s: %w",
+			config.StateSync.ServeNodeKeyFile(), err)
+	}
+
+	// the listener is a peer of its own, only knowing the state sync channels
+	nodeInfo.NodeID = nodeKey.ID
+	nodeInfo.ListenAddr = config.StateSync.ServeListenAddress
+	nodeInfo.Channels = []byte{
+		byte(statesync.SnapshotChannel),
+		byte(statesync.ChunkChannel),
+		byte(statesync.LightBlockChannel),
+		byte(statesync.ParamsChannel),
+	}
+	if err := nodeInfo.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid state sync serve node info: %w", err)
+	}
+
+	p2pLogger := logger.With("module", "p2p", "listener", "statesync")
+	chShims := statesync.GetChannelShims(config.StateSync)
+	chDescs := make([]*p2p.ChannelDescriptor, 0, len(chShims))
+	for _, chShim := range chShims {
+		chDescs = append(chDescs, chShim.Descriptor)
+	}
+	transport := p2p.NewMConnTransport(
+		p2pLogger, p2p.MConnConfig(config.P2P), chDescs,
+		p2p.MConnTransportOptions{MaxAcceptedConnections: uint32(config.P2P.MaxNumInboundPeers)},
+	)
+
+	// peers only dial in to be served, so the peer store isn't persisted
+	maxConns := 64
+	if config.P2P.MaxNumInboundPeers > 0 {
+		maxConns = tmmath.MinInt(config.P2P.MaxNumInboundPeers, 1000)
+	}
+	peerManager, err := p2p.NewPeerManager(nodeKey.ID, dbm.NewMemDB(), p2p.PeerManagerOptions{
+		MaxConnected:    uint16(maxConns),
+		MaxPeers:        1000,
+		MinRetryTime:    100 * time.Millisecond,
+		MaxRetryTime:    8 * time.Hour,
+		RetryTimeJitter: 3 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state sync serve peer manager: %w", err)
+	}
+
+	router, err := createRouter(p2pLogger, p2pMetrics, nodeInfo, nodeKey.PrivKey,
+		peerManager, transport, getRouterConfig(config, proxyApp))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state sync serve router: %w", err)
+	}
+
+	channels := makeChannelsFromShims(router, chShims)
+	reactor := statesync.NewReactor(
+		genDoc.ChainID,
+		genDoc.InitialHeight,
+		nodeKey.ID,
+		*config.StateSync,
+		logger.With("module", "statesync", "listener", "statesync"),
+		proxyApp.Snapshot(),
+		proxyApp.Query(),
+		channels[statesync.SnapshotChannel],
+		channels[statesync.ChunkChannel],
+		channels[statesync.LightBlockChannel],
+		channels[statesync.ParamsChannel],
+		peerManager.Subscribe(),
+		stateStore,
+		blockStore,
+		config.StateSync.TempDir,
+		ssMetrics,
+	)
+
+	return &stateSyncServer{
+		nodeKey:   nodeKey,
+		transport: transport,
+		router:    router,
+		reactor:   reactor,
+	}, nil
+}
+
+// start listens on the serve address and starts serving peers.
+func (s *stateSyncServer) start(laddr string) error {
+	addr, err := types.NewNetAddressString(s.nodeKey.ID.AddressString(laddr))
+	if err != nil {
+		return err
+	}
+	if err := s.transport.Listen(p2p.NewEndpoint(addr)); err != nil {
+		return err
+	}
+	if err := s.router.Start(); err != nil {
+		return err
+	}
+	return s.reactor.Start()
+}
+
+// stop stops serving peers and closes the listener.
+func (s *stateSyncServer) stop(logger log.Logger) {
+	if err := s.reactor.Stop(); err != nil {
+		logger.Error("failed to stop the state sync serve reactor", "err", err)
+	}
+	if err := s.router.Stop(); err != nil {
+		logger.Error("failed to stop the state sync serve router", "err", err)
+	}
+	if err := s.transport.Close(); err != nil {
+		logger.Error("Error closing state sync serve transport", "err", err)
+	}
+}
+
 func createSwitch(
 	config *cfg.Config,
 	transport p2p.Transport,
@@ -34,6 +34,9 @@ func (m *Message) Wrap(pb proto.Message) error {
 	case *ParamsResponse:
 		m.Sum = &Message_ParamsResponse{ParamsResponse: msg}
 
+	case *Capabilities:
+		m.Sum = &Message_Capabilities{Capabilities: msg}
+
 	default:
 		return fmt.Errorf("unknown message: %T", msg)
 	}
@@ -69,6 +72,9 @@ func (m *Message) Unwrap() (proto.Message, error) {
 	case *Message_ParamsResponse:
 		return m.GetParamsResponse(), nil
 
+	case *Message_Capabilities:
+		return m.GetCapabilities(), nil
+
 	default:
 		return nil, fmt.Errorf("unknown message: %T", msg)
 	}
@@ -129,6 +135,12 @@ func (m *Message) Validate() error {
 			return errors.New("height cannot be 0")
 		}
 
+	case *Message_Capabilities:
+
+	// a message type added in a later protocol version, which is ignored
+	// rather than treated as invalid
+	case nil:
+
 	default:
 		return fmt.Errorf("unknown message type: %T", msg)
 	}
@@ -18,8 +18,10 @@ func TestValidateMsg(t *testing.T) {
 		validMsg bool
 		valid    bool
 	}{
-		"nil":       {nil, false, false},
-		"unrelated": {&tmproto.Block{}, false, false},
+		// an empty message is what a message type unknown to this version
+		// unmarshals to, so it is not invalid
+		"nil":       {nil, false, true},
+		"unrelated": {&tmproto.Block{}, false, true},
 
 		"ChunkRequest valid":    {&ssproto.ChunkRequest{Height: 1, Format: 1, Index: 1}, true, true},
 		"ChunkRequest 0 height": {&ssproto.ChunkRequest{Height: 0, Format: 1, Index: 1}, true, false},
@@ -99,6 +101,8 @@ func TestValidateMsg(t *testing.T) {
 			true,
 			false,
 		},
+
+		"Capabilities valid": {&ssproto.Capabilities{Version: 2}, true, true},
 	}
 
 	for name, tc := range testcases {
@@ -191,6 +195,13 @@ func TestStateSyncVectors(t *testing.T) {
 			},
 			"423408a946122f0a10088080c00a10ffffffffffffffffff01120e08a08d0612040880c60a188080401a090a07656432353531392200",
 		},
+		{
+			"Capabilities",
+			&ssproto.Capabilities{
+				Version: 2,
+			},
+			"4a020802",
+		},
 	}
 
 	for _, tc := range testCases {
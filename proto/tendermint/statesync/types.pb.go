@@ -34,6 +34,7 @@ type Message struct {
 	//	*Message_LightBlockResponse
 	//	*Message_ParamsRequest
 	//	*Message_ParamsResponse
+	//	*Message_Capabilities
 	Sum isMessage_Sum `protobuf_oneof:"sum"`
 }
 
@@ -100,6 +101,9 @@ type Message_ParamsRequest struct {
 type Message_ParamsResponse struct {
 	ParamsResponse *ParamsResponse `protobuf:"bytes,8,opt,name=params_response,json=paramsResponse,proto3,oneof" json:"params_response,omitempty"`
 }
+type Message_Capabilities struct {
+	Capabilities *Capabilities `protobuf:"bytes,9,opt,name=capabilities,proto3,oneof" json:"capabilities,omitempty"`
+}
 
 func (*Message_SnapshotsRequest) isMessage_Sum()   {}
 func (*Message_SnapshotsResponse) isMessage_Sum()  {}
@@ -109,6 +113,7 @@ func (*Message_LightBlockRequest) isMessage_Sum()  {}
 func (*Message_LightBlockResponse) isMessage_Sum() {}
 func (*Message_ParamsRequest) isMessage_Sum()      {}
 func (*Message_ParamsResponse) isMessage_Sum()     {}
+func (*Message_Capabilities) isMessage_Sum()       {}
 
 func (m *Message) GetSum() isMessage_Sum {
 	if m != nil {
@@ -173,6 +178,13 @@ func (m *Message) GetParamsResponse() *ParamsResponse {
 	return nil
 }
 
+func (m *Message) GetCapabilities() *Capabilities {
+	if x, ok := m.GetSum().(*Message_Capabilities); ok {
+		return x.Capabilities
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Message) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -184,10 +196,13 @@ func (*Message) XXX_OneofWrappers() []interface{} {
 		(*Message_LightBlockResponse)(nil),
 		(*Message_ParamsRequest)(nil),
 		(*Message_ParamsResponse)(nil),
+		(*Message_Capabilities)(nil),
 	}
 }
 
 type SnapshotsRequest struct {
+	AcceptCompressedMetadata bool   `protobuf:"varint,1,opt,name=accept_compressed_metadata,json=acceptCompressedMetadata,proto3" json:"accept_compressed_metadata,omitempty"`
+	BaseHeight               uint64 `protobuf:"varint,2,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *SnapshotsRequest) Reset()         { *m = SnapshotsRequest{} }
@@ -223,12 +238,28 @@ func (m *SnapshotsRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SnapshotsRequest proto.InternalMessageInfo
 
+func (m *SnapshotsRequest) GetAcceptCompressedMetadata() bool {
+	if m != nil {
+		return m.AcceptCompressedMetadata
+	}
+	return false
+}
+
+func (m *SnapshotsRequest) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 type SnapshotsResponse struct {
-	Height   uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Format   uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
-	Chunks   uint32 `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
-	Hash     []byte `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
-	Metadata []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Height             uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Format             uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
+	Chunks             uint32 `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	Hash               []byte `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Metadata           []byte `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	MetadataCompressed bool   `protobuf:"varint,6,opt,name=metadata_compressed,json=metadataCompressed,proto3" json:"metadata_compressed,omitempty"`
+	BaseHeight         uint64 `protobuf:"varint,7,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *SnapshotsResponse) Reset()         { *m = SnapshotsResponse{} }
@@ -299,10 +330,25 @@ func (m *SnapshotsResponse) GetMetadata() []byte {
 	return nil
 }
 
+func (m *SnapshotsResponse) GetMetadataCompressed() bool {
+	if m != nil {
+		return m.MetadataCompressed
+	}
+	return false
+}
+
+func (m *SnapshotsResponse) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 type ChunkRequest struct {
-	Height uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Format uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
-	Index  uint32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Height     uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Format     uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
+	Index      uint32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	BaseHeight uint64 `protobuf:"varint,4,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *ChunkRequest) Reset()         { *m = ChunkRequest{} }
@@ -359,12 +405,22 @@ func (m *ChunkRequest) GetIndex() uint32 {
 	return 0
 }
 
+func (m *ChunkRequest) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 type ChunkResponse struct {
-	Height  uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
-	Format  uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
-	Index   uint32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
-	Chunk   []byte `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk,omitempty"`
-	Missing bool   `protobuf:"varint,5,opt,name=missing,proto3" json:"missing,omitempty"`
+	Height            uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Format            uint32 `protobuf:"varint,2,opt,name=format,proto3" json:"format,omitempty"`
+	Index             uint32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Chunk             []byte `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Missing           bool   `protobuf:"varint,5,opt,name=missing,proto3" json:"missing,omitempty"`
+	Checksum          []byte `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	ChecksumAlgorithm string `protobuf:"bytes,7,opt,name=checksum_algorithm,json=checksumAlgorithm,proto3" json:"checksum_algorithm,omitempty"`
+	BaseHeight        uint64 `protobuf:"varint,8,opt,name=base_height,json=baseHeight,proto3" json:"base_height,omitempty"`
 }
 
 func (m *ChunkResponse) Reset()         { *m = ChunkResponse{} }
@@ -435,6 +491,27 @@ func (m *ChunkResponse) GetMissing() bool {
 	return false
 }
 
+func (m *ChunkResponse) GetChecksum() []byte {
+	if m != nil {
+		return m.Checksum
+	}
+	return nil
+}
+
+func (m *ChunkResponse) GetChecksumAlgorithm() string {
+	if m != nil {
+		return m.ChecksumAlgorithm
+	}
+	return ""
+}
+
+func (m *ChunkResponse) GetBaseHeight() uint64 {
+	if m != nil {
+		return m.BaseHeight
+	}
+	return 0
+}
+
 type LightBlockRequest struct {
 	Height uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
 }
@@ -619,6 +696,50 @@ func (m *ParamsResponse) GetConsensusParams() types.ConsensusParams {
 	return types.ConsensusParams{}
 }
 
+type Capabilities struct {
+	Version uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (m *Capabilities) Reset()         { *m = Capabilities{} }
+func (m *Capabilities) String() string { return proto.CompactTextString(m) }
+func (*Capabilities) ProtoMessage()    {}
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a1c2869546ca7914, []int{9}
+}
+func (m *Capabilities) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Capabilities) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Capabilities.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Capabilities) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Capabilities.Merge(m, src)
+}
+func (m *Capabilities) XXX_Size() int {
+	return m.Size()
+}
+func (m *Capabilities) XXX_DiscardUnknown() {
+	xxx_messageInfo_Capabilities.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Capabilities proto.InternalMessageInfo
+
+func (m *Capabilities) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Message)(nil), "tendermint.statesync.Message")
 	proto.RegisterType((*SnapshotsRequest)(nil), "tendermint.statesync.SnapshotsRequest")
@@ -629,49 +750,60 @@ func init() {
 	proto.RegisterType((*LightBlockResponse)(nil), "tendermint.statesync.LightBlockResponse")
 	proto.RegisterType((*ParamsRequest)(nil), "tendermint.statesync.ParamsRequest")
 	proto.RegisterType((*ParamsResponse)(nil), "tendermint.statesync.ParamsResponse")
+	proto.RegisterType((*Capabilities)(nil), "tendermint.statesync.Capabilities")
 }
 
 func init() { proto.RegisterFile("tendermint/statesync/types.proto", fileDescriptor_a1c2869546ca7914) }
 
 var fileDescriptor_a1c2869546ca7914 = []byte{
-	// 589 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x95, 0x4f, 0x8b, 0xd3, 0x40,
-	0x18, 0xc6, 0x13, 0xb7, 0xdd, 0x96, 0x77, 0x9b, 0x6e, 0x3b, 0x16, 0x29, 0x65, 0x8d, 0x6b, 0x14,
-	0x77, 0x41, 0x68, 0x41, 0x8f, 0xe2, 0xa5, 0x7b, 0x59, 0x61, 0x45, 0x99, 0x75, 0x41, 0x45, 0x28,
-	0x69, 0x3a, 0x26, 0xc1, 0xe6, 0x8f, 0x7d, 0xa7, 0xe0, 0x82, 0x57, 0x4f, 0x5e, 0xfc, 0x2c, 0x7e,
-	0x8a, 0x3d, 0xee, 0xd1, 0x93, 0x48, 0xfb, 0x45, 0x24, 0x93, 0x69, 0x32, 0x6d, 0xda, 0x2e, 0x82,
-	0xb7, 0xbc, 0xcf, 0x3c, 0xf9, 0xf5, 0x99, 0xc9, 0xc3, 0x14, 0x0e, 0x39, 0x0b, 0x47, 0x6c, 0x12,
-	0xf8, 0x21, 0xef, 0x21, 0xb7, 0x39, 0xc3, 0xcb, 0xd0, 0xe9, 0xf1, 0xcb, 0x98, 0x61, 0x37, 0x9e,
-	0x44, 0x3c, 0x22, 0xad, 0xdc, 0xd1, 0xcd, 0x1c, 0x9d, 0x96, 0x1b, 0xb9, 0x91, 0x30, 0xf4, 0x92,
-	0xa7, 0xd4, 0xdb, 0x39, 0x50, 0x68, 0x82, 0xa1, 0x92, 0x3a, 0x77, 0x0b, 0xab, 0xb1, 0x3d, 0xb1,
-	0x03, 0xb9, 0x6c, 0xfd, 0x2c, 0x43, 0xe5, 0x25, 0x43, 0xb4, 0x5d, 0x46, 0x2e, 0xa0, 0x89, 0xa1,
-	0x1d, 0xa3, 0x17, 0x71, 0x1c, 0x4c, 0xd8, 0xe7, 0x29, 0x43, 0xde, 0xd6, 0x0f, 0xf5, 0xe3, 0xbd,
-	0x27, 0x8f, 0xba, 0xeb, 0x02, 0x75, 0xcf, 0x17, 0x76, 0x9a, 0xba, 0x4f, 0x35, 0xda, 0xc0, 0x15,
-	0x8d, 0xbc, 0x05, 0xa2, 0x62, 0x31, 0x8e, 0x42, 0x64, 0xed, 0x5b, 0x82, 0x7b, 0x74, 0x23, 0x37,
-	0xb5, 0x9f, 0x6a, 0xb4, 0x89, 0xab, 0x22, 0x79, 0x01, 0x86, 0xe3, 0x4d, 0xc3, 0x4f, 0x59, 0xd8,
-	0x1d, 0x01, 0xb5, 0xd6, 0x43, 0x4f, 0x12, 0x6b, 0x1e, 0xb4, 0xe6, 0x28, 0x33, 0x39, 0x83, 0xfa,
-	0x02, 0x25, 0x03, 0x96, 0x04, 0xeb, 0xc1, 0x56, 0x56, 0x16, 0xce, 0x70, 0x54, 0x81, 0xbc, 0x83,
-	0xdb, 0x63, 0xdf, 0xf5, 0xf8, 0x60, 0x38, 0x8e, 0x9c, 0x3c, 0x5e, 0x79, 0xdb, 0x9e, 0xcf, 0x92,
-	0x17, 0xfa, 0x89, 0x3f, 0xcf, 0xd8, 0x1c, 0xaf, 0x8a, 0xe4, 0x03, 0xb4, 0x96, 0xd1, 0x32, 0xee,
-	0xae, 0x60, 0x1f, 0xdf, 0xcc, 0xce, 0x32, 0x93, 0x71, 0x41, 0x4d, 0x8e, 0x21, 0xad, 0x47, 0x96,
-	0xb9, 0xb2, 0xed, 0x18, 0x5e, 0x0b, 0x6f, 0x9e, 0xd7, 0x88, 0x55, 0x81, 0xbc, 0x82, 0xfd, 0x8c,
-	0x26, 0x63, 0x56, 0x05, 0xee, 0xe1, 0x76, 0x5c, 0x16, 0xb1, 0x1e, 0x2f, 0x29, 0xfd, 0x32, 0xec,
-	0xe0, 0x34, 0xb0, 0x08, 0x34, 0x56, 0x9b, 0x67, 0x7d, 0xd7, 0xa1, 0x59, 0xa8, 0x0d, 0xb9, 0x03,
-	0xbb, 0x1e, 0x4b, 0xb6, 0x29, 0x7a, 0x5c, 0xa2, 0x72, 0x4a, 0xf4, 0x8f, 0xd1, 0x24, 0xb0, 0xb9,
-	0xe8, 0xa1, 0x41, 0xe5, 0x94, 0xe8, 0xe2, 0x4b, 0xa2, 0xa8, 0x92, 0x41, 0xe5, 0x44, 0x08, 0x94,
-	0x3c, 0x1b, 0x3d, 0x51, 0x8a, 0x1a, 0x15, 0xcf, 0xa4, 0x03, 0xd5, 0x80, 0x71, 0x7b, 0x64, 0x73,
-	0x5b, 0x7c, 0xd9, 0x1a, 0xcd, 0x66, 0xeb, 0x0d, 0xd4, 0xd4, 0xba, 0xfd, 0x73, 0x8e, 0x16, 0x94,
-	0xfd, 0x70, 0xc4, 0xbe, 0xc8, 0x18, 0xe9, 0x60, 0x7d, 0xd3, 0xc1, 0x58, 0x6a, 0xde, 0xff, 0xe1,
-	0x26, 0xaa, 0xd8, 0xa7, 0xdc, 0x5e, 0x3a, 0x90, 0x36, 0x54, 0x02, 0x1f, 0xd1, 0x0f, 0x5d, 0xb1,
-	0xbd, 0x2a, 0x5d, 0x8c, 0xd6, 0x63, 0x68, 0x16, 0xda, 0xba, 0x29, 0x8a, 0x75, 0x0e, 0xa4, 0x58,
-	0x3f, 0xf2, 0x1c, 0xf6, 0x94, 0x1a, 0xcb, 0x5b, 0xe6, 0x40, 0xad, 0x45, 0x7a, 0x89, 0x29, 0xaf,
-	0x42, 0xde, 0x57, 0xeb, 0x08, 0x8c, 0xa5, 0xee, 0x6d, 0xfc, 0xf5, 0xaf, 0x50, 0x5f, 0x6e, 0xd5,
-	0xc6, 0x23, 0xa3, 0xd0, 0x70, 0x12, 0x43, 0x88, 0x53, 0x1c, 0xa4, 0xbd, 0x93, 0x97, 0xd4, 0xfd,
-	0x62, 0xac, 0x93, 0x85, 0x33, 0x85, 0xf7, 0x4b, 0x57, 0xbf, 0xef, 0x69, 0x74, 0xdf, 0x59, 0x91,
-	0x2f, 0xae, 0x66, 0xa6, 0x7e, 0x3d, 0x33, 0xf5, 0x3f, 0x33, 0x53, 0xff, 0x31, 0x37, 0xb5, 0xeb,
-	0xb9, 0xa9, 0xfd, 0x9a, 0x9b, 0xda, 0xfb, 0x67, 0xae, 0xcf, 0xbd, 0xe9, 0xb0, 0xeb, 0x44, 0x41,
-	0x4f, 0xbd, 0xa1, 0xf3, 0xc7, 0xf4, 0x9e, 0x5f, 0xf7, 0x4f, 0x31, 0xdc, 0x15, 0x6b, 0x4f, 0xff,
-	0x06, 0x00, 0x00, 0xff, 0xff, 0xa1, 0xb2, 0xfd, 0x65, 0x48, 0x06, 0x00, 0x00,
+	// 738 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0x4f, 0x6b, 0x13, 0x41,
+	0x14, 0xcf, 0xda, 0xa4, 0x49, 0x5f, 0xb3, 0x6d, 0x33, 0x0d, 0x12, 0x42, 0x4d, 0x6b, 0x14, 0x5b,
+	0x10, 0x13, 0xd0, 0xa3, 0x7a, 0x30, 0xbd, 0x44, 0x68, 0x51, 0xa6, 0x14, 0x54, 0x84, 0x65, 0x32,
+	0x19, 0x77, 0x97, 0x66, 0xff, 0x74, 0x67, 0x56, 0x2c, 0xf8, 0x21, 0xfc, 0x58, 0x3d, 0xf6, 0xe8,
+	0x45, 0x91, 0xf6, 0x13, 0x78, 0xf7, 0x20, 0x3b, 0xfb, 0x6f, 0xb2, 0xdb, 0xa6, 0x08, 0xde, 0xe6,
+	0xbd, 0xf7, 0x9b, 0xdf, 0xfc, 0xde, 0x9b, 0x37, 0x6f, 0x60, 0x47, 0x30, 0x77, 0xca, 0x02, 0xc7,
+	0x76, 0xc5, 0x90, 0x0b, 0x22, 0x18, 0x3f, 0x73, 0xe9, 0x50, 0x9c, 0xf9, 0x8c, 0x0f, 0xfc, 0xc0,
+	0x13, 0x1e, 0x6a, 0xe7, 0x88, 0x41, 0x86, 0xe8, 0xb6, 0x4d, 0xcf, 0xf4, 0x24, 0x60, 0x18, 0xad,
+	0x62, 0x6c, 0x77, 0x4b, 0x61, 0x93, 0x1c, 0x2a, 0x53, 0xf7, 0x5e, 0x29, 0xea, 0x93, 0x80, 0x38,
+	0x49, 0xb8, 0xff, 0xa7, 0x06, 0xf5, 0x43, 0xc6, 0x39, 0x31, 0x19, 0x3a, 0x86, 0x16, 0x77, 0x89,
+	0xcf, 0x2d, 0x4f, 0x70, 0x23, 0x60, 0xa7, 0x21, 0xe3, 0xa2, 0xa3, 0xed, 0x68, 0x7b, 0xab, 0x4f,
+	0x1f, 0x0d, 0xae, 0x13, 0x34, 0x38, 0x4a, 0xe1, 0x38, 0x46, 0x8f, 0x2b, 0x78, 0x83, 0x17, 0x7c,
+	0xe8, 0x1d, 0x20, 0x95, 0x96, 0xfb, 0x9e, 0xcb, 0x59, 0xe7, 0x8e, 0xe4, 0xdd, 0xbd, 0x95, 0x37,
+	0x86, 0x8f, 0x2b, 0xb8, 0xc5, 0x8b, 0x4e, 0xf4, 0x1a, 0x74, 0x6a, 0x85, 0xee, 0x49, 0x26, 0x76,
+	0x49, 0x92, 0xf6, 0xaf, 0x27, 0xdd, 0x8f, 0xa0, 0xb9, 0xd0, 0x26, 0x55, 0x6c, 0x74, 0x00, 0x6b,
+	0x29, 0x55, 0x22, 0xb0, 0x2a, 0xb9, 0x1e, 0x2c, 0xe4, 0xca, 0xc4, 0xe9, 0x54, 0x75, 0xa0, 0xf7,
+	0xb0, 0x39, 0xb3, 0x4d, 0x4b, 0x18, 0x93, 0x99, 0x47, 0x73, 0x79, 0xb5, 0x45, 0x39, 0x1f, 0x44,
+	0x1b, 0x46, 0x11, 0x3e, 0xd7, 0xd8, 0x9a, 0x15, 0x9d, 0xe8, 0x23, 0xb4, 0xe7, 0xa9, 0x13, 0xb9,
+	0xcb, 0x92, 0x7b, 0xef, 0x76, 0xee, 0x4c, 0x33, 0x9a, 0x95, 0xbc, 0x51, 0x19, 0xe2, 0xf6, 0xc8,
+	0x34, 0xd7, 0x17, 0x95, 0xe1, 0xad, 0xc4, 0xe6, 0x7a, 0x75, 0x5f, 0x75, 0xa0, 0x37, 0xb0, 0x9e,
+	0xb1, 0x25, 0x32, 0x1b, 0x92, 0xee, 0xe1, 0x62, 0xba, 0x4c, 0xe2, 0x9a, 0x3f, 0xe7, 0x41, 0x63,
+	0x68, 0x52, 0xe2, 0x93, 0x89, 0x3d, 0xb3, 0x85, 0xcd, 0x78, 0x67, 0x65, 0xe1, 0x7d, 0x2b, 0x48,
+	0x79, 0xdf, 0x8a, 0x3d, 0xaa, 0xc1, 0x12, 0x0f, 0x9d, 0xfe, 0x29, 0x6c, 0x14, 0x7b, 0x18, 0xbd,
+	0x80, 0x2e, 0xa1, 0x94, 0xf9, 0xc2, 0xa0, 0x9e, 0xe3, 0x07, 0x8c, 0x73, 0x36, 0x35, 0x1c, 0x26,
+	0xc8, 0x94, 0x08, 0x22, 0xdf, 0x43, 0x03, 0x77, 0x62, 0xc4, 0x7e, 0x06, 0x38, 0x4c, 0xe2, 0x68,
+	0x1b, 0x56, 0x27, 0x84, 0x33, 0xc3, 0x62, 0x51, 0x75, 0x65, 0x9b, 0x57, 0x31, 0x44, 0xae, 0xb1,
+	0xf4, 0xf4, 0x7f, 0x68, 0xd0, 0x2a, 0xf5, 0x37, 0xba, 0x0b, 0xcb, 0xc9, 0x0e, 0x4d, 0xee, 0x48,
+	0xac, 0xc8, 0xff, 0xc9, 0x0b, 0x1c, 0x12, 0x33, 0xe9, 0x38, 0xb1, 0x22, 0xbf, 0x6c, 0x39, 0x2e,
+	0x7b, 0x5e, 0xc7, 0x89, 0x85, 0x10, 0x54, 0x2d, 0xc2, 0x2d, 0xd9, 0xbd, 0x4d, 0x2c, 0xd7, 0xa8,
+	0x0b, 0x8d, 0x4c, 0x7e, 0x4d, 0xfa, 0x33, 0x1b, 0x0d, 0x61, 0x33, 0x5d, 0x2b, 0xe9, 0xca, 0x6e,
+	0x6a, 0x60, 0x94, 0x86, 0xf2, 0x3c, 0x8b, 0xf9, 0xd5, 0x4b, 0xf9, 0x85, 0xd0, 0x54, 0x5f, 0xda,
+	0x3f, 0x67, 0xd6, 0x86, 0x9a, 0xed, 0x4e, 0xd9, 0x97, 0x24, 0xb1, 0xd8, 0x28, 0x1e, 0x5b, 0x2d,
+	0x1d, 0xfb, 0x5b, 0x03, 0x7d, 0xee, 0x55, 0xfe, 0xa7, 0x83, 0xdb, 0x50, 0x93, 0xa5, 0x4d, 0x2a,
+	0x1a, 0x1b, 0xa8, 0x03, 0x75, 0xc7, 0xe6, 0xdc, 0x76, 0x4d, 0x59, 0xd1, 0x06, 0x4e, 0xcd, 0xa8,
+	0xd8, 0xd4, 0x62, 0xf4, 0x84, 0x87, 0x8e, 0xac, 0x62, 0x13, 0x67, 0x36, 0x7a, 0x02, 0x28, 0x5d,
+	0x1b, 0x64, 0x66, 0x7a, 0x81, 0x2d, 0x2c, 0x47, 0x96, 0x70, 0x05, 0xb7, 0xd2, 0xc8, 0xab, 0x34,
+	0x50, 0xcc, 0xb9, 0x51, 0xca, 0xf9, 0x31, 0xb4, 0x4a, 0x53, 0xe3, 0xa6, 0xb4, 0xfb, 0x47, 0x80,
+	0xca, 0x63, 0x00, 0xbd, 0x84, 0x55, 0x65, 0x9c, 0x24, 0xd3, 0x7e, 0x4b, 0x7d, 0x50, 0xf1, 0x67,
+	0xa2, 0x6c, 0x85, 0x7c, 0x6e, 0xf4, 0x77, 0x41, 0x9f, 0x9b, 0x01, 0x37, 0x9e, 0xfe, 0x15, 0xd6,
+	0xe6, 0x5f, 0xf7, 0x8d, 0xd7, 0x83, 0x61, 0x83, 0x46, 0x00, 0x97, 0x87, 0xdc, 0x88, 0xdf, 0x7f,
+	0xf2, 0x59, 0xdc, 0x2f, 0xcb, 0xda, 0x4f, 0x91, 0x31, 0xf9, 0xa8, 0x7a, 0xfe, 0x73, 0xbb, 0x82,
+	0xd7, 0xe9, 0xbc, 0xbb, 0xbf, 0x07, 0x4d, 0x75, 0x1a, 0x44, 0xd7, 0xf7, 0x99, 0x05, 0xdc, 0xf6,
+	0x5c, 0x79, 0xb8, 0x8e, 0x53, 0x73, 0x74, 0x7c, 0x7e, 0xd9, 0xd3, 0x2e, 0x2e, 0x7b, 0xda, 0xaf,
+	0xcb, 0x9e, 0xf6, 0xed, 0xaa, 0x57, 0xb9, 0xb8, 0xea, 0x55, 0xbe, 0x5f, 0xf5, 0x2a, 0x1f, 0x9e,
+	0x9b, 0xb6, 0xb0, 0xc2, 0xc9, 0x80, 0x7a, 0xce, 0x50, 0xfd, 0x53, 0xf3, 0x65, 0xfc, 0x33, 0x5f,
+	0xf7, 0xb7, 0x4f, 0x96, 0x65, 0xec, 0xd9, 0xdf, 0x01, 0x00, 0x8f, 0x42, 0x2b, 0xaf, 0xfa, 0x07,
+	0x00, 0x00,
 }
 
 func (m *Message) Marshal() (dAtA []byte, err error) {
@@ -874,6 +1006,27 @@ func (m *Message_ParamsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	}
 	return len(dAtA) - i, nil
 }
+func (m *Message_Capabilities) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_Capabilities) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Capabilities != nil {
+		{
+			size, err := m.Capabilities.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4a
+	}
+	return len(dAtA) - i, nil
+}
 func (m *SnapshotsRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -894,6 +1047,21 @@ func (m *SnapshotsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.AcceptCompressedMetadata {
+		i--
+		if m.AcceptCompressedMetadata {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
@@ -917,6 +1085,21 @@ func (m *SnapshotsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.MetadataCompressed {
+		i--
+		if m.MetadataCompressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Metadata) > 0 {
 		i -= len(m.Metadata)
 		copy(dAtA[i:], m.Metadata)
@@ -969,6 +1152,11 @@ func (m *ChunkRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Index != 0 {
 		i = encodeVarintTypes(dAtA, i, uint64(m.Index))
 		i--
@@ -1007,6 +1195,25 @@ func (m *ChunkResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BaseHeight != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.BaseHeight))
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.ChecksumAlgorithm) > 0 {
+		i -= len(m.ChecksumAlgorithm)
+		copy(dAtA[i:], m.ChecksumAlgorithm)
+		i = encodeVarintTypes(dAtA, i, uint64(len(m.ChecksumAlgorithm)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Checksum) > 0 {
+		i -= len(m.Checksum)
+		copy(dAtA[i:], m.Checksum)
+		i = encodeVarintTypes(dAtA, i, uint64(len(m.Checksum)))
+		i--
+		dAtA[i] = 0x32
+	}
 	if m.Missing {
 		i--
 		if m.Missing {
@@ -1171,6 +1378,34 @@ func (m *ParamsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Capabilities) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Capabilities) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Capabilities) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Version != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.Version))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintTypes(dAtA []byte, offset int, v uint64) int {
 	offset -= sovTypes(v)
 	base := offset
@@ -1290,12 +1525,30 @@ func (m *Message_ParamsResponse) Size() (n int) {
 	}
 	return n
 }
+func (m *Message_Capabilities) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Capabilities != nil {
+		l = m.Capabilities.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	return n
+}
 func (m *SnapshotsRequest) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
+	if m.AcceptCompressedMetadata {
+		n += 2
+	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -1322,6 +1575,12 @@ func (m *SnapshotsResponse) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if m.MetadataCompressed {
+		n += 2
+	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -1340,6 +1599,9 @@ func (m *ChunkRequest) Size() (n int) {
 	if m.Index != 0 {
 		n += 1 + sovTypes(uint64(m.Index))
 	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -1365,6 +1627,17 @@ func (m *ChunkResponse) Size() (n int) {
 	if m.Missing {
 		n += 2
 	}
+	l = len(m.Checksum)
+	if l > 0 {
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	l = len(m.ChecksumAlgorithm)
+	if l > 0 {
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	if m.BaseHeight != 0 {
+		n += 1 + sovTypes(uint64(m.BaseHeight))
+	}
 	return n
 }
 
@@ -1419,6 +1692,18 @@ func (m *ParamsResponse) Size() (n int) {
 	return n
 }
 
+func (m *Capabilities) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Version != 0 {
+		n += 1 + sovTypes(uint64(m.Version))
+	}
+	return n
+}
+
 func sovTypes(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1734,6 +2019,41 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 			}
 			m.Sum = &Message_ParamsResponse{v}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Capabilities", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Capabilities{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Sum = &Message_Capabilities{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -1784,6 +2104,45 @@ func (m *SnapshotsRequest) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: SnapshotsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptCompressedMetadata", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AcceptCompressedMetadata = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -1959,6 +2318,45 @@ func (m *SnapshotsResponse) Unmarshal(dAtA []byte) error {
 				m.Metadata = []byte{}
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MetadataCompressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.MetadataCompressed = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -2066,6 +2464,25 @@ func (m *ChunkRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -2227,6 +2644,91 @@ func (m *ChunkResponse) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Missing = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Checksum", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Checksum = append(m.Checksum[:0], dAtA[iNdEx:postIndex]...)
+			if m.Checksum == nil {
+				m.Checksum = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChecksumAlgorithm", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ChecksumAlgorithm = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaseHeight", wireType)
+			}
+			m.BaseHeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BaseHeight |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -2574,6 +3076,75 @@ func (m *ParamsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *Capabilities) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Capabilities: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Capabilities: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipTypes(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
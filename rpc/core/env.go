@@ -10,6 +10,7 @@ import (
 	"github.com/tendermint/tendermint/internal/consensus"
 	mempl "github.com/tendermint/tendermint/internal/mempool"
 	"github.com/tendermint/tendermint/internal/p2p"
+	"github.com/tendermint/tendermint/internal/statesync"
 	tmjson "github.com/tendermint/tendermint/libs/json"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/proxy"
@@ -63,6 +64,10 @@ type consensusReactor interface {
 	GetPeerState(peerID types.NodeID) (*consensus.PeerState, bool)
 }
 
+type stateSyncReactor interface {
+	Snapshots() ([]statesync.SnapshotInfo, error)
+}
+
 type peerManager interface {
 	Peers() []types.NodeID
 	Addresses(types.NodeID) []p2p.NodeAddress
@@ -97,6 +102,7 @@ type Environment struct {
 	EventBus         *types.EventBus // thread safe
 	Mempool          mempl.Mempool
 	BlockSyncReactor consensus.BlockSyncReactor
+	StateSyncReactor stateSyncReactor
 
 	Logger log.Logger
 
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"errors"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// StateSyncSnapshots returns the snapshots discovered by the state sync in
+// progress along with their acceptance status: pending, applying or rejected.
+func (env *Environment) StateSyncSnapshots(ctx *rpctypes.Context) (*ctypes.ResultStateSyncSnapshots, error) {
+	if env.StateSyncReactor == nil {
+		return nil, errors.New("state sync reactor is not available")
+	}
+
+	snapshots, err := env.StateSyncReactor.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ctypes.ResultStateSyncSnapshots{
+		Snapshots: make([]ctypes.SnapshotInfo, 0, len(snapshots)),
+	}
+	for _, s := range snapshots {
+		result.Snapshots = append(result.Snapshots, ctypes.SnapshotInfo{
+			Height: s.Height,
+			Format: s.Format,
+			Chunks: s.Chunks,
+			Hash:   s.Hash,
+			Peers:  s.Peers,
+			Status: string(s.Status),
+
+			RejectReason: s.RejectReason,
+		})
+	}
+	return result, nil
+}
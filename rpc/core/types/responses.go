@@ -251,6 +251,23 @@ type ResultBroadcastEvidence struct {
 	Hash []byte `json:"hash"`
 }
 
+// SnapshotInfo describes a snapshot discovered during state sync
+type SnapshotInfo struct {
+	Height uint64         `json:"height"`
+	Format uint32         `json:"format"`
+	Chunks uint32         `json:"chunks"`
+	Hash   bytes.HexBytes `json:"hash"`
+	Peers  int            `json:"peers"`
+	Status string         `json:"status"`
+
+	RejectReason string `json:"reject_reason,omitempty"`
+}
+
+// Snapshots discovered by the state sync in progress
+type ResultStateSyncSnapshots struct {
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
 // empty results
 type (
 	ResultUnsafeFlushMempool struct{}
@@ -3,6 +3,7 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,10 @@ type Config struct {
 	// MaxBodyBytes controls the maximum number of bytes the
 	// server will read parsing the request body.
 	MaxBodyBytes int64
+	// MaxResponseBodyBytes controls the maximum number of bytes of an HTTP
+	// response body. Larger responses are replaced by an error. 0 means
+	// unlimited.
+	MaxResponseBodyBytes int64
 	// mirrors http.Server#MaxHeaderBytes
 	MaxHeaderBytes int
 }
@@ -46,14 +51,15 @@ func DefaultConfig() *Config {
 }
 
 // Serve creates a http.Server and calls Serve with the given listener. It
-// wraps handler with RecoverAndLogHandler and a handler, which limits the max
-// body size to config.MaxBodyBytes.
+// wraps handler with RecoverAndLogHandler and handlers, which limit the max
+// request body size to config.MaxBodyBytes and the max response body size to
+// config.MaxResponseBodyBytes.
 //
 // NOTE: This function blocks - you may want to call it in a go-routine.
 func Serve(listener net.Listener, handler http.Handler, logger log.Logger, config *Config) error {
 	logger.Info(fmt.Sprintf("Starting RPC HTTP server on %s", listener.Addr()))
 	s := &http.Server{
-		Handler:        RecoverAndLogHandler(maxBytesHandler{h: handler, n: config.MaxBodyBytes}, logger),
+		Handler:        RecoverAndLogHandler(limitHandler(handler, config), logger),
 		ReadTimeout:    config.ReadTimeout,
 		WriteTimeout:   config.WriteTimeout,
 		MaxHeaderBytes: config.MaxHeaderBytes,
@@ -64,8 +70,9 @@ func Serve(listener net.Listener, handler http.Handler, logger log.Logger, confi
 }
 
 // Serve creates a http.Server and calls ServeTLS with the given listener,
-// certFile and keyFile. It wraps handler with RecoverAndLogHandler and a
-// handler, which limits the max body size to config.MaxBodyBytes.
+// certFile and keyFile. It wraps handler with RecoverAndLogHandler and
+// handlers, which limit the max request body size to config.MaxBodyBytes and
+// the max response body size to config.MaxResponseBodyBytes.
 //
 // NOTE: This function blocks - you may want to call it in a go-routine.
 func ServeTLS(
@@ -78,7 +85,7 @@ func ServeTLS(
 	logger.Info(fmt.Sprintf("Starting RPC HTTPS server on %s (cert: %q, key: %q)",
 		listener.Addr(), certFile, keyFile))
 	s := &http.Server{
-		Handler:        RecoverAndLogHandler(maxBytesHandler{h: handler, n: config.MaxBodyBytes}, logger),
+		Handler:        RecoverAndLogHandler(limitHandler(handler, config), logger),
 		ReadTimeout:    config.ReadTimeout,
 		WriteTimeout:   config.WriteTimeout,
 		MaxHeaderBytes: config.MaxHeaderBytes,
@@ -249,6 +256,15 @@ func (w *responseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return w.ResponseWriter.(http.Hijacker).Hijack()
 }
 
+// limitHandler wraps handler with the request and response body size limits
+// of config.
+func limitHandler(handler http.Handler, config *Config) http.Handler {
+	if config.MaxResponseBodyBytes > 0 {
+		handler = maxResponseBytesHandler{h: handler, n: config.MaxResponseBodyBytes}
+	}
+	return maxBytesHandler{h: handler, n: config.MaxBodyBytes}
+}
+
 type maxBytesHandler struct {
 	h http.Handler
 	n int64
@@ -259,6 +275,67 @@ func (h maxBytesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.h.ServeHTTP(w, r)
 }
 
+// maxResponseBytesHandler buffers the response of h, replacing it by an
+// internal error if its body exceeds n bytes.
+type maxResponseBytesHandler struct {
+	h http.Handler
+	n int64
+}
+
+func (h maxResponseBytesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lw := &limitedResponseWriter{ResponseWriter: w, n: h.n, status: http.StatusOK}
+	h.h.ServeHTTP(lw, r)
+	if lw.hijacked {
+		return
+	}
+
+	if lw.exceeded {
+		// the oversized response must not be cached
+		w.Header().Del("Cache-Control")
+		res := types.RPCInternalError(types.JSONRPCIntID(-1),
+			fmt.Errorf("response body exceeds the maximum of %d bytes", h.n))
+		_ = WriteRPCResponseHTTPError(w, res)
+		return
+	}
+
+	w.WriteHeader(lw.status)
+	_, _ = w.Write(lw.buf.Bytes())
+}
+
+// limitedResponseWriter holds the status and body of a response until it is
+// complete, discarding the body once it exceeds n bytes.
+type limitedResponseWriter struct {
+	http.ResponseWriter
+	n        int64
+	status   int
+	buf      bytes.Buffer
+	exceeded bool
+	hijacked bool
+}
+
+func (w *limitedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *limitedResponseWriter) Write(b []byte) (int, error) {
+	if w.exceeded || int64(w.buf.Len()+len(b)) > w.n {
+		w.exceeded = true
+		w.buf.Reset()
+		return 0, errors.New("response body too large")
+	}
+	return w.buf.Write(b)
+}
+
+// implements http.Hijacker, so that websocket connections, whose messages
+// aren't limited, can be upgraded
+func (w *limitedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
 // Listen starts a new net.Listener on the given address.
 // It returns an error if the address is invalid or the call to Listen() fails.
 func Listen(addr string, maxOpenConnections int) (listener net.Listener, err error) {
@@ -2,12 +2,14 @@ package server
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -106,6 +108,86 @@ func TestServeTLS(t *testing.T) {
 	assert.Equal(t, []byte("some body"), body)
 }
 
+func TestMaxBodyBytes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		fmt.Fprint(w, strings.Repeat("a", 100))
+	})
+	config := DefaultConfig()
+	config.MaxBodyBytes = 10
+	l, err := Listen("tcp://127.0.0.1:0", 0)
+	require.NoError(t, err)
+	defer l.Close()
+	go Serve(l, mux, log.TestingLogger(), config) //nolint:errcheck // ignore for tests
+
+	// responses larger than the request limit are served
+	res, err := http.Post("http://"+l.Addr().String(), "text/plain", strings.NewReader("small"))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, body, 100)
+
+	res, err = http.Post("http://"+l.Addr().String(), "text/plain", strings.NewReader(strings.Repeat("a", 11)))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
+
+func TestMaxResponseBodyBytes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=31536000")
+		fmt.Fprint(w, strings.Repeat("a", 10))
+		fmt.Fprint(w, strings.Repeat("a", 10))
+	})
+	config := DefaultConfig()
+	config.MaxBodyBytes = 1000
+	config.MaxResponseBodyBytes = 20
+	l, err := Listen("tcp://127.0.0.1:0", 0)
+	require.NoError(t, err)
+	defer l.Close()
+	go Serve(l, mux, log.TestingLogger(), config) //nolint:errcheck // ignore for tests
+
+	// requests larger than the response limit are accepted
+	res, err := http.Post("http://"+l.Addr().String(), "text/plain", strings.NewReader(strings.Repeat("a", 100)))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, strings.Repeat("a", 20), string(body))
+
+	// and responses larger than the limit are replaced by an error
+	config.MaxResponseBodyBytes = 19
+	l2, err := Listen("tcp://127.0.0.1:0", 0)
+	require.NoError(t, err)
+	defer l2.Close()
+	go Serve(l2, mux, log.TestingLogger(), config) //nolint:errcheck // ignore for tests
+
+	res, err = http.Get("http://" + l2.Addr().String())
+	require.NoError(t, err)
+	body, err = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Cache-Control"))
+
+	var rpcRes types.RPCResponse
+	require.NoError(t, json.Unmarshal(body, &rpcRes))
+	require.NotNil(t, rpcRes.Error)
+	assert.Contains(t, rpcRes.Error.Data, "exceeds the maximum of 19 bytes")
+}
+
 func TestWriteRPCResponseHTTP(t *testing.T) {
 	id := types.JSONRPCIntID(-1)
 
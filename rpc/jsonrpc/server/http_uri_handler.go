@@ -93,6 +93,27 @@ func makeHTTPHandler(rpcFunc *RPCFunc, logger log.Logger) func(http.ResponseWrit
 	}
 }
 
+// Call calls the function with the arguments parsed from the URI query of r,
+// as the handler registered for it by RegisterRPCFuncs does, and returns its
+// result. It allows handlers to encode results other than as JSON.
+func (f *RPCFunc) Call(r *http.Request) (interface{}, error) {
+	if f.ws {
+		return nil, errors.New("function is only available over websocket")
+	}
+
+	args := []reflect.Value{reflect.ValueOf(&types.Context{HTTPReq: r})}
+	fnArgs, err := httpParamsToArgs(f, r)
+	if err != nil {
+		return nil, fmt.Errorf("error converting http params to arguments: %w", err)
+	}
+	returns := f.f.Call(append(args, fnArgs...))
+
+	if err, ok := returns[1].Interface().(error); ok && err != nil {
+		return nil, err
+	}
+	return returns[0].Interface(), nil
+}
+
 // Covert an http query to a list of properly typed values.
 // To be properly decoded the arg must be a concrete type from tendermint (if its an interface).
 func httpParamsToArgs(rpcFunc *RPCFunc, r *http.Request) ([]reflect.Value, error) {
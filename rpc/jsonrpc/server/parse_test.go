@@ -211,3 +211,28 @@ func TestParseURI(t *testing.T) {
 
 	}
 }
+
+func TestRPCFuncCall(t *testing.T) {
+	demo := func(ctx *types.Context, height int, name string) (string, error) {
+		if height < 0 {
+			return "", fmt.Errorf("negative height %d", height)
+		}
+		return fmt.Sprintf("%s@%d", name, height), nil
+	}
+	call := NewRPCFunc(demo, "height,name", false)
+
+	req, err := http.NewRequest("GET", `test.com/method?height=7&name="flew"`, nil)
+	assert.NoError(t, err)
+	res, err := call.Call(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "flew@7", res)
+
+	req, err = http.NewRequest("GET", `test.com/method?height=-1&name="flew"`, nil)
+	assert.NoError(t, err)
+	_, err = call.Call(req)
+	assert.Error(t, err)
+
+	// websocket only functions can't be called
+	_, err = NewWSRPCFunc(demo, "height,name").Call(req)
+	assert.Error(t, err)
+}
@@ -253,5 +253,8 @@ func (es *EventSink) HasBlock(h int64) (bool, error) {
 	return false, errors.New("hasBlock is not supported via the postgres event sink")
 }
 
+// Ping verifies that the underlying PostgreSQL database is reachable.
+func (es *EventSink) Ping(ctx context.Context) error { return es.store.PingContext(ctx) }
+
 // Stop closes the underlying PostgreSQL database.
 func (es *EventSink) Stop() error { return es.store.Close() }
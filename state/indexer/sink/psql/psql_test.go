@@ -199,6 +199,11 @@ func TestIndexing(t *testing.T) {
 	})
 }
 
+func TestPing(t *testing.T) {
+	indexer := &EventSink{store: testDB()}
+	require.NoError(t, indexer.Ping(context.Background()))
+}
+
 func TestStop(t *testing.T) {
 	indexer := &EventSink{store: testDB()}
 	require.NoError(t, indexer.Stop())
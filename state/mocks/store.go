@@ -95,6 +95,29 @@ func (_m *Store) LoadConsensusParams(_a0 int64) (types.ConsensusParams, error) {
 	return r0, r1
 }
 
+// LoadValidatorSetHeights provides a mock function with given fields:
+func (_m *Store) LoadValidatorSetHeights() ([]state.HeightRange, error) {
+	ret := _m.Called()
+
+	var r0 []state.HeightRange
+	if rf, ok := ret.Get(0).(func() []state.HeightRange); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]state.HeightRange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LoadValidators provides a mock function with given fields: _a0
 func (_m *Store) LoadValidators(_a0 int64) (*types.ValidatorSet, error) {
 	ret := _m.Called(_a0)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/google/orderedcode"
@@ -89,6 +90,8 @@ type Store interface {
 	SaveABCIResponses(int64, *tmstate.ABCIResponses) error
 	// SaveValidatorSet saves the validator set at a given height
 	SaveValidatorSets(int64, int64, *types.ValidatorSet) error
+	// LoadValidatorSetHeights returns the ranges of heights for which a validator set is stored
+	LoadValidatorSetHeights() ([]HeightRange, error)
 	// Bootstrap is used for bootstrapping state when not starting from a initial height.
 	Bootstrap(State) error
 	// PruneStates takes the height from which to prune up to (exclusive)
@@ -524,6 +527,52 @@ func (store dbStore) LoadValidators(height int64) (*types.ValidatorSet, error) {
 	return vip, nil
 }
 
+// HeightRange is an inclusive range of heights.
+type HeightRange struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+// LoadValidatorSetHeights returns the ranges of consecutive heights for which
+// a validator set is stored, in ascending order. It includes the heights saved
+// through Save as well as SaveValidatorSets, for instance during backfill.
+func (store dbStore) LoadValidatorSetHeights() ([]HeightRange, error) {
+	iter, err := store.db.Iterator(validatorsKey(0), validatorsKey(math.MaxInt64))
+	if err != nil {
+		return nil, fmt.Errorf("iterator error: %w", err)
+	}
+	defer iter.Close()
+
+	ranges := []HeightRange{}
+	for ; iter.Valid(); iter.Next() {
+		height, err := decodeValidatorsKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].Max == height-1 {
+			ranges[n-1].Max = height
+			continue
+		}
+		ranges = append(ranges, HeightRange{Min: height, Max: height})
+	}
+	return ranges, iter.Error()
+}
+
+func decodeValidatorsKey(key []byte) (height int64, err error) {
+	var prefix int64
+	remaining, err := orderedcode.Parse(string(key), &prefix, &height)
+	if err != nil {
+		return
+	}
+	if len(remaining) != 0 {
+		return -1, fmt.Errorf("expected complete key but got remainder: %s", remaining)
+	}
+	if prefix != prefixValidators {
+		return -1, fmt.Errorf("incorrect prefix. Expected %v, got %v", prefixValidators, prefix)
+	}
+	return
+}
+
 func lastStoredHeightFor(height, lastHeightChanged int64) int64 {
 	checkpointHeight := height - height%valSetCheckpointInterval
 	return tmmath.MaxInt64(checkpointHeight, lastHeightChanged)
@@ -139,6 +139,25 @@ func BenchmarkLoadValidators(b *testing.B) {
 	}
 }
 
+func TestStoreLoadValidatorSetHeights(t *testing.T) {
+	stateDB := dbm.NewMemDB()
+	stateStore := sm.NewStore(stateDB)
+	vals, _ := factory.RandValidatorSet(3, 10)
+
+	ranges, err := stateStore.LoadValidatorSetHeights()
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	// two ranges saved by backfill and one adjacent to the second
+	require.NoError(t, stateStore.SaveValidatorSets(3, 5, vals))
+	require.NoError(t, stateStore.SaveValidatorSets(10, 12, vals))
+	require.NoError(t, stateStore.SaveValidatorSets(13, 13, vals))
+
+	ranges, err = stateStore.LoadValidatorSetHeights()
+	require.NoError(t, err)
+	require.Equal(t, []sm.HeightRange{{Min: 3, Max: 5}, {Min: 10, Max: 13}}, ranges)
+}
+
 func TestStoreLoadConsensusParams(t *testing.T) {
 	stateDB := dbm.NewMemDB()
 	stateStore := sm.NewStore(stateDB)
@@ -552,6 +552,48 @@ func (bs *BlockStore) SaveSignedHeader(sh *types.SignedHeader, blockID types.Blo
 	return batch.Close()
 }
 
+// SaveBackfillCheckpoint persists a light block as a backfill checkpoint.
+// Checkpoints are stored separately from the block metas and commits so that
+// a partially backfilled range can be verified independently.
+func (bs *BlockStore) SaveBackfillCheckpoint(lb *types.LightBlock) error {
+	pblb, err := lb.ToProto()
+	if err != nil {
+		return fmt.Errorf("unable to convert light block to proto: %w", err)
+	}
+	return bs.db.SetSync(backfillCheckpointKey(lb.Height), mustEncode(pblb))
+}
+
+// LoadBackfillCheckpoints returns all persisted backfill checkpoints in
+// ascending order of height.
+func (bs *BlockStore) LoadBackfillCheckpoints() ([]*types.LightBlock, error) {
+	iter, err := bs.db.Iterator(
+		backfillCheckpointKey(1),
+		backfillCheckpointKey(1<<63-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	checkpoints := make([]*types.LightBlock, 0)
+	for ; iter.Valid(); iter.Next() {
+		pblb := new(tmproto.LightBlock)
+		if err := proto.Unmarshal(iter.Value(), pblb); err != nil {
+			return nil, fmt.Errorf("unmarshal to tmproto.LightBlock: %w", err)
+		}
+		lb, err := types.LightBlockFromProto(pblb)
+		if err != nil {
+			return nil, fmt.Errorf("error from proto light block: %w", err)
+		}
+		checkpoints = append(checkpoints, lb)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	return checkpoints, nil
+}
+
 //---------------------------------- KEY ENCODING -----------------------------------------
 
 // key prefixes
@@ -562,6 +604,8 @@ const (
 	prefixBlockCommit = int64(2)
 	prefixSeenCommit  = int64(3)
 	prefixBlockHash   = int64(4)
+
+	prefixBackfillCheckpoint = int64(13)
 )
 
 func blockMetaKey(height int64) []byte {
@@ -619,6 +663,14 @@ func blockHashKey(hash []byte) []byte {
 	return key
 }
 
+func backfillCheckpointKey(height int64) []byte {
+	key, err := orderedcode.Append(nil, prefixBackfillCheckpoint, height)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
 //-----------------------------------------------------------------------------
 
 // mustEncode proto encodes a proto.message and panics if fails
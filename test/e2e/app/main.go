@@ -187,6 +187,7 @@ func startLightNode(cfg *Config) error {
 
 	rpccfg := rpcserver.DefaultConfig()
 	rpccfg.MaxBodyBytes = tmcfg.RPC.MaxBodyBytes
+	rpccfg.MaxResponseBodyBytes = tmcfg.RPC.MaxResponseBodyBytes
 	rpccfg.MaxHeaderBytes = tmcfg.RPC.MaxHeaderBytes
 	rpccfg.MaxOpenConnections = tmcfg.RPC.MaxOpenConnections
 	// If necessary adjust global WriteTimeout to ensure it's greater than